@@ -0,0 +1,36 @@
+package autodiff
+
+import "go-neuralnetwork/internal/neuralnetwork"
+
+// Activate applies an activation function from the neuralnetwork package to every
+// element. The activation's Derivative is evaluated on the activated output, following
+// the convention used there.
+func Activate(a *Tensor, activation neuralnetwork.Activation) *Tensor {
+	return unary(a, activation.Activate, func(x, out float64) float64 {
+		return activation.Derivative(out)
+	})
+}
+
+// ActivateByName applies the activation function registered under name.
+func ActivateByName(a *Tensor, name string) (*Tensor, error) {
+	activation, err := neuralnetwork.GetActivation(name)
+	if err != nil {
+		return nil, err
+	}
+	return Activate(a, activation), nil
+}
+
+// ReLU applies the rectified linear unit to every element.
+func ReLU(a *Tensor) *Tensor {
+	return Activate(a, &neuralnetwork.ReLU{})
+}
+
+// Sigmoid applies the sigmoid function to every element.
+func Sigmoid(a *Tensor) *Tensor {
+	return Activate(a, &neuralnetwork.Sigmoid{})
+}
+
+// Tanh applies the hyperbolic tangent to every element.
+func Tanh(a *Tensor) *Tensor {
+	return Activate(a, &neuralnetwork.Tanh{})
+}
@@ -0,0 +1,173 @@
+package autodiff_test
+
+import (
+	"math"
+	"testing"
+
+	"go-neuralnetwork/internal/autodiff"
+	"go-neuralnetwork/internal/neuralnetwork"
+)
+
+const gradTolerance = 1e-6
+
+func TestBackward(t *testing.T) {
+	tape := autodiff.NewTape()
+	x := tape.Variable([]float64{2, 3})
+	y := tape.Variable([]float64{4, 5})
+
+	// f = sum(x * y + x) => df/dx = y + 1, df/dy = x
+	f := autodiff.Sum(autodiff.Add(autodiff.Mul(x, y), x))
+	if err := tape.Backward(f); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if f.Value() != 2*4+3*5+2+3 {
+		t.Errorf("Expected value %v, got %v", 2*4+3*5+2+3, f.Value())
+	}
+	expectedX := []float64{5, 6}
+	expectedY := []float64{2, 3}
+	for i := range expectedX {
+		if x.Grad[i] != expectedX[i] || y.Grad[i] != expectedY[i] {
+			t.Errorf("Gradient mismatch at %d: got dx=%v dy=%v, expected dx=%v dy=%v", i, x.Grad[i], y.Grad[i], expectedX[i], expectedY[i])
+		}
+	}
+
+	// A second backward pass must not accumulate onto the first.
+	if err := tape.Backward(f); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if x.Grad[0] != expectedX[0] {
+		t.Errorf("Expected gradients to be reset between passes, got %v", x.Grad[0])
+	}
+
+	if err := tape.Backward(autodiff.Add(x, y)); err == nil {
+		t.Errorf("Expected an error for a non-scalar output, got nil")
+	}
+}
+
+func TestGradCheckOperations(t *testing.T) {
+	a := autodiff.Constant([]float64{0.5, -1.2, 0.8, 1.5, -0.3, 0.9}, 2, 3)
+	b := autodiff.Constant([]float64{0.7, 0.4, -0.6, 1.1, 0.2, -0.9}, 3, 2)
+	row := autodiff.Constant([]float64{0.3, -0.2, 0.6}, 3)
+	positive := autodiff.Constant([]float64{0.5, 1.2, 2.0, 0.8, 1.5, 0.3}, 2, 3)
+
+	testCases := []struct {
+		name   string
+		params []*autodiff.Tensor
+		f      func(p []*autodiff.Tensor) *autodiff.Tensor
+	}{
+		{"add_broadcast", []*autodiff.Tensor{a, row}, func(p []*autodiff.Tensor) *autodiff.Tensor {
+			return autodiff.Sum(autodiff.Square(autodiff.Add(p[0], p[1])))
+		}},
+		{"sub", []*autodiff.Tensor{a, positive}, func(p []*autodiff.Tensor) *autodiff.Tensor {
+			return autodiff.Sum(autodiff.Square(autodiff.Sub(p[0], p[1])))
+		}},
+		{"mul", []*autodiff.Tensor{a, positive}, func(p []*autodiff.Tensor) *autodiff.Tensor {
+			return autodiff.Sum(autodiff.Mul(p[0], p[1]))
+		}},
+		{"div", []*autodiff.Tensor{a, positive}, func(p []*autodiff.Tensor) *autodiff.Tensor {
+			return autodiff.Sum(autodiff.Div(p[0], p[1]))
+		}},
+		{"matmul", []*autodiff.Tensor{a, b}, func(p []*autodiff.Tensor) *autodiff.Tensor {
+			return autodiff.Sum(autodiff.Square(autodiff.MatMul(p[0], p[1])))
+		}},
+		{"transpose", []*autodiff.Tensor{a, a}, func(p []*autodiff.Tensor) *autodiff.Tensor {
+			return autodiff.Sum(autodiff.MatMul(p[0], autodiff.Transpose(p[1])))
+		}},
+		{"exp_log_sqrt", []*autodiff.Tensor{positive}, func(p []*autodiff.Tensor) *autodiff.Tensor {
+			return autodiff.Mean(autodiff.Add(autodiff.Exp(p[0]), autodiff.Mul(autodiff.Log(p[0]), autodiff.Sqrt(p[0]))))
+		}},
+		{"pow_scale_neg", []*autodiff.Tensor{positive}, func(p []*autodiff.Tensor) *autodiff.Tensor {
+			return autodiff.Sum(autodiff.Neg(autodiff.Scale(autodiff.Pow(p[0], 1.5), 0.3)))
+		}},
+		{"sum_axis", []*autodiff.Tensor{a}, func(p []*autodiff.Tensor) *autodiff.Tensor {
+			return autodiff.Add(
+				autodiff.Sum(autodiff.Square(autodiff.SumAxis(p[0], 0))),
+				autodiff.Sum(autodiff.Square(autodiff.SumAxis(p[0], 1))))
+		}},
+		{"reshape", []*autodiff.Tensor{a, b}, func(p []*autodiff.Tensor) *autodiff.Tensor {
+			return autodiff.Sum(autodiff.Mul(autodiff.Reshape(p[0], 3, 2), p[1]))
+		}},
+	}
+
+	for _, name := range neuralnetwork.GetAvailableActivations() {
+		name := name
+		testCases = append(testCases, struct {
+			name   string
+			params []*autodiff.Tensor
+			f      func(p []*autodiff.Tensor) *autodiff.Tensor
+		}{"activation_" + name, []*autodiff.Tensor{a}, func(p []*autodiff.Tensor) *autodiff.Tensor {
+			activated, err := autodiff.ActivateByName(p[0], name)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			return autodiff.Sum(autodiff.Square(activated))
+		}})
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			maxErrors, err := autodiff.GradCheck(tc.f, tc.params, 1e-6)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for i, e := range maxErrors {
+				if e > gradTolerance {
+					t.Errorf("Parameter %d: relative gradient error %e exceeds %e", i, e, gradTolerance)
+				}
+			}
+		})
+	}
+}
+
+func TestComposedModelMatchesSequential(t *testing.T) {
+	hidden := neuralnetwork.NewDense(3, 4)
+	output := neuralnetwork.NewDense(4, 2)
+	tanh, _ := neuralnetwork.NewActivationLayer("tanh")
+	sigmoid, _ := neuralnetwork.NewActivationLayer("sigmoid")
+	seq, err := neuralnetwork.NewSequential("mse", hidden, tanh, output, sigmoid)
+	if err != nil {
+		t.Fatalf("Failed to build model: %v", err)
+	}
+
+	input := []float64{0.2, -0.5, 0.9}
+	target := []float64{1, 0}
+	_, grad := seq.ComputeLoss(seq.Forward(input, true), target)
+	seq.Backward(grad)
+
+	// The same model written with tensors: W is stored as outputs x inputs.
+	flatten := func(m [][]float64) []float64 {
+		var flat []float64
+		for _, row := range m {
+			flat = append(flat, row...)
+		}
+		return flat
+	}
+	tape := autodiff.NewTape()
+	w1 := tape.Variable(flatten(hidden.Weights), 4, 3)
+	b1 := tape.Variable(append([]float64(nil), hidden.Biases...), 4)
+	w2 := tape.Variable(flatten(output.Weights), 2, 4)
+	b2 := tape.Variable(append([]float64(nil), output.Biases...), 2)
+	x := autodiff.Constant(input, 1, 3)
+	h := autodiff.Tanh(autodiff.Add(autodiff.MatMul(x, autodiff.Transpose(w1)), b1))
+	y := autodiff.Sigmoid(autodiff.Add(autodiff.MatMul(h, autodiff.Transpose(w2)), b2))
+	loss := autodiff.Scale(autodiff.Sum(autodiff.Square(autodiff.Sub(y, autodiff.Constant(target, 1, 2)))), 0.5)
+	if err := tape.Backward(loss); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	layerGrads := hidden.Grads()
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 3; j++ {
+			if math.Abs(layerGrads[i][j]-w1.Grad[i*3+j]) > 1e-12 {
+				t.Errorf("Hidden weight gradient [%d][%d] mismatch: layer %v, tape %v", i, j, layerGrads[i][j], w1.Grad[i*3+j])
+			}
+		}
+	}
+	outputGrads := output.Grads()
+	for i := 0; i < 2; i++ {
+		if math.Abs(outputGrads[2][i]-b2.Grad[i]) > 1e-12 {
+			t.Errorf("Output bias gradient %d mismatch: layer %v, tape %v", i, outputGrads[2][i], b2.Grad[i])
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package autodiff
+
+import (
+	"fmt"
+	"math"
+
+	"go-neuralnetwork/internal/neuralnetwork"
+)
+
+// GradCheck compares the gradients computed by the tape with central finite
+// differences. f must build a scalar from the tensors it is given; it is called
+// once with tracked copies of params and twice per parameter element with
+// perturbed untracked copies. The largest relative error of each parameter is returned;
+// differences within the rounding noise of the central difference are ignored.
+func GradCheck(f func(params []*Tensor) *Tensor, params []*Tensor, epsilon float64) ([]float64, error) {
+	tape := NewTape()
+	variables := make([]*Tensor, len(params))
+	for i, p := range params {
+		variables[i] = tape.Variable(append([]float64(nil), p.Data...), p.Shape...)
+	}
+	output := f(variables)
+	if err := tape.Backward(output); err != nil {
+		return nil, err
+	}
+	noise := neuralnetwork.FiniteDifferenceNoise(output.Data[0], epsilon)
+
+	evaluate := func(index, element int, delta float64) (float64, error) {
+		constants := make([]*Tensor, len(params))
+		for i, p := range params {
+			constants[i] = Constant(append([]float64(nil), p.Data...), p.Shape...)
+		}
+		constants[index].Data[element] += delta
+		output := f(constants)
+		if output.Size() != 1 {
+			return 0, fmt.Errorf("gradient check requires a scalar output, got shape %v", output.Shape)
+		}
+		return output.Data[0], nil
+	}
+
+	maxErrors := make([]float64, len(params))
+	for i, p := range params {
+		for j := range p.Data {
+			plus, err := evaluate(i, j, epsilon)
+			if err != nil {
+				return nil, err
+			}
+			minus, err := evaluate(i, j, -epsilon)
+			if err != nil {
+				return nil, err
+			}
+			numerical := (plus - minus) / (2 * epsilon)
+			if math.Abs(variables[i].Grad[j]-numerical) > noise {
+				maxErrors[i] = math.Max(maxErrors[i], neuralnetwork.RelativeError(variables[i].Grad[j], numerical))
+			}
+		}
+	}
+	return maxErrors, nil
+}
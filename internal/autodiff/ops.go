@@ -0,0 +1,274 @@
+package autodiff
+
+import (
+	"fmt"
+	"math"
+)
+
+// Operations panic when their operands have incompatible shapes, in the same way
+// that indexing a slice out of range does.
+
+// broadcastShape returns the shape of an element-wise operation on a and b. The
+// smaller operand must be a scalar or have a shape equal to the trailing dimensions
+// of the larger one, in which case it is repeated across the leading dimensions.
+func broadcastShape(a, b *Tensor) []int {
+	larger, smaller := a, b
+	if b.Size() > a.Size() {
+		larger, smaller = b, a
+	}
+	if smaller.Size() == 1 {
+		return larger.Shape
+	}
+	offset := len(larger.Shape) - len(smaller.Shape)
+	if offset < 0 {
+		panic(fmt.Sprintf("autodiff: cannot broadcast shapes %v and %v", a.Shape, b.Shape))
+	}
+	for i, dim := range smaller.Shape {
+		if larger.Shape[offset+i] != dim {
+			panic(fmt.Sprintf("autodiff: cannot broadcast shapes %v and %v", a.Shape, b.Shape))
+		}
+	}
+	return larger.Shape
+}
+
+// elementwise applies a binary function with broadcasting. partials returns the
+// derivatives of the function with respect to its two arguments.
+func elementwise(a, b *Tensor, f func(x, y float64) float64, partials func(x, y, out float64) (float64, float64)) *Tensor {
+	result := newResult(broadcastShape(a, b), a, b)
+	for i := range result.Data {
+		result.Data[i] = f(a.Data[i%a.Size()], b.Data[i%b.Size()])
+	}
+	if result.Tracked() {
+		result.backward = func() {
+			for i, grad := range result.Grad {
+				ia, ib := i%a.Size(), i%b.Size()
+				da, db := partials(a.Data[ia], b.Data[ib], result.Data[i])
+				if a.Tracked() {
+					a.Grad[ia] += grad * da
+				}
+				if b.Tracked() {
+					b.Grad[ib] += grad * db
+				}
+			}
+		}
+	}
+	return result
+}
+
+// unary applies a function to every element. derivative receives the input and output values.
+func unary(a *Tensor, f func(x float64) float64, derivative func(x, out float64) float64) *Tensor {
+	result := newResult(a.Shape, a)
+	for i, val := range a.Data {
+		result.Data[i] = f(val)
+	}
+	if result.Tracked() {
+		result.backward = func() {
+			for i, grad := range result.Grad {
+				a.Grad[i] += grad * derivative(a.Data[i], result.Data[i])
+			}
+		}
+	}
+	return result
+}
+
+// Add returns a + b element-wise.
+func Add(a, b *Tensor) *Tensor {
+	return elementwise(a, b,
+		func(x, y float64) float64 { return x + y },
+		func(x, y, out float64) (float64, float64) { return 1, 1 })
+}
+
+// Sub returns a - b element-wise.
+func Sub(a, b *Tensor) *Tensor {
+	return elementwise(a, b,
+		func(x, y float64) float64 { return x - y },
+		func(x, y, out float64) (float64, float64) { return 1, -1 })
+}
+
+// Mul returns a * b element-wise.
+func Mul(a, b *Tensor) *Tensor {
+	return elementwise(a, b,
+		func(x, y float64) float64 { return x * y },
+		func(x, y, out float64) (float64, float64) { return y, x })
+}
+
+// Div returns a / b element-wise.
+func Div(a, b *Tensor) *Tensor {
+	return elementwise(a, b,
+		func(x, y float64) float64 { return x / y },
+		func(x, y, out float64) (float64, float64) { return 1 / y, -x / (y * y) })
+}
+
+// Scale multiplies every element by a constant.
+func Scale(a *Tensor, c float64) *Tensor {
+	return unary(a,
+		func(x float64) float64 { return c * x },
+		func(x, out float64) float64 { return c })
+}
+
+// Neg negates every element.
+func Neg(a *Tensor) *Tensor {
+	return Scale(a, -1)
+}
+
+// Square squares every element.
+func Square(a *Tensor) *Tensor {
+	return unary(a,
+		func(x float64) float64 { return x * x },
+		func(x, out float64) float64 { return 2 * x })
+}
+
+// Pow raises every element to a constant power.
+func Pow(a *Tensor, p float64) *Tensor {
+	return unary(a,
+		func(x float64) float64 { return math.Pow(x, p) },
+		func(x, out float64) float64 { return p * math.Pow(x, p-1) })
+}
+
+// Sqrt takes the square root of every element.
+func Sqrt(a *Tensor) *Tensor {
+	return unary(a, math.Sqrt, func(x, out float64) float64 { return 0.5 / out })
+}
+
+// Exp applies the exponential function to every element.
+func Exp(a *Tensor) *Tensor {
+	return unary(a, math.Exp, func(x, out float64) float64 { return out })
+}
+
+// Log applies the natural logarithm to every element.
+func Log(a *Tensor) *Tensor {
+	return unary(a, math.Log, func(x, out float64) float64 { return 1 / x })
+}
+
+// MatMul multiplies an m x k matrix by a k x n matrix.
+func MatMul(a, b *Tensor) *Tensor {
+	if len(a.Shape) != 2 || len(b.Shape) != 2 || a.Shape[1] != b.Shape[0] {
+		panic(fmt.Sprintf("autodiff: cannot multiply shapes %v and %v", a.Shape, b.Shape))
+	}
+	m, k, n := a.Shape[0], a.Shape[1], b.Shape[1]
+	result := newResult([]int{m, n}, a, b)
+	for i := 0; i < m; i++ {
+		for j := 0; j < n; j++ {
+			sum := 0.0
+			for p := 0; p < k; p++ {
+				sum += a.Data[i*k+p] * b.Data[p*n+j]
+			}
+			result.Data[i*n+j] = sum
+		}
+	}
+	if result.Tracked() {
+		result.backward = func() {
+			for i := 0; i < m; i++ {
+				for j := 0; j < n; j++ {
+					grad := result.Grad[i*n+j]
+					for p := 0; p < k; p++ {
+						if a.Tracked() {
+							a.Grad[i*k+p] += grad * b.Data[p*n+j]
+						}
+						if b.Tracked() {
+							b.Grad[p*n+j] += grad * a.Data[i*k+p]
+						}
+					}
+				}
+			}
+		}
+	}
+	return result
+}
+
+// Transpose swaps the rows and columns of a matrix.
+func Transpose(a *Tensor) *Tensor {
+	if len(a.Shape) != 2 {
+		panic(fmt.Sprintf("autodiff: cannot transpose shape %v", a.Shape))
+	}
+	rows, cols := a.Shape[0], a.Shape[1]
+	result := newResult([]int{cols, rows}, a)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			result.Data[j*rows+i] = a.Data[i*cols+j]
+		}
+	}
+	if result.Tracked() {
+		result.backward = func() {
+			for i := 0; i < rows; i++ {
+				for j := 0; j < cols; j++ {
+					a.Grad[i*cols+j] += result.Grad[j*rows+i]
+				}
+			}
+		}
+	}
+	return result
+}
+
+// Reshape returns a tensor with the same values and a different shape.
+func Reshape(a *Tensor, shape ...int) *Tensor {
+	result := newResult(shape, a)
+	if result.Size() != a.Size() {
+		panic(fmt.Sprintf("autodiff: cannot reshape %v to %v", a.Shape, shape))
+	}
+	copy(result.Data, a.Data)
+	if result.Tracked() {
+		result.backward = func() {
+			for i, grad := range result.Grad {
+				a.Grad[i] += grad
+			}
+		}
+	}
+	return result
+}
+
+// Sum adds up every element into a scalar.
+func Sum(a *Tensor) *Tensor {
+	result := newResult([]int{1}, a)
+	for _, val := range a.Data {
+		result.Data[0] += val
+	}
+	if result.Tracked() {
+		result.backward = func() {
+			for i := range a.Grad {
+				a.Grad[i] += result.Grad[0]
+			}
+		}
+	}
+	return result
+}
+
+// Mean averages every element into a scalar.
+func Mean(a *Tensor) *Tensor {
+	return Scale(Sum(a), 1/float64(a.Size()))
+}
+
+// SumAxis sums a matrix along an axis: axis 0 gives a row of column sums and
+// axis 1 gives a column of row sums.
+func SumAxis(a *Tensor, axis int) *Tensor {
+	if len(a.Shape) != 2 || axis < 0 || axis > 1 {
+		panic(fmt.Sprintf("autodiff: cannot sum shape %v along axis %d", a.Shape, axis))
+	}
+	rows, cols := a.Shape[0], a.Shape[1]
+	shape := []int{1, cols}
+	if axis == 1 {
+		shape = []int{rows, 1}
+	}
+	index := func(i, j int) int {
+		if axis == 0 {
+			return j
+		}
+		return i
+	}
+	result := newResult(shape, a)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			result.Data[index(i, j)] += a.Data[i*cols+j]
+		}
+	}
+	if result.Tracked() {
+		result.backward = func() {
+			for i := 0; i < rows; i++ {
+				for j := 0; j < cols; j++ {
+					a.Grad[i*cols+j] += result.Grad[index(i, j)]
+				}
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,124 @@
+// Package autodiff implements reverse-mode automatic differentiation for small
+// dense tensors.
+//
+// Operations on tensors that belong to a Tape are recorded in the order they are
+// executed. Calling Backward on a scalar result walks the recording in reverse
+// and accumulates the gradient of that scalar into every variable's Grad slice.
+//
+// The layers in package neuralnetwork keep their hand-written backward passes;
+// a function composed from these operations gets its gradients from the tape,
+// and GradCheck verifies them against finite differences.
+package autodiff
+
+import "fmt"
+
+// Tensor is a dense, row-major array of float64 values.
+type Tensor struct {
+	Data  []float64
+	Shape []int
+	// Grad holds the accumulated gradient. It is nil for tensors that are not tracked.
+	Grad []float64
+
+	tape     *Tape
+	backward func()
+}
+
+// Tape records the operations applied to its variables.
+type Tape struct {
+	nodes []*Tensor
+}
+
+// NewTape creates an empty tape.
+func NewTape() *Tape {
+	return &Tape{}
+}
+
+// Variable creates a tracked tensor whose gradient will be computed by Backward.
+// The data slice is used as is, not copied.
+func (t *Tape) Variable(data []float64, shape ...int) *Tensor {
+	v := Constant(data, shape...)
+	v.tape = t
+	v.Grad = make([]float64, len(data))
+	t.nodes = append(t.nodes, v)
+	return v
+}
+
+// Backward computes the gradient of the scalar output with respect to every
+// tensor recorded on the tape. Gradients from previous calls are discarded.
+func (t *Tape) Backward(output *Tensor) error {
+	if output.tape != t {
+		return fmt.Errorf("output tensor was not recorded on this tape")
+	}
+	if output.Size() != 1 {
+		return fmt.Errorf("backward requires a scalar output, got shape %v", output.Shape)
+	}
+	for _, node := range t.nodes {
+		for i := range node.Grad {
+			node.Grad[i] = 0
+		}
+	}
+	output.Grad[0] = 1
+	for i := len(t.nodes) - 1; i >= 0; i-- {
+		if t.nodes[i].backward != nil {
+			t.nodes[i].backward()
+		}
+	}
+	return nil
+}
+
+// Constant creates an untracked tensor. If no shape is given the tensor is one-dimensional.
+func Constant(data []float64, shape ...int) *Tensor {
+	if len(shape) == 0 {
+		shape = []int{len(data)}
+	}
+	size := 1
+	for _, dim := range shape {
+		size *= dim
+	}
+	if size != len(data) {
+		panic(fmt.Sprintf("autodiff: shape %v does not match %d values", shape, len(data)))
+	}
+	return &Tensor{Data: data, Shape: shape}
+}
+
+// Scalar creates an untracked tensor holding a single value.
+func Scalar(value float64) *Tensor {
+	return Constant([]float64{value}, 1)
+}
+
+// Size returns the number of elements in the tensor.
+func (t *Tensor) Size() int {
+	return len(t.Data)
+}
+
+// Tracked reports whether gradients are computed for the tensor.
+func (t *Tensor) Tracked() bool {
+	return t.Grad != nil
+}
+
+// Value returns the single value of a scalar tensor.
+func (t *Tensor) Value() float64 {
+	if t.Size() != 1 {
+		panic(fmt.Sprintf("autodiff: Value called on tensor of shape %v", t.Shape))
+	}
+	return t.Data[0]
+}
+
+// newResult allocates the output of an operation. When any operand is tracked the
+// result is tracked as well and recorded on the operand's tape.
+func newResult(shape []int, operands ...*Tensor) *Tensor {
+	size := 1
+	for _, dim := range shape {
+		size *= dim
+	}
+	result := &Tensor{Data: make([]float64, size), Shape: shape}
+	for _, operand := range operands {
+		if operand.Tracked() {
+			result.tape = operand.tape
+			result.Grad = make([]float64, size)
+			result.tape.nodes = append(result.tape.nodes, result)
+			break
+		}
+	}
+	return result
+}
@@ -0,0 +1,38 @@
+package data
+
+import "go-neuralnetwork/internal/neuralnetwork"
+
+// Vocabulary maps the categories of one input column to embedding indices. Index 0 is the
+// unknown bucket used for categories that were not seen in the training rows.
+type Vocabulary struct {
+	Column   string         `json:"column"`
+	Position int            `json:"position"`
+	Dim      int            `json:"dim"`
+	Tokens   map[string]int `json:"tokens"`
+}
+
+// Index returns the embedding index of a category.
+func (v *Vocabulary) Index(token string) int {
+	return v.Tokens[token]
+}
+
+// Size returns the number of embedding rows, including the unknown bucket.
+func (v *Vocabulary) Size() int {
+	return len(v.Tokens) + 1
+}
+
+// EmbeddingLayer returns the layer that embeds the categorical columns of the dataset, or
+// nil when it has none. Layers after it see EmbeddingLayer().OutputSize() inputs.
+func (d *Dataset) EmbeddingLayer() *neuralnetwork.Embedding {
+	vocabularies := d.Pipeline.Vocabularies
+	if len(vocabularies) == 0 {
+		return nil
+	}
+	vocabSizes := make([]int, len(vocabularies))
+	dims := make([]int, len(vocabularies))
+	for i := range vocabularies {
+		vocabSizes[i] = vocabularies[i].Size()
+		dims[i] = vocabularies[i].Dim
+	}
+	return neuralnetwork.NewEmbedding(d.Pipeline.NumNumeric(), vocabSizes, dims)
+}
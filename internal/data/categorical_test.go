@@ -0,0 +1,61 @@
+package data_test
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"go-neuralnetwork/internal/data"
+	"go-neuralnetwork/internal/tempfile"
+)
+
+func TestLoadCSVWithOptions(t *testing.T) {
+	csvContent := `size,color,shape,label
+1,red,round,a
+2,blue,square,b
+3,red,square,a
+5,green,round,b`
+	filePath, err := tempfile.CreateTempFileWithContent("categorical-*.csv", csvContent)
+	if err != nil {
+		t.Fatalf("Failed to create temp CSV: %v", err)
+	}
+	defer os.Remove(filePath)
+
+	if _, err := data.LoadCSV(filePath, 1.0); err == nil {
+		t.Errorf("Expected LoadCSV to reject categorical inputs, got nil")
+	}
+
+	opts := data.LoadOptions{SplitRatio: 1.0, CategoricalColumns: map[string]int{"color": 3, "shape": 2}}
+	dataset, err := data.LoadCSVWithOptions(filePath, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if dataset.InputSize != 3 || len(dataset.Pipeline.Vocabularies) != 2 || dataset.OutputSize != 2 {
+		t.Fatalf("Unexpected layout: %d inputs, %d vocabularies, %d outputs", dataset.InputSize, len(dataset.Pipeline.Vocabularies), dataset.OutputSize)
+	}
+	color := dataset.Pipeline.Vocabularies[0]
+	if color.Column != "color" || color.Position != 1 || color.Size() != 4 || color.Index("purple") != 0 {
+		t.Errorf("Unexpected color vocabulary %+v", color)
+	}
+	if embedding := dataset.EmbeddingLayer(); embedding.OutputSize() != 1+3+2 {
+		t.Errorf("Expected embedding output size 6, got %d", embedding.OutputSize())
+	}
+
+	md := &data.ModelData{Pipeline: dataset.Pipeline}
+	input, err := md.EncodeInput([]string{"3", "blue", "triangle"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []float64{0.5, float64(color.Index("blue")), 0}
+	if !reflect.DeepEqual(input, expected) {
+		t.Errorf("Expected %v, got %v", expected, input)
+	}
+	if _, err := md.EncodeInput([]string{"3", "blue"}); err == nil {
+		t.Errorf("Expected an error for a missing value, got nil")
+	}
+
+	opts.CategoricalColumns = map[string]int{"weight": 2}
+	if _, err := data.LoadCSVWithOptions(filePath, opts); err == nil {
+		t.Errorf("Expected an error for an unknown categorical column, got nil")
+	}
+}
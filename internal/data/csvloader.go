@@ -3,6 +3,7 @@ package data
 import (
 	"encoding/csv"
 	"fmt"
+	"math"
 	"math/rand"
 	"os"
 	"strconv"
@@ -16,11 +17,48 @@ type Dataset struct {
 	TestTargets  [][]float64
 	InputSize    int
 	OutputSize   int
-	InputMins    []float64
-	InputMaxs    []float64
 	TargetMins   []float64
 	TargetMaxs   []float64
 	ClassMap     map[string]int
+
+	// ValidationInputs and ValidationTargets hold the rows set aside for validation by
+	// LoadOptions.ValidationRatio; they are empty for other datasets.
+	ValidationInputs  [][]float64
+	ValidationTargets [][]float64
+
+	// TargetScalers normalize the regression targets when a loader chose them per
+	// column; otherwise the targets are min-max scaled with the training ranges above.
+	// Use RegressionScalers to get them either way.
+	TargetScalers []Scaler
+	// Pipeline is the preprocessing that turned the raw input values into TrainInputs
+	// and TestInputs, fitted on the training rows. It is saved with the model.
+	Pipeline *Pipeline
+
+	// InputShape describes one input row, e.g. [features] or [channels, length].
+	InputShape          []int
+	InterleavedChannels bool
+	// SequenceLength is the number of time steps of sequence inputs, which hold
+	// len(Pipeline.Scalers) features per step. It is 0 for other datasets.
+	SequenceLength int
+	// TimeSeries holds the windowing of datasets built by LoadTimeSeries.
+	TimeSeries *TimeSeriesOptions
+	// TargetNames names the regression outputs, in the order of TargetMins and TargetMaxs.
+	TargetNames []string
+	// Heads describes the output heads of multi-task datasets built by LoadCSVWithOptions.
+	Heads []TaskHead
+	// MultiLabel is set when a row can carry several classes of ClassMap (see
+	// LoadCSVMultiLabel); Thresholds then holds the decision threshold of every class.
+	MultiLabel bool
+	Thresholds []float64
+}
+
+// RegressionScalers returns the scalers of the regression targets, built from
+// TargetMins and TargetMaxs when the loader chose none.
+func (d *Dataset) RegressionScalers() []Scaler {
+	if d.TargetScalers == nil {
+		return MinMaxScalers(d.TargetMins, d.TargetMaxs)
+	}
+	return d.TargetScalers
 }
 
 func Shuffle(inputs, targets [][]float64) {
@@ -31,6 +69,39 @@ func Shuffle(inputs, targets [][]float64) {
 	})
 }
 
+// ReshapeChannels describes every input row as channels x length instead of a flat
+// feature vector. When interleaved is true the CSV columns are assumed to list every
+// channel for one position before moving on to the next (x0,y0,x1,y1,...), and the
+// rows are reordered channel by channel, which is the layout convolution layers expect.
+func (d *Dataset) ReshapeChannels(channels int, interleaved bool) error {
+	if channels < 1 || d.InputSize%channels != 0 {
+		return fmt.Errorf("cannot split %d input columns into %d channels", d.InputSize, channels)
+	}
+	if interleaved {
+		for _, rows := range [][][]float64{d.TrainInputs, d.TestInputs} {
+			for i, row := range rows {
+				rows[i] = ChannelsFirst(row, channels)
+			}
+		}
+	}
+	d.InputShape = []int{channels, d.InputSize / channels}
+	d.InterleavedChannels = interleaved
+	return nil
+}
+
+// ChannelsFirst reorders an interleaved row (x0,y0,x1,y1,...) into one block per
+// channel (x0,x1,...,y0,y1,...).
+func ChannelsFirst(row []float64, channels int) []float64 {
+	length := len(row) / channels
+	reordered := make([]float64, len(row))
+	for pos := 0; pos < length; pos++ {
+		for ch := 0; ch < channels; ch++ {
+			reordered[ch*length+pos] = row[pos*channels+ch]
+		}
+	}
+	return reordered
+}
+
 func SplitData(inputs, targets [][]float64, splitRatio float64) (trainInputs, trainTargets, testInputs, testTargets [][]float64) {
 	splitIndex := int(float64(len(inputs)) * splitRatio)
 	trainInputs = inputs[:splitIndex]
@@ -59,78 +130,41 @@ func LoadCSV(filePath string, splitRatio float64) (*Dataset, error) {
 	}
 
 	if len(records) > 0 {
+		if hasMultipleLabels(records, len(header)-1) {
+			return LoadCSVMultiLabel(filePath, splitRatio)
+		}
 		if _, err := strconv.ParseFloat(records[0][len(records[0])-1], 64); err != nil {
 			return LoadCSVForClassification(filePath, splitRatio)
 		}
 	}
 
-	// Reset reader and read records again
-	file.Seek(0, 0)
-	reader = csv.NewReader(file)
-	header, err = reader.Read()
-	if err != nil {
-		return nil, err
-	}
-
 	inputSize := len(header) - 1
 	outputSize := 1
 
-	// Find min and max for each column to normalize the data
-	mins := make([]float64, inputSize+outputSize)
-	maxs := make([]float64, inputSize+outputSize)
-	for i := range mins {
-		mins[i] = 1e9
-		maxs[i] = -1e9
-	}
-
+	var inputs, targets [][]float64
 	for _, record := range records {
-		for i := range mins {
+		row := make([]float64, inputSize+outputSize)
+		for i := range row {
 			val, err := strconv.ParseFloat(record[i], 64)
 			if err != nil {
 				return nil, err
 			}
-			if val < mins[i] {
-				mins[i] = val
-			}
-			if val > maxs[i] {
-				maxs[i] = val
-			}
-		}
-	}
-
-	inputMins := mins[:inputSize]
-	inputMaxs := maxs[:inputSize]
-	targetMins := mins[inputSize:]
-	targetMaxs := maxs[inputSize:]
-
-	var inputs, targets [][]float64
-	for _, record := range records {
-		inputRow := make([]float64, inputSize)
-		outputRow := make([]float64, outputSize)
-
-		for i := range inputRow {
-			val, _ := strconv.ParseFloat(record[i], 64)
-			if maxs[i]-mins[i] == 0 {
-				inputRow[i] = 0
-			} else {
-				inputRow[i] = (val - mins[i]) / (maxs[i] - mins[i])
-			}
-		}
-
-		for i := range outputRow {
-			val, _ := strconv.ParseFloat(record[inputSize+i], 64)
-			if maxs[inputSize+i]-mins[inputSize+i] == 0 {
-				outputRow[i] = 0
-			} else {
-				outputRow[i] = (val - mins[inputSize+i]) / (maxs[inputSize+i] - mins[inputSize+i])
-			}
+			row[i] = val
 		}
-
-		inputs = append(inputs, inputRow)
-		targets = append(targets, outputRow)
+		inputs = append(inputs, row[:inputSize])
+		targets = append(targets, row[inputSize:])
 	}
 
+	// Normalize with the ranges of the training rows only, so that no statistics of the
+	// test rows leak into training.
 	Shuffle(inputs, targets)
+	splitIndex := int(float64(len(inputs)) * splitRatio)
+	targetMins, targetMaxs := columnRanges(targets[:splitIndex], outputSize)
+	pipeline, err := fitNumericPipeline(header[:inputSize], inputs, splitIndex)
+	if err != nil {
+		return nil, err
+	}
+	normalizeRows(targets, targetMins, targetMaxs)
 	trainInputs, trainTargets, testInputs, testTargets := SplitData(inputs, targets, splitRatio)
 
 	return &Dataset{
@@ -140,10 +174,11 @@ func LoadCSV(filePath string, splitRatio float64) (*Dataset, error) {
 		TestTargets:  testTargets,
 		InputSize:    inputSize,
 		OutputSize:   outputSize,
-		InputMins:    inputMins,
-		InputMaxs:    inputMaxs,
+		InputShape:   []int{inputSize},
+		Pipeline:     pipeline,
 		TargetMins:   targetMins,
 		TargetMaxs:   targetMaxs,
+		TargetNames:  header[inputSize:],
 	}, nil
 }
 
@@ -179,40 +214,16 @@ func LoadCSVForClassification(filePath string, splitRatio float64) (*Dataset, er
 	}
 	outputSize := len(classMap)
 
-	// Find min and max for input columns to normalize the data
-	inputMins := make([]float64, inputSize)
-	inputMaxs := make([]float64, inputSize)
-	for i := range inputMins {
-		inputMins[i] = 1e9
-		inputMaxs[i] = -1e9
-	}
-
-	for _, record := range records {
-		for i := range inputMins {
-			val, err := strconv.ParseFloat(record[i], 64)
-			if err != nil {
-				return nil, fmt.Errorf("error parsing float in record %v: %w", record, err)
-			}
-			if val < inputMins[i] {
-				inputMins[i] = val
-			}
-			if val > inputMaxs[i] {
-				inputMaxs[i] = val
-			}
-		}
-	}
-
 	var inputs, targets [][]float64
 	// Second pass: build the inputs and targets slices
 	for _, record := range records {
 		inputRow := make([]float64, inputSize)
 		for i := range inputRow {
-			val, _ := strconv.ParseFloat(record[i], 64)
-			if inputMaxs[i]-inputMins[i] == 0 {
-				inputRow[i] = 0
-			} else {
-				inputRow[i] = (val - inputMins[i]) / (inputMaxs[i] - inputMins[i])
+			val, err := strconv.ParseFloat(record[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing float in record %v: %w", record, err)
 			}
+			inputRow[i] = val
 		}
 		inputs = append(inputs, inputRow)
 
@@ -222,7 +233,13 @@ func LoadCSVForClassification(filePath string, splitRatio float64) (*Dataset, er
 		targets = append(targets, targetRow)
 	}
 
+	// Normalize with the ranges of the training rows only.
 	Shuffle(inputs, targets)
+	splitIndex := int(float64(len(inputs)) * splitRatio)
+	pipeline, err := fitNumericPipeline(header[:inputSize], inputs, splitIndex)
+	if err != nil {
+		return nil, err
+	}
 	trainInputs, trainTargets, testInputs, testTargets := SplitData(inputs, targets, splitRatio)
 
 	return &Dataset{
@@ -232,8 +249,38 @@ func LoadCSVForClassification(filePath string, splitRatio float64) (*Dataset, er
 		TestTargets:  testTargets,
 		InputSize:    inputSize,
 		OutputSize:   outputSize,
-		InputMins:    inputMins,
-		InputMaxs:    inputMaxs,
+		InputShape:   []int{inputSize},
+		Pipeline:     pipeline,
 		ClassMap:     classMap,
 	}, nil
 }
+
+// columnRanges returns the smallest and largest value of every column of rows.
+func columnRanges(rows [][]float64, numColumns int) (mins, maxs []float64) {
+	mins = make([]float64, numColumns)
+	maxs = make([]float64, numColumns)
+	for i := range mins {
+		mins[i] = math.Inf(1)
+		maxs[i] = math.Inf(-1)
+	}
+	for _, row := range rows {
+		for i, val := range row {
+			mins[i] = min(mins[i], val)
+			maxs[i] = max(maxs[i], val)
+		}
+	}
+	return mins, maxs
+}
+
+// normalizeRows min-max scales rows in place. Columns holding a single value become 0.
+func normalizeRows(rows [][]float64, mins, maxs []float64) {
+	for _, row := range rows {
+		for i, val := range row {
+			if maxs[i]-mins[i] == 0 {
+				row[i] = 0
+			} else {
+				row[i] = (val - mins[i]) / (maxs[i] - mins[i])
+			}
+		}
+	}
+}
@@ -71,11 +71,17 @@ func TestLoadCSV(t *testing.T) {
 	if !reflect.DeepEqual(dataset.TargetMaxs, expectedTargetMaxs1) {
 		t.Errorf("Test Case 1: TargetMaxs mismatch. Got %v, Expected %v", dataset.TargetMaxs, expectedTargetMaxs1)
 	}
-	if !reflect.DeepEqual(dataset.InputMins, expectedInputMins1) {
-		t.Errorf("Test Case 1: InputMins mismatch. Got %v, Expected %v", dataset.InputMins, expectedInputMins1)
+	inputMins1 := make([]float64, len(dataset.Pipeline.Scalers))
+	inputMaxs1 := make([]float64, len(dataset.Pipeline.Scalers))
+	for i, scaler := range dataset.Pipeline.Scalers {
+		minmax := scaler.(*data.MinMaxScaler)
+		inputMins1[i], inputMaxs1[i] = minmax.Min, minmax.Max
 	}
-	if !reflect.DeepEqual(dataset.InputMaxs, expectedInputMaxs1) {
-		t.Errorf("Test Case 1: InputMaxs mismatch. Got %v, Expected %v", dataset.InputMaxs, expectedInputMaxs1)
+	if !reflect.DeepEqual(inputMins1, expectedInputMins1) {
+		t.Errorf("Test Case 1: InputMins mismatch. Got %v, Expected %v", inputMins1, expectedInputMins1)
+	}
+	if !reflect.DeepEqual(inputMaxs1, expectedInputMaxs1) {
+		t.Errorf("Test Case 1: InputMaxs mismatch. Got %v, Expected %v", inputMaxs1, expectedInputMaxs1)
 	}
 	if len(dataset.TestInputs) != 0 {
 		t.Errorf("Test Case 1: TestInputs should be empty with splitRatio 1.0, got %v", dataset.TestInputs)
@@ -280,3 +286,29 @@ func TestShuffle(t *testing.T) {
 		t.Log("Warning: Data was not shuffled. This might happen by chance, re-run test.")
 	}
 }
+
+func TestReshapeChannels(t *testing.T) {
+	dataset := &data.Dataset{
+		TrainInputs: [][]float64{{1, 10, 2, 20, 3, 30}},
+		TestInputs:  [][]float64{{4, 40, 5, 50, 6, 60}},
+		InputSize:   6,
+		InputShape:  []int{6},
+	}
+
+	if err := dataset.ReshapeChannels(4, true); err == nil {
+		t.Errorf("Expected an error when the columns do not divide into channels, got nil")
+	}
+
+	if err := dataset.ReshapeChannels(2, true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(dataset.InputShape, []int{2, 3}) {
+		t.Errorf("Expected input shape [2 3], got %v", dataset.InputShape)
+	}
+	if !reflect.DeepEqual(dataset.TrainInputs[0], []float64{1, 2, 3, 10, 20, 30}) {
+		t.Errorf("Train row not reordered by channel, got %v", dataset.TrainInputs[0])
+	}
+	if !reflect.DeepEqual(dataset.TestInputs[0], []float64{4, 5, 6, 40, 50, 60}) {
+		t.Errorf("Test row not reordered by channel, got %v", dataset.TestInputs[0])
+	}
+}
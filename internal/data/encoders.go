@@ -0,0 +1,123 @@
+package data
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// EncoderKind selects how an Encoder turns a categorical column into numbers.
+type EncoderKind string
+
+const (
+	// EncodeOneHot gives every category its own 0/1 feature.
+	EncodeOneHot EncoderKind = "onehot"
+	// EncodeOrdinal maps the categories, in a given order, to evenly spaced values in [0, 1].
+	EncodeOrdinal EncoderKind = "ordinal"
+	// EncodeFrequency replaces a category with its share of the training rows.
+	EncodeFrequency EncoderKind = "frequency"
+)
+
+// Encoder turns the categories of one input column into numeric features. Unlike an
+// Embedding, it has nothing to learn during training.
+type Encoder struct {
+	Column string `json:"column"`
+	// Position is the index of the column among the input values.
+	Position int         `json:"position"`
+	Kind     EncoderKind `json:"kind"`
+	// MaxCategories limits one-hot encoding to the most frequent categories of the
+	// training rows; the others share an extra "other" feature. 0 means no limit.
+	MaxCategories int `json:"maxCategories,omitempty"`
+	// Categories are the one-hot features, learned from the training rows, or the
+	// ordinal order, given from lowest to highest.
+	Categories []string `json:"categories,omitempty"`
+	// Other is set when one-hot encoding has an "other" feature.
+	Other bool `json:"other,omitempty"`
+	// Frequencies maps every category of the training rows to its share of them.
+	Frequencies map[string]float64 `json:"frequencies,omitempty"`
+}
+
+// Size returns the number of features the encoder produces.
+func (e *Encoder) Size() int {
+	if e.Kind != EncodeOneHot {
+		return 1
+	}
+	if e.Other {
+		return len(e.Categories) + 1
+	}
+	return len(e.Categories)
+}
+
+// fit learns the categories or frequencies of the column from the training rows.
+func (e *Encoder) fit(values []string) error {
+	counts := make(map[string]int)
+	for _, value := range values {
+		counts[value]++
+	}
+	switch e.Kind {
+	case EncodeOneHot:
+		e.Categories = nil
+		for category := range counts {
+			e.Categories = append(e.Categories, category)
+		}
+		// Most frequent first, then alphabetical, so the features do not depend on the row order.
+		sort.Slice(e.Categories, func(i, j int) bool {
+			a, b := e.Categories[i], e.Categories[j]
+			return counts[a] > counts[b] || counts[a] == counts[b] && a < b
+		})
+		if e.MaxCategories > 0 && len(e.Categories) > e.MaxCategories {
+			e.Categories = e.Categories[:e.MaxCategories]
+			e.Other = true
+		}
+	case EncodeOrdinal:
+		if len(e.Categories) < 2 {
+			return fmt.Errorf("ordinal column %q needs the order of at least 2 categories", e.Column)
+		}
+		for category := range counts {
+			if !slices.Contains(e.Categories, category) {
+				return fmt.Errorf("category %q of column %q is missing from its order", category, e.Column)
+			}
+		}
+	case EncodeFrequency:
+		e.Frequencies = make(map[string]float64, len(counts))
+		for category, count := range counts {
+			e.Frequencies[category] = float64(count) / float64(len(values))
+		}
+	default:
+		return fmt.Errorf("unknown encoding %q for column %q", e.Kind, e.Column)
+	}
+	return nil
+}
+
+// encode returns the features of one category. Categories not seen in the training rows
+// go to the "other" feature of one-hot encoding, or have no feature set without one,
+// and have a frequency of 0. Ordinal columns only accept categories of their order.
+func (e *Encoder) encode(value string) ([]float64, error) {
+	switch e.Kind {
+	case EncodeOneHot:
+		features := make([]float64, e.Size())
+		if i := slices.Index(e.Categories, value); i >= 0 {
+			features[i] = 1
+		} else if e.Other {
+			features[len(e.Categories)] = 1
+		}
+		return features, nil
+	case EncodeOrdinal:
+		i := slices.Index(e.Categories, value)
+		if i < 0 {
+			return nil, fmt.Errorf("unknown category %q of ordinal column %q", value, e.Column)
+		}
+		return []float64{float64(i) / float64(len(e.Categories)-1)}, nil
+	default:
+		return []float64{e.Frequencies[value]}, nil
+	}
+}
+
+// encodedSize returns the number of features produced by a list of encoders.
+func encodedSize(encoders []Encoder) int {
+	n := 0
+	for i := range encoders {
+		n += encoders[i].Size()
+	}
+	return n
+}
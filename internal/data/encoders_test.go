@@ -0,0 +1,64 @@
+package data_test
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"go-neuralnetwork/internal/data"
+	"go-neuralnetwork/internal/tempfile"
+)
+
+func TestLoadCSVEncoders(t *testing.T) {
+	csvContent := `region,size,brand,price
+north,small,acme,1
+north,large,acme,2
+south,medium,zenith,3
+east,small,acme,4
+north,medium,zenith,5
+west,large,other,6`
+	filePath, err := tempfile.CreateTempFileWithContent("encoders-*.csv", csvContent)
+	if err != nil {
+		t.Fatalf("Failed to create temp CSV: %v", err)
+	}
+	defer os.Remove(filePath)
+
+	opts := data.LoadOptions{SplitRatio: 1.0, Encoders: map[string]data.Encoder{
+		"region": {Kind: data.EncodeOneHot, MaxCategories: 2},
+		"size":   {Kind: data.EncodeOrdinal, Categories: []string{"small", "medium", "large"}},
+		"brand":  {Kind: data.EncodeFrequency},
+	}}
+	dataset, err := data.LoadCSVWithOptions(filePath, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	region := dataset.Pipeline.Encoders[0]
+	// north is the most frequent region; east, south and west tie and share "other" after east.
+	if !reflect.DeepEqual(region.Categories, []string{"north", "east"}) || !region.Other {
+		t.Errorf("Unexpected one-hot categories %v (other %v)", region.Categories, region.Other)
+	}
+	if dataset.InputSize != 3+1+1 || dataset.Pipeline.Size() != 5 {
+		t.Fatalf("Expected 5 input features, got %d", dataset.InputSize)
+	}
+
+	md := &data.ModelData{Pipeline: dataset.Pipeline}
+	input, err := md.EncodeInput([]string{"south", "medium", "acme"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []float64{0, 0, 1, 0.5, 0.5}
+	if !reflect.DeepEqual(input, expected) {
+		t.Errorf("Expected %v, got %v", expected, input)
+	}
+	if input, _ := md.EncodeInput([]string{"north", "large", "unseen"}); input[4] != 0 || input[0] != 1 {
+		t.Errorf("Expected an unseen brand to have frequency 0, got %v", input)
+	}
+	if _, err := md.EncodeInput([]string{"north", "huge", "acme"}); err == nil {
+		t.Errorf("Expected an error for a category outside the ordinal order, got nil")
+	}
+
+	opts.Encoders["size"] = data.Encoder{Kind: data.EncodeOrdinal, Categories: []string{"small", "large"}}
+	if _, err := data.LoadCSVWithOptions(filePath, opts); err == nil {
+		t.Errorf("Expected an error for an incomplete ordinal order, got nil")
+	}
+}
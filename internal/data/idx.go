@@ -0,0 +1,216 @@
+package data
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Magic numbers of the IDX files used by MNIST and Fashion-MNIST: unsigned bytes
+// with three dimensions for images and one dimension for labels.
+const (
+	idxImagesMagic = 0x00000803
+	idxLabelsMagic = 0x00000801
+)
+
+// idxFile is an IDX file being read, possibly through a gzip decompressor.
+type idxFile struct {
+	io.Reader
+	io.Closer
+}
+
+// openIDX opens an IDX file, transparently decompressing it if it is gzipped. It also
+// returns the decompressed size of the file, which bounds what its header may declare.
+func openIDX(filePath string) (io.ReadCloser, int64, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	reader := bufio.NewReader(file)
+	header, err := reader.Peek(2)
+	if err != nil {
+		file.Close()
+		return nil, 0, fmt.Errorf("reading %s: %w", filePath, err)
+	}
+	if header[0] != 0x1f || header[1] != 0x8b {
+		return idxFile{reader, file}, info.Size(), nil
+	}
+	// The gzip trailer ends with the decompressed size modulo 2^32.
+	var trailer [4]byte
+	if _, err := file.ReadAt(trailer[:], info.Size()-int64(len(trailer))); err != nil {
+		file.Close()
+		return nil, 0, fmt.Errorf("reading %s: %w", filePath, err)
+	}
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	return idxFile{gz, file}, int64(binary.LittleEndian.Uint32(trailer[:])), nil
+}
+
+// ReadIDXImages reads an IDX image file. Pixels are scaled from 0-255 to 0-1 and each
+// image is returned as a flat row-major slice.
+func ReadIDXImages(filePath string) (images [][]float64, rows, cols int, err error) {
+	file, size, err := openIDX(filePath)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer file.Close()
+
+	var header [4]uint32
+	if err := binary.Read(file, binary.BigEndian, &header); err != nil {
+		return nil, 0, 0, fmt.Errorf("reading IDX header: %w", err)
+	}
+	if header[0] != idxImagesMagic {
+		return nil, 0, 0, fmt.Errorf("%s is not an IDX image file (magic %#x)", filePath, header[0])
+	}
+	count, rows, cols := int(header[1]), int(header[2]), int(header[3])
+	if rows == 0 || cols == 0 {
+		return nil, 0, 0, fmt.Errorf("%s declares empty %dx%d images", filePath, rows, cols)
+	}
+	// Check the declared size against the file before allocating for it.
+	available := uint64(max(size-int64(binary.Size(header)), 0))
+	if uint64(count) > available/(uint64(rows)*uint64(cols)) {
+		return nil, 0, 0, fmt.Errorf("%s declares %d images of %dx%d pixels but holds only %d bytes of pixels", filePath, count, rows, cols, available)
+	}
+
+	pixels := make([]byte, rows*cols)
+	images = make([][]float64, count)
+	for i := range images {
+		if _, err := io.ReadFull(file, pixels); err != nil {
+			return nil, 0, 0, fmt.Errorf("reading image %d: %w", i, err)
+		}
+		images[i] = make([]float64, len(pixels))
+		for j, p := range pixels {
+			images[i][j] = float64(p) / 255
+		}
+	}
+	return images, rows, cols, nil
+}
+
+// ReadIDXLabels reads an IDX label file.
+func ReadIDXLabels(filePath string) ([]int, error) {
+	file, size, err := openIDX(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var header [2]uint32
+	if err := binary.Read(file, binary.BigEndian, &header); err != nil {
+		return nil, fmt.Errorf("reading IDX header: %w", err)
+	}
+	if header[0] != idxLabelsMagic {
+		return nil, fmt.Errorf("%s is not an IDX label file (magic %#x)", filePath, header[0])
+	}
+	if available := size - int64(binary.Size(header)); int64(header[1]) > available {
+		return nil, fmt.Errorf("%s declares %d labels but holds only %d bytes of labels", filePath, header[1], available)
+	}
+	raw := make([]byte, header[1])
+	if _, err := io.ReadFull(file, raw); err != nil {
+		return nil, fmt.Errorf("reading labels: %w", err)
+	}
+	labels := make([]int, len(raw))
+	for i, l := range raw {
+		labels[i] = int(l)
+	}
+	return labels, nil
+}
+
+// IsIDXImages reports whether a file name follows the MNIST naming scheme for image files,
+// such as train-images-idx3-ubyte or t10k-images-idx3-ubyte.gz.
+func IsIDXImages(filePath string) bool {
+	return strings.Contains(filepath.Base(filePath), "-images-idx3-ubyte")
+}
+
+// idxLabelsPath returns the label file that belongs to an image file.
+func idxLabelsPath(imagesPath string) string {
+	dir, base := filepath.Split(imagesPath)
+	return filepath.Join(dir, strings.Replace(base, "-images-idx3-ubyte", "-labels-idx1-ubyte", 1))
+}
+
+// loadIDXPair reads matching image and label files into one-hot encoded samples.
+func loadIDXPair(imagesPath string) (inputs, targets [][]float64, rows, cols int, err error) {
+	images, rows, cols, err := ReadIDXImages(imagesPath)
+	if err != nil {
+		return nil, nil, 0, 0, err
+	}
+	labels, err := ReadIDXLabels(idxLabelsPath(imagesPath))
+	if err != nil {
+		return nil, nil, 0, 0, err
+	}
+	if len(labels) != len(images) {
+		return nil, nil, 0, 0, fmt.Errorf("%d images but %d labels", len(images), len(labels))
+	}
+	targets = make([][]float64, len(labels))
+	for i, label := range labels {
+		if label > 9 {
+			return nil, nil, 0, 0, fmt.Errorf("label %d of image %d is not a digit class", label, i)
+		}
+		targets[i] = make([]float64, 10)
+		targets[i][label] = 1.0 // One-hot encoding
+	}
+	return images, targets, rows, cols, nil
+}
+
+// LoadIDX loads an MNIST-style image file and the label file next to it (the same name
+// with "labels-idx1" in place of "images-idx3"). Inputs are 1 x rows x cols images with
+// pixels scaled to 0-1, and targets are one-hot encoded over the ten classes "0" to "9".
+//
+// When imagesPath is a "train-" file and the matching "t10k-" files exist, they are used
+// as the test set and splitRatio is ignored. Otherwise the samples are shuffled and split.
+func LoadIDX(imagesPath string, splitRatio float64) (*Dataset, error) {
+	inputs, targets, rows, cols, err := loadIDXPair(imagesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var trainInputs, trainTargets, testInputs, testTargets [][]float64
+	dir, base := filepath.Split(imagesPath)
+	testPath := filepath.Join(dir, strings.Replace(base, "train-", "t10k-", 1))
+	if _, statErr := os.Stat(testPath); strings.HasPrefix(base, "train-") && statErr == nil {
+		testInputs, testTargets, _, _, err = loadIDXPair(testPath)
+		if err != nil {
+			return nil, err
+		}
+		trainInputs, trainTargets = inputs, targets
+		Shuffle(trainInputs, trainTargets)
+	} else {
+		Shuffle(inputs, targets)
+		trainInputs, trainTargets, testInputs, testTargets = SplitData(inputs, targets, splitRatio)
+	}
+
+	classMap := make(map[string]int, 10)
+	for i := 0; i < 10; i++ {
+		classMap[strconv.Itoa(i)] = i
+	}
+	// Raw pixel values are 0-255, so predictions on raw input are scaled the same way.
+	pipeline := &Pipeline{Scalers: make(Scalers, rows*cols)}
+	for i := range pipeline.Scalers {
+		pipeline.Scalers[i] = &MinMaxScaler{Min: 0, Max: 255}
+	}
+
+	return &Dataset{
+		TrainInputs:  trainInputs,
+		TrainTargets: trainTargets,
+		TestInputs:   testInputs,
+		TestTargets:  testTargets,
+		InputSize:    rows * cols,
+		OutputSize:   10,
+		Pipeline:     pipeline,
+		ClassMap:     classMap,
+		InputShape:   []int{1, rows, cols},
+	}, nil
+}
@@ -0,0 +1,104 @@
+package data_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go-neuralnetwork/internal/data"
+)
+
+// writeIDX writes an IDX file with the given header values followed by the payload.
+func writeIDX(t *testing.T, path string, compress bool, header []uint32, payload []byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, header)
+	buf.Write(payload)
+
+	content := buf.Bytes()
+	if compress {
+		var gz bytes.Buffer
+		w := gzip.NewWriter(&gz)
+		w.Write(content)
+		w.Close()
+		content = gz.Bytes()
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func TestLoadIDX(t *testing.T) {
+	dir := t.TempDir()
+
+	// Three 2x2 training images and one gzipped test image.
+	writeIDX(t, filepath.Join(dir, "train-images-idx3-ubyte"), false, []uint32{0x803, 3, 2, 2},
+		[]byte{0, 255, 0, 255, 255, 0, 255, 0, 51, 51, 51, 51})
+	writeIDX(t, filepath.Join(dir, "train-labels-idx1-ubyte"), false, []uint32{0x801, 3}, []byte{1, 7, 3})
+	writeIDX(t, filepath.Join(dir, "t10k-images-idx3-ubyte"), true, []uint32{0x803, 1, 2, 2}, []byte{0, 0, 255, 255})
+	writeIDX(t, filepath.Join(dir, "t10k-labels-idx1-ubyte"), true, []uint32{0x801, 1}, []byte{9})
+
+	if !data.IsIDXImages(filepath.Join(dir, "train-images-idx3-ubyte")) {
+		t.Errorf("Expected train-images-idx3-ubyte to be recognised as an IDX image file")
+	}
+
+	dataset, err := data.LoadIDX(filepath.Join(dir, "train-images-idx3-ubyte"), 0.5)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(dataset.InputShape, []int{1, 2, 2}) {
+		t.Errorf("Expected input shape [1 2 2], got %v", dataset.InputShape)
+	}
+	if len(dataset.TrainInputs) != 3 || len(dataset.TestInputs) != 1 {
+		t.Fatalf("Expected the t10k files to be the test set, got %d train and %d test samples", len(dataset.TrainInputs), len(dataset.TestInputs))
+	}
+	if !reflect.DeepEqual(dataset.TestInputs[0], []float64{0, 0, 1, 1}) {
+		t.Errorf("Expected pixels scaled to 0-1, got %v", dataset.TestInputs[0])
+	}
+	expectedTarget := make([]float64, 10)
+	expectedTarget[9] = 1
+	if !reflect.DeepEqual(dataset.TestTargets[0], expectedTarget) {
+		t.Errorf("Expected one-hot target for class 9, got %v", dataset.TestTargets[0])
+	}
+	for i, input := range dataset.TrainInputs {
+		if input[0] == 0.2 && dataset.TrainTargets[i][3] != 1 {
+			t.Errorf("Training image and label were separated by shuffling")
+		}
+	}
+	if dataset.ClassMap["7"] != 7 || len(dataset.ClassMap) != 10 {
+		t.Errorf("Expected a class map of the ten digits, got %v", dataset.ClassMap)
+	}
+
+	// Without the t10k files the training file is split.
+	testDataset, err := data.LoadIDX(filepath.Join(dir, "t10k-images-idx3-ubyte"), 1.0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(testDataset.TrainInputs) != 1 || len(testDataset.TestInputs) != 0 {
+		t.Errorf("Expected a 1/0 split, got %d/%d", len(testDataset.TrainInputs), len(testDataset.TestInputs))
+	}
+
+	// Label files are not image files.
+	if _, _, _, err := data.ReadIDXImages(filepath.Join(dir, "train-labels-idx1-ubyte")); err == nil {
+		t.Errorf("Expected an error when reading labels as images, got nil")
+	}
+
+	// A header that declares more images or labels than the file holds is rejected before allocating.
+	writeIDX(t, filepath.Join(dir, "bad-images-idx3-ubyte"), false, []uint32{0x803, 1 << 30, 28, 28}, []byte{0, 0, 0, 0})
+	if _, _, _, err := data.ReadIDXImages(filepath.Join(dir, "bad-images-idx3-ubyte")); err == nil {
+		t.Errorf("Expected an error for a truncated image file, got nil")
+	}
+	writeIDX(t, filepath.Join(dir, "bad-gz-images-idx3-ubyte"), true, []uint32{0x803, 1 << 30, 28, 28}, []byte{0, 0, 0, 0})
+	if _, _, _, err := data.ReadIDXImages(filepath.Join(dir, "bad-gz-images-idx3-ubyte")); err == nil {
+		t.Errorf("Expected an error for a truncated gzipped image file, got nil")
+	}
+	writeIDX(t, filepath.Join(dir, "bad-labels-idx1-ubyte"), false, []uint32{0x801, 1 << 30}, []byte{1})
+	if _, err := data.ReadIDXLabels(filepath.Join(dir, "bad-labels-idx1-ubyte")); err == nil {
+		t.Errorf("Expected an error for a truncated label file, got nil")
+	}
+}
@@ -0,0 +1,162 @@
+package data
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// ImputeStrategy selects how an Imputer fills in a missing value.
+type ImputeStrategy string
+
+const (
+	// ImputeDrop drops the rows in which the column is missing.
+	ImputeDrop ImputeStrategy = "drop"
+	// ImputeMean fills in the mean of the training rows.
+	ImputeMean ImputeStrategy = "mean"
+	// ImputeMedian fills in the median of the training rows.
+	ImputeMedian ImputeStrategy = "median"
+	// ImputeMode fills in the most frequent value of the training rows.
+	ImputeMode ImputeStrategy = "mode"
+	// ImputeConstant fills in a given value.
+	ImputeConstant ImputeStrategy = "constant"
+)
+
+// DefaultMissingTokens are the cell values treated as missing when
+// LoadOptions.MissingTokens is nil.
+var DefaultMissingTokens = []string{"", "NA"}
+
+// Imputer fills in the missing values of one numeric input column.
+type Imputer struct {
+	Column string `json:"column"`
+	// Position is the index of the column among the input values.
+	Position int            `json:"position"`
+	Strategy ImputeStrategy `json:"strategy"`
+	// Value is filled in for missing values: given for ImputeConstant, learned from the
+	// training rows for the other strategies.
+	Value float64 `json:"value"`
+	// Indicator adds an input feature that is 1 when the value was missing and 0 otherwise.
+	Indicator bool `json:"indicator,omitempty"`
+}
+
+// MissingValues describes how the missing cells of the input columns are handled.
+type MissingValues struct {
+	Tokens   []string  `json:"tokens"`
+	Imputers []Imputer `json:"imputers"`
+}
+
+// IsMissing reports whether a cell value is one of the missing-value tokens.
+func (m *MissingValues) IsMissing(value string) bool {
+	return m != nil && slices.Contains(m.Tokens, value)
+}
+
+// numIndicators returns the number of missing-indicator features the imputers add.
+func (m *MissingValues) numIndicators() int {
+	if m == nil {
+		return 0
+	}
+	n := 0
+	for _, imputer := range m.Imputers {
+		if imputer.Indicator {
+			n++
+		}
+	}
+	return n
+}
+
+// fill returns a copy of the input values of one row with the missing values filled in,
+// and the missing-indicator features of the row.
+func (m *MissingValues) fill(values []string) ([]string, []float64, error) {
+	if m == nil {
+		return values, nil, nil
+	}
+	filled := append([]string(nil), values...)
+	var indicators []float64
+	for _, imputer := range m.Imputers {
+		missing := m.IsMissing(values[imputer.Position])
+		if missing {
+			if imputer.Strategy == ImputeDrop {
+				return nil, nil, fmt.Errorf("column %q is missing", imputer.Column)
+			}
+			filled[imputer.Position] = strconv.FormatFloat(imputer.Value, 'g', -1, 64)
+		}
+		if imputer.Indicator && missing {
+			indicators = append(indicators, 1)
+		} else if imputer.Indicator {
+			indicators = append(indicators, 0)
+		}
+	}
+	return filled, indicators, nil
+}
+
+// keepRow reports whether a row has none of the given columns missing.
+func (m *MissingValues) keepRow(record []string, columns []int) bool {
+	for _, col := range columns {
+		if m.IsMissing(record[col]) {
+			return false
+		}
+	}
+	return true
+}
+
+// fit learns the fill value of every imputer from the training rows. values returns the
+// input values of a row.
+func (m *MissingValues) fit(records [][]string, values func([]string) []string) error {
+	for i := range m.Imputers {
+		imputer := &m.Imputers[i]
+		if imputer.Strategy == ImputeConstant || imputer.Strategy == ImputeDrop {
+			continue
+		}
+		var observed []float64
+		for _, record := range records {
+			value := values(record)[imputer.Position]
+			if m.IsMissing(value) {
+				continue
+			}
+			val, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("error parsing float %q in column %q: %w", value, imputer.Column, err)
+			}
+			observed = append(observed, val)
+		}
+		if len(observed) == 0 {
+			return fmt.Errorf("column %q has no values in the training rows to impute from", imputer.Column)
+		}
+		switch imputer.Strategy {
+		case ImputeMean:
+			sum := 0.0
+			for _, val := range observed {
+				sum += val
+			}
+			imputer.Value = sum / float64(len(observed))
+		case ImputeMedian:
+			imputer.Value = median(observed)
+		case ImputeMode:
+			counts := make(map[float64]int)
+			for _, val := range observed {
+				counts[val]++
+			}
+			// Ties go to the smallest value so the result does not depend on the row order.
+			imputer.Value = observed[0]
+			for val, count := range counts {
+				if count > counts[imputer.Value] || count == counts[imputer.Value] && val < imputer.Value {
+					imputer.Value = val
+				}
+			}
+		default:
+			return fmt.Errorf("unknown imputation strategy %q for column %q", imputer.Strategy, imputer.Column)
+		}
+	}
+	return nil
+}
+
+// median returns the median of the values, reordering them.
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
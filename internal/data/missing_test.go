@@ -0,0 +1,78 @@
+package data_test
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"go-neuralnetwork/internal/data"
+	"go-neuralnetwork/internal/tempfile"
+)
+
+func TestLoadCSVImputation(t *testing.T) {
+	csvContent := `a,b,c,target
+1,10,5,1
+NA,20,,2
+3,,7,3
+4,40,NA,
+5,20,9,5`
+	filePath, err := tempfile.CreateTempFileWithContent("missing-*.csv", csvContent)
+	if err != nil {
+		t.Fatalf("Failed to create temp CSV: %v", err)
+	}
+	defer os.Remove(filePath)
+
+	if _, err := data.LoadCSV(filePath, 1.0); err == nil {
+		t.Errorf("Expected LoadCSV to reject missing values, got nil")
+	}
+
+	opts := data.LoadOptions{SplitRatio: 1.0, Imputation: map[string]data.Imputer{
+		"a": {Strategy: data.ImputeMedian, Indicator: true},
+		"b": {Strategy: data.ImputeMode},
+		"*": {Strategy: data.ImputeConstant, Value: -1},
+	}}
+	dataset, err := data.LoadCSVWithOptions(filePath, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// The row with a missing target is dropped and a gets an indicator feature.
+	if len(dataset.TrainInputs) != 4 || dataset.InputSize != 4 {
+		t.Fatalf("Expected 4 rows of 4 inputs, got %d rows of %d", len(dataset.TrainInputs), dataset.InputSize)
+	}
+	var values []float64
+	for _, imputer := range dataset.Pipeline.Missing.Imputers {
+		values = append(values, imputer.Value)
+	}
+	if !reflect.DeepEqual(values, []float64{3, 20, -1}) {
+		t.Errorf("Expected fill values [3 20 -1], got %v", values)
+	}
+
+	md := &data.ModelData{Pipeline: dataset.Pipeline}
+	input, err := md.EncodeInput([]string{"NA", "", "9"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// a = 3 on [1, 5], b = 20 on [10, 20], c = 9 on [-1, 9], then the indicator of a.
+	expected := []float64{0.5, 1, 1, 1}
+	if !reflect.DeepEqual(input, expected) {
+		t.Errorf("Expected %v, got %v", expected, input)
+	}
+
+	opts.Imputation = map[string]data.Imputer{"b": {Strategy: data.ImputeDrop}, "*": {Strategy: data.ImputeMean}}
+	dataset, err = data.LoadCSVWithOptions(filePath, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(dataset.TrainInputs) != 3 {
+		t.Errorf("Expected the rows missing b or the target to be dropped, got %d rows", len(dataset.TrainInputs))
+	}
+	md.Pipeline = dataset.Pipeline
+	if _, err := md.EncodeInput([]string{"1", "NA", "5"}); err == nil {
+		t.Errorf("Expected an error for a missing value in a dropped column, got nil")
+	}
+
+	opts.Imputation = map[string]data.Imputer{"target": {Strategy: data.ImputeMean}}
+	if _, err := data.LoadCSVWithOptions(filePath, opts); err == nil {
+		t.Errorf("Expected an error for imputing a target column, got nil")
+	}
+}
@@ -2,18 +2,78 @@ package data
 
 import (
 	"encoding/json"
+	"io"
 	"os"
 
 	"go-neuralnetwork/internal/neuralnetwork"
 )
 
 type ModelData struct {
-	NN         *neuralnetwork.NeuralNetwork `json:"neuralNetwork"`
-	InputMins  []float64                    `json:"inputMins"`
-	InputMaxs  []float64                    `json:"inputMaxs"`
-	TargetMins []float64                    `json:"targetMins,omitempty"`
-	TargetMaxs []float64                    `json:"targetMaxs,omitempty"`
-	ClassMap   map[string]int               `json:"classMap,omitempty"`
+	Model *neuralnetwork.Sequential    `json:"model,omitempty"`
+	NN    *neuralnetwork.NeuralNetwork `json:"neuralNetwork,omitempty"`
+	// Pipeline turns raw input values into model inputs, as it did for the training rows.
+	Pipeline *Pipeline `json:"pipeline,omitempty"`
+	// TargetScalers map regression outputs back to the scale of the target columns.
+	TargetScalers Scalers        `json:"targetScalers,omitempty"`
+	ClassMap      map[string]int `json:"classMap,omitempty"`
+
+	// InputShape and InterleavedChannels record how Dataset.ReshapeChannels arranged the inputs.
+	InputShape          []int `json:"inputShape,omitempty"`
+	InterleavedChannels bool  `json:"interleavedChannels,omitempty"`
+	// SequenceLength is the number of time steps a sequence model reads (see LoadCSVSequences).
+	SequenceLength int `json:"sequenceLength,omitempty"`
+	// TimeSeries is set for forecasting models and enables Forecast.
+	TimeSeries *TimeSeriesOptions `json:"timeSeries,omitempty"`
+	// TargetNames names the regression outputs.
+	TargetNames []string `json:"targetNames,omitempty"`
+	// Heads decode the outputs of a multi-task model, one head at a time.
+	Heads []TaskHead `json:"heads,omitempty"`
+	// MultiLabel models predict every class of ClassMap whose score reaches its threshold.
+	MultiLabel bool      `json:"multiLabel,omitempty"`
+	Thresholds []float64 `json:"thresholds,omitempty"`
+}
+
+// DenormalizeTargets maps normalized regression outputs back to the scale of the target columns.
+func (md *ModelData) DenormalizeTargets(output []float64) []float64 {
+	values := make([]float64, len(output))
+	for i, val := range output {
+		values[i] = md.TargetScalers[i].Inverse(val)
+	}
+	return values
+}
+
+// ClassNames returns the names of the classes of a class map, in the order of their
+// output units.
+func ClassNames(classMap map[string]int) []string {
+	names := make([]string, len(classMap))
+	for name, index := range classMap {
+		names[index] = name
+	}
+	return names
+}
+
+// EncodeInput turns the raw input values of one row, with categories given as strings
+// and missing values as one of the missing-value tokens, into the input row of the model.
+func (md *ModelData) EncodeInput(values []string) ([]float64, error) {
+	input, err := md.Pipeline.Transform(values)
+	if err != nil {
+		return nil, err
+	}
+	if md.InterleavedChannels {
+		input = ChannelsFirst(input, md.InputShape[0])
+	}
+	return input, nil
+}
+
+// ScaleInputs normalizes raw numeric input values.
+func (md *ModelData) ScaleInputs(values []float64) ([]float64, error) {
+	return md.Pipeline.TransformValues(values)
+}
+
+// SequenceInput normalizes the rows of raw feature values of a sequence model and lays
+// them out like LoadCSVSequences does.
+func (md *ModelData) SequenceInput(rows [][]float64) ([]float64, error) {
+	return md.Pipeline.TransformSequence(rows, md.SequenceLength)
 }
 
 func (md *ModelData) SaveModel(filePath string) error {
@@ -35,11 +95,41 @@ func LoadModel(filePath string) (*ModelData, error) {
 	}
 	defer file.Close()
 
-	var md ModelData
-	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&md)
+	content, err := io.ReadAll(file)
 	if err != nil {
 		return nil, err
 	}
+	var md ModelData
+	if err := json.Unmarshal(content, &md); err != nil {
+		return nil, err
+	}
+
+	// Models saved before pipelines and scalers existed store min-max ranges instead.
+	var legacy struct {
+		InputMins  []float64 `json:"inputMins"`
+		InputMaxs  []float64 `json:"inputMaxs"`
+		TargetMins []float64 `json:"targetMins"`
+		TargetMaxs []float64 `json:"targetMaxs"`
+	}
+	if err := json.Unmarshal(content, &legacy); err != nil {
+		return nil, err
+	}
+	if md.Pipeline == nil {
+		md.Pipeline = &Pipeline{Scalers: MinMaxScalers(legacy.InputMins, legacy.InputMaxs)}
+	}
+	if md.TargetScalers == nil {
+		md.TargetScalers = MinMaxScalers(legacy.TargetMins, legacy.TargetMaxs)
+	}
+
+	// Models saved before Sequential existed only contain the dense network.
+	if md.Model == nil && md.NN != nil {
+		if err := md.NN.SetActivationFunctions(); err != nil {
+			return nil, err
+		}
+		md.Model, err = neuralnetwork.NewSequentialFromNetwork(md.NN)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return &md, nil
 }
@@ -0,0 +1,132 @@
+package data
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LabelSeparator separates the labels of one row in the label column of a multi-label CSV.
+const LabelSeparator = "|"
+
+// LoadCSVMultiLabel loads a CSV file whose last column lists any number of labels per
+// row, separated by LabelSeparator (e.g. "news|sport"). Targets are multi-hot: one value
+// per label in ClassMap, 1 when the row carries it. Such models are trained with sigmoid
+// outputs and binary cross-entropy, and every label is decided by its own threshold in
+// Thresholds, 0.5 until tuned. An empty label column means a row with no labels.
+func LoadCSVMultiLabel(filePath string, splitRatio float64) (*Dataset, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s has no data rows", filePath)
+	}
+
+	inputSize := len(header) - 1
+	classMap := make(map[string]int)
+	for _, record := range records {
+		for _, label := range splitLabels(record[inputSize]) {
+			if _, exists := classMap[label]; !exists {
+				classMap[label] = len(classMap)
+			}
+		}
+	}
+	outputSize := len(classMap)
+	if outputSize == 0 {
+		return nil, fmt.Errorf("%s has no labels in column %q", filePath, header[inputSize])
+	}
+
+	var inputs, targets [][]float64
+	for _, record := range records {
+		inputRow := make([]float64, inputSize)
+		for i := range inputRow {
+			val, err := strconv.ParseFloat(record[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing float in record %v: %w", record, err)
+			}
+			inputRow[i] = val
+		}
+		inputs = append(inputs, inputRow)
+
+		targetRow := make([]float64, outputSize)
+		for _, label := range splitLabels(record[inputSize]) {
+			targetRow[classMap[label]] = 1.0 // Multi-hot encoding
+		}
+		targets = append(targets, targetRow)
+	}
+
+	// Normalize with the ranges of the training rows only.
+	Shuffle(inputs, targets)
+	splitIndex := int(float64(len(inputs)) * splitRatio)
+	pipeline, err := fitNumericPipeline(header[:inputSize], inputs, splitIndex)
+	if err != nil {
+		return nil, err
+	}
+	trainInputs, trainTargets, testInputs, testTargets := SplitData(inputs, targets, splitRatio)
+
+	thresholds := make([]float64, outputSize)
+	for i := range thresholds {
+		thresholds[i] = 0.5
+	}
+	return &Dataset{
+		TrainInputs:  trainInputs,
+		TrainTargets: trainTargets,
+		TestInputs:   testInputs,
+		TestTargets:  testTargets,
+		InputSize:    inputSize,
+		OutputSize:   outputSize,
+		InputShape:   []int{inputSize},
+		Pipeline:     pipeline,
+		ClassMap:     classMap,
+		MultiLabel:   true,
+		Thresholds:   thresholds,
+	}, nil
+}
+
+// splitLabels returns the non-empty labels of a label column value.
+func splitLabels(value string) []string {
+	var labels []string
+	for _, label := range strings.Split(value, LabelSeparator) {
+		if label = strings.TrimSpace(label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// hasMultipleLabels reports whether any record lists several labels in the given column.
+func hasMultipleLabels(records [][]string, col int) bool {
+	for _, record := range records {
+		if strings.Contains(record[col], LabelSeparator) {
+			return true
+		}
+	}
+	return false
+}
+
+// Labels returns the labels of a multi-label model whose scores in output reach their
+// thresholds, in ClassMap order.
+func (md *ModelData) Labels(output []float64) []string {
+	names := ClassNames(md.ClassMap)
+	var labels []string
+	for i, score := range output {
+		if score >= md.Thresholds[i] {
+			labels = append(labels, names[i])
+		}
+	}
+	return labels
+}
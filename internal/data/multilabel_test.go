@@ -0,0 +1,120 @@
+package data_test
+
+import (
+	"math"
+	"os"
+	"reflect"
+	"slices"
+	"testing"
+
+	"go-neuralnetwork/internal/data"
+	"go-neuralnetwork/internal/tempfile"
+)
+
+func TestLoadCSVMultiLabel(t *testing.T) {
+	csvContent := `x,y,tags
+1,2,news|sport
+3,4,sport
+5,6,
+7,8,weather | news`
+	filePath, err := tempfile.CreateTempFileWithContent("multilabel-*.csv", csvContent)
+	if err != nil {
+		t.Fatalf("Failed to create temp CSV: %v", err)
+	}
+	defer os.Remove(filePath)
+
+	// LoadCSV switches to the multi-label loader when a row lists several labels.
+	dataset, err := data.LoadCSV(filePath, 1.0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !dataset.MultiLabel || dataset.OutputSize != 3 {
+		t.Fatalf("Expected a multi-label dataset with 3 labels, got %d outputs", dataset.OutputSize)
+	}
+	if !reflect.DeepEqual(dataset.Thresholds, []float64{0.5, 0.5, 0.5}) {
+		t.Errorf("Expected default thresholds of 0.5, got %v", dataset.Thresholds)
+	}
+
+	news, sport, weather := dataset.ClassMap["news"], dataset.ClassMap["sport"], dataset.ClassMap["weather"]
+	expected := map[float64][]int{0: {news, sport}, 1.0 / 3: {sport}, 2.0 / 3: nil, 1: {weather, news}}
+	for i, input := range dataset.TrainInputs {
+		var labels []int
+		for label, val := range dataset.TrainTargets[i] {
+			if val == 1 {
+				labels = append(labels, label)
+			}
+		}
+		want := map[int]bool{}
+		for _, label := range expected[float64(int(input[0]*3+0.5))/3] {
+			want[label] = true
+		}
+		if len(labels) != len(want) {
+			t.Errorf("Input %v: expected labels %v, got %v", input, want, labels)
+		}
+		for _, label := range labels {
+			if !want[label] {
+				t.Errorf("Input %v: unexpected label %d", input, label)
+			}
+		}
+	}
+
+	// The ranges are fitted on the training rows, which then span exactly [0, 1].
+	if dataset, err = data.LoadCSVMultiLabel(filePath, 0.5); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for i := range dataset.InputSize {
+		low, high := math.Min(dataset.TrainInputs[0][i], dataset.TrainInputs[1][i]), math.Max(dataset.TrainInputs[0][i], dataset.TrainInputs[1][i])
+		if low != 0 || high != 1 {
+			t.Errorf("Column %d: expected training values spanning [0, 1], got [%v, %v]", i, low, high)
+		}
+	}
+
+	md := &data.ModelData{ClassMap: dataset.ClassMap, MultiLabel: true, Thresholds: []float64{0.5, 0.5, 0.9}}
+	output := make([]float64, 3)
+	output[news], output[sport], output[weather] = 0.7, 0.2, 0.8
+	// Labels are numbered in order of appearance, so weather has the 0.9 threshold.
+	if labels := md.Labels(output); !reflect.DeepEqual(labels, []string{"news"}) {
+		t.Errorf("Expected only the labels reaching their thresholds, got %v", labels)
+	}
+}
+
+func TestLoadCSVWithOptionsMultiLabel(t *testing.T) {
+	csvContent := `x,tags,y
+1,news|sport,2
+3,sport,4
+5,,6
+7,weather | news,8`
+	filePath, err := tempfile.CreateTempFileWithContent("multilabel-*.csv", csvContent)
+	if err != nil {
+		t.Fatalf("Failed to create temp CSV: %v", err)
+	}
+	defer os.Remove(filePath)
+
+	dataset, err := data.LoadCSVWithOptions(filePath, data.LoadOptions{SplitRatio: 0.5, ValidationRatio: 0.25, TargetColumns: []string{"tags"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !dataset.MultiLabel || dataset.OutputSize != 3 || dataset.InputSize != 2 {
+		t.Fatalf("Expected a multi-label dataset with 2 inputs and 3 labels, got %d inputs and %d outputs", dataset.InputSize, dataset.OutputSize)
+	}
+	if len(dataset.TrainTargets) != 2 || len(dataset.ValidationTargets) != 1 || len(dataset.TestTargets) != 1 {
+		t.Fatalf("Expected 2 training, 1 validation and 1 test row, got %d, %d and %d", len(dataset.TrainTargets), len(dataset.ValidationTargets), len(dataset.TestTargets))
+	}
+	if !reflect.DeepEqual(dataset.Thresholds, []float64{0.5, 0.5, 0.5}) {
+		t.Errorf("Expected default thresholds of 0.5, got %v", dataset.Thresholds)
+	}
+	counts := make([]float64, dataset.OutputSize)
+	for _, target := range slices.Concat(dataset.TrainTargets, dataset.ValidationTargets, dataset.TestTargets) {
+		for label, val := range target {
+			counts[label] += val
+		}
+	}
+	news, sport, weather := dataset.ClassMap["news"], dataset.ClassMap["sport"], dataset.ClassMap["weather"]
+	if counts[news] != 2 || counts[sport] != 2 || counts[weather] != 1 {
+		t.Errorf("Expected news and sport on 2 rows and weather on 1, got %v", counts)
+	}
+
+	if _, err := data.LoadCSVWithOptions(filePath, data.LoadOptions{SplitRatio: 0.5, TargetColumns: []string{"tags", "y"}}); err == nil {
+		t.Errorf("Expected an error for a multi-label column among several targets, got nil")
+	}
+}
@@ -0,0 +1,507 @@
+package data
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+)
+
+// TaskType selects how LoadCSVWithOptions encodes the targets.
+type TaskType string
+
+const (
+	// TaskAuto treats a target as a class label when it is not numeric in the first row.
+	TaskAuto TaskType = ""
+	// TaskRegression predicts the normalized value of every target.
+	TaskRegression TaskType = "regression"
+	// TaskClassification one-hot encodes every target, even integer-coded ones.
+	TaskClassification TaskType = "classification"
+)
+
+// LoadOptions configures LoadCSVWithOptions. Columns are referred to by header name or,
+// when no column has that name, by 0-based index, negative indices counting from the end
+// (-1 is the last column).
+type LoadOptions struct {
+	// SplitRatio is the fraction of rows used for training.
+	SplitRatio float64
+	// ValidationRatio is the fraction of rows held out for validation; the test set gets
+	// the rows left after training and validation.
+	ValidationRatio float64
+	// Split selects how rows are assigned to the sets, randomly by default.
+	Split SplitStrategy
+	// GroupColumn holds the group IDs of SplitGroup; it is not used as an input column.
+	GroupColumn string
+	// Fold, when set, makes the dataset one fold of k-fold cross-validation divided by
+	// FoldRows with the Split strategy; SplitRatio and ValidationRatio are then ignored.
+	Fold *Fold
+	// TargetColumns are the columns to predict; empty means the last column. Several
+	// target columns make a multi-output regression dataset.
+	TargetColumns []string
+	// TaskType forces regression or classification instead of guessing from the data.
+	// Several classification targets make a multi-task dataset.
+	TaskType TaskType
+	// IncludeColumns, when set, restricts the input columns to those listed.
+	IncludeColumns []string
+	// ExcludeColumns are left out of the input columns.
+	ExcludeColumns []string
+	// MultiTask trains one output head per target column. It is implied when several
+	// target columns include a non-numeric one.
+	MultiTask bool
+	// CategoricalColumns maps the names of categorical input columns to the dimension of
+	// the embedding learned for them. All other input columns must be numeric.
+	CategoricalColumns map[string]int
+	// Scalers maps numeric input and target columns to the name of the scaler that
+	// normalizes them (see GetAvailableScalers); the key "*" applies to every other
+	// column and the default is "minmax". Scalers are fitted on the training rows.
+	Scalers map[string]string
+	// Encoders maps non-numeric input columns to how they are turned into features. Only
+	// the Kind, MaxCategories and, for EncodeOrdinal, the Categories in order are read.
+	Encoders map[string]Encoder
+	// MissingTokens are the cell values treated as missing; nil means DefaultMissingTokens.
+	MissingTokens []string
+	// Imputation maps numeric input columns to how their missing values are handled; the
+	// key "*" applies to every other numeric input column. Only the Strategy, the Value of
+	// ImputeConstant and Indicator are read. When Imputation or MissingTokens is set, rows
+	// with a missing target are dropped.
+	Imputation map[string]Imputer
+	// Features are extra numeric inputs computed from the numeric input columns, scaled
+	// like them. Only the Name, Op and Columns are read.
+	Features []Feature
+}
+
+// LoadCSVWithOptions loads a CSV file like LoadCSV, with a choice of target columns and
+// optionally categorical input columns. The rows are split first into training,
+// validation and test sets (see SplitRows); the numeric ranges and the category
+// vocabularies are then built from the training rows only.
+//
+// Each input row holds the normalized numeric input columns in file order, then the
+// features of the encoded columns and the missing-indicator features, then the
+// vocabulary index of every categorical column, which an Embedding layer turns into
+// learned vectors. Missing values are filled in with statistics of the training rows
+// and LoadOptions.Features adds engineered features after the numeric columns. These steps make up Dataset.Pipeline, which turns every row into its
+// input row and is saved with the model to do the same at prediction time.
+//
+// Numeric inputs and regression targets are normalized by the scalers chosen in
+// LoadOptions.Scalers, min-max by default, fitted on the training rows and returned in
+// Pipeline.Scalers and TargetScalers; TargetMins and TargetMaxs hold the training range
+// of every target. A single non-numeric target column, or any single target when TaskType
+// is TaskClassification, is one-hot encoded instead, or multi-hot encoded like in
+// LoadCSVMultiLabel when a row lists several labels in it.
+//
+// Multi-task datasets give every target column its own head instead, described in
+// Dataset.Heads: non-numeric columns are one-hot encoded classification heads and
+// numeric ones are regression heads normalized by their own scaler from
+// LoadOptions.Scalers. The target row holds the heads one after the other.
+func LoadCSVWithOptions(filePath string, opts LoadOptions) (*Dataset, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s has no data rows", filePath)
+	}
+
+	switch opts.TaskType {
+	case TaskAuto, TaskRegression, TaskClassification:
+	default:
+		return nil, fmt.Errorf("unknown task type %q", opts.TaskType)
+	}
+	targetRefs := opts.TargetColumns
+	if len(targetRefs) == 0 {
+		targetRefs = []string{"-1"}
+	}
+	targetColumns, err := resolveColumns(header, targetRefs)
+	if err != nil {
+		return nil, fmt.Errorf("target columns: %w", err)
+	}
+	targetNames := make([]string, len(targetColumns))
+	isTarget := make(map[int]bool, len(targetColumns))
+	for i, col := range targetColumns {
+		if isTarget[col] {
+			return nil, fmt.Errorf("target column %q is listed twice", header[col])
+		}
+		targetNames[i] = header[col]
+		isTarget[col] = true
+	}
+	isFeature := make(map[int]bool, len(header))
+	if len(opts.IncludeColumns) > 0 {
+		included, err := resolveColumns(header, opts.IncludeColumns)
+		if err != nil {
+			return nil, fmt.Errorf("include columns: %w", err)
+		}
+		for _, col := range included {
+			isFeature[col] = true
+		}
+	} else {
+		for i := range header {
+			isFeature[i] = true
+		}
+	}
+	excluded, err := resolveColumns(header, opts.ExcludeColumns)
+	if err != nil {
+		return nil, fmt.Errorf("exclude columns: %w", err)
+	}
+	for _, col := range excluded {
+		delete(isFeature, col)
+	}
+	var groupColumn int
+	if opts.Split == SplitGroup {
+		if opts.GroupColumn == "" {
+			return nil, fmt.Errorf("group splits need a group column")
+		}
+		groupColumns, err := resolveColumns(header, []string{opts.GroupColumn})
+		if err != nil {
+			return nil, fmt.Errorf("group column: %w", err)
+		}
+		groupColumn = groupColumns[0]
+		delete(isFeature, groupColumn)
+	}
+
+	// Input values are the remaining columns in file order; numericInputs and the
+	// encoder and vocabulary positions index into them.
+	var inputColumns, numericInputs []int
+	var encoders []Encoder
+	var vocabularies []Vocabulary
+	for i, name := range header {
+		if isTarget[i] || !isFeature[i] {
+			continue
+		}
+		if dim, ok := opts.CategoricalColumns[name]; ok {
+			if dim < 1 {
+				return nil, fmt.Errorf("embedding dimension of column %q must be positive", name)
+			}
+			vocabularies = append(vocabularies, Vocabulary{Column: name, Position: len(inputColumns), Dim: dim, Tokens: map[string]int{}})
+		} else if encoder, ok := opts.Encoders[name]; ok {
+			encoder.Column, encoder.Position = name, len(inputColumns)
+			encoders = append(encoders, encoder)
+		} else {
+			numericInputs = append(numericInputs, len(inputColumns))
+		}
+		inputColumns = append(inputColumns, i)
+	}
+	if len(vocabularies) != len(opts.CategoricalColumns) {
+		return nil, fmt.Errorf("categorical columns must name input columns of %s", filePath)
+	}
+	if len(encoders) != len(opts.Encoders) {
+		return nil, fmt.Errorf("encoders must name input columns of %s that are not categorical", filePath)
+	}
+	inputValues := func(record []string) []string {
+		values := make([]string, len(inputColumns))
+		for i, col := range inputColumns {
+			values[i] = record[col]
+		}
+		return values
+	}
+
+	pipeline := &Pipeline{Encoders: encoders, Vocabularies: vocabularies}
+	for _, col := range inputColumns {
+		pipeline.Columns = append(pipeline.Columns, header[col])
+	}
+	for _, pos := range numericInputs {
+		scaler, err := NewScaler(scalerType(opts.Scalers, header[inputColumns[pos]]))
+		if err != nil {
+			return nil, err
+		}
+		pipeline.Scalers = append(pipeline.Scalers, scaler)
+	}
+	for _, feature := range opts.Features {
+		if feature.Positions, err = resolveFeature(feature, pipeline.Columns, numericInputs); err != nil {
+			return nil, err
+		}
+		scaler, err := NewScaler(scalerType(opts.Scalers, feature.Name))
+		if err != nil {
+			return nil, err
+		}
+		pipeline.Features = append(pipeline.Features, feature)
+		pipeline.Scalers = append(pipeline.Scalers, scaler)
+	}
+	if opts.Imputation != nil || opts.MissingTokens != nil {
+		if pipeline.Missing, records, err = newMissingValues(header, records, opts, inputColumns, numericInputs, targetColumns); err != nil {
+			return nil, err
+		}
+	}
+
+	var labels, groups []string
+	for _, record := range records {
+		switch opts.Split {
+		case SplitStratified:
+			labels = append(labels, record[targetColumns[0]])
+		case SplitGroup:
+			groups = append(groups, record[groupColumn])
+		}
+	}
+	if _, err := strconv.ParseFloat(records[0][targetColumns[0]], 64); opts.Split == SplitStratified && (opts.TaskType == TaskRegression || err == nil && opts.TaskType == TaskAuto) {
+		return nil, fmt.Errorf("stratified splits need a classification target")
+	}
+	var split Split
+	if opts.Fold != nil {
+		split, err = FoldRows(len(records), opts.Split, *opts.Fold, labels, groups)
+	} else {
+		split, err = SplitRows(len(records), opts.Split, opts.SplitRatio, opts.ValidationRatio, labels, groups)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ordered := make([][]string, 0, len(records))
+	for _, i := range split.Order() {
+		ordered = append(ordered, records[i])
+	}
+	records = ordered
+	splitIndex := len(split.Train)
+	validationEnd := splitIndex + len(split.Validation)
+	trainValues := make([][]string, splitIndex)
+	for i, record := range records[:splitIndex] {
+		trainValues[i] = inputValues(record)
+	}
+	if err := pipeline.Fit(trainValues); err != nil {
+		return nil, err
+	}
+
+	multiTask := opts.MultiTask || (len(targetColumns) > 1 && opts.TaskType == TaskClassification)
+	for _, col := range targetColumns {
+		if _, err := strconv.ParseFloat(records[0][col], 64); err != nil && len(targetColumns) > 1 && opts.TaskType == TaskAuto {
+			multiTask = true
+		}
+	}
+
+	multiLabel := false
+	for _, col := range targetColumns {
+		if hasMultipleLabels(records, col) && opts.TaskType != TaskRegression {
+			if multiTask || len(targetColumns) > 1 {
+				return nil, fmt.Errorf("multi-label target column %q must be the only target", header[col])
+			}
+			multiLabel = true
+		}
+	}
+
+	var classMap map[string]int
+	var targetMins, targetMaxs, thresholds []float64
+	var targetScalers []Scaler
+	var heads []TaskHead
+	outputSize := len(targetColumns)
+	if multiTask {
+		if heads, err = newTaskHeads(header, records, records[:splitIndex], targetColumns, opts.TaskType, opts.Scalers); err != nil {
+			return nil, err
+		}
+		last := heads[len(heads)-1]
+		outputSize = last.Offset + last.Size
+	} else if multiLabel {
+		classMap = make(map[string]int)
+		for _, record := range records {
+			for _, label := range splitLabels(record[targetColumns[0]]) {
+				if _, exists := classMap[label]; !exists {
+					classMap[label] = len(classMap)
+				}
+			}
+		}
+		outputSize = len(classMap)
+		thresholds = make([]float64, outputSize)
+		for i := range thresholds {
+			thresholds[i] = 0.5
+		}
+	} else if _, err := strconv.ParseFloat(records[0][targetColumns[0]], 64); opts.TaskType == TaskClassification || (err != nil && opts.TaskType == TaskAuto) {
+		classMap = make(map[string]int)
+		for _, record := range records {
+			if _, exists := classMap[record[targetColumns[0]]]; !exists {
+				classMap[record[targetColumns[0]]] = len(classMap)
+			}
+		}
+		outputSize = len(classMap)
+	} else {
+		targetMins = make([]float64, len(targetColumns))
+		targetMaxs = make([]float64, len(targetColumns))
+		for i := range targetMins {
+			targetMins[i] = math.Inf(1)
+			targetMaxs[i] = math.Inf(-1)
+		}
+		var trainTargets [][]float64
+		for _, record := range records[:splitIndex] {
+			row := make([]float64, len(targetColumns))
+			for i, col := range targetColumns {
+				val, err := strconv.ParseFloat(record[col], 64)
+				if err != nil {
+					return nil, fmt.Errorf("error parsing target %q in record %v: %w", header[col], record, err)
+				}
+				targetMins[i] = min(targetMins[i], val)
+				targetMaxs[i] = max(targetMaxs[i], val)
+				row[i] = val
+			}
+			trainTargets = append(trainTargets, row)
+		}
+		types := make([]string, len(targetColumns))
+		for i, col := range targetColumns {
+			types[i] = scalerType(opts.Scalers, header[col])
+		}
+		if targetScalers, err = fitScalers(trainTargets, types); err != nil {
+			return nil, err
+		}
+	}
+
+	var inputs, targets [][]float64
+	for _, record := range records {
+		inputRow, err := pipeline.Transform(inputValues(record))
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, inputRow)
+
+		targetRow := make([]float64, outputSize)
+		if heads != nil {
+			if targetRow, err = encodeTaskTargets(heads, record, targetColumns); err != nil {
+				return nil, err
+			}
+		} else if multiLabel {
+			for _, label := range splitLabels(record[targetColumns[0]]) {
+				targetRow[classMap[label]] = 1.0 // Multi-hot encoding
+			}
+		} else if classMap != nil {
+			targetRow[classMap[record[targetColumns[0]]]] = 1.0 // One-hot encoding
+		} else {
+			for i, col := range targetColumns {
+				val, err := strconv.ParseFloat(record[col], 64)
+				if err != nil {
+					return nil, fmt.Errorf("error parsing target %q in record %v: %w", header[col], record, err)
+				}
+				targetRow[i] = targetScalers[i].Transform(val)
+			}
+		}
+		targets = append(targets, targetRow)
+	}
+
+	inputSize := pipeline.Size()
+	return &Dataset{
+		TrainInputs:  inputs[:splitIndex],
+		TrainTargets: targets[:splitIndex],
+		TestInputs:   inputs[validationEnd:],
+		TestTargets:  targets[validationEnd:],
+		InputSize:    inputSize,
+		OutputSize:   outputSize,
+		TargetMins:   targetMins,
+		TargetMaxs:   targetMaxs,
+		ClassMap:     classMap,
+		MultiLabel:   multiLabel,
+		Thresholds:   thresholds,
+		InputShape:   []int{inputSize},
+		Pipeline:     pipeline,
+
+		ValidationInputs:  inputs[splitIndex:validationEnd],
+		ValidationTargets: targets[splitIndex:validationEnd],
+		TargetScalers:     targetScalers,
+		TargetNames:       targetNames,
+		Heads:             heads,
+	}, nil
+}
+
+// resolveFeature checks an engineered feature and returns the positions of its columns
+// among the input values, which must be numeric.
+func resolveFeature(feature Feature, inputNames []string, numericInputs []int) ([]int, error) {
+	if feature.Name == "" {
+		return nil, fmt.Errorf("engineered features need a name")
+	}
+	if n := feature.arity(); n == 0 {
+		return nil, fmt.Errorf("feature %q: unknown operation %q", feature.Name, feature.Op)
+	} else if len(feature.Columns) != n {
+		return nil, fmt.Errorf("feature %q: %s takes %d columns, got %d", feature.Name, feature.Op, n, len(feature.Columns))
+	}
+	positions := make([]int, len(feature.Columns))
+	for i, name := range feature.Columns {
+		positions[i] = slices.Index(inputNames, name)
+		if !slices.Contains(numericInputs, positions[i]) {
+			return nil, fmt.Errorf("feature %q: %q is not a numeric input column", feature.Name, name)
+		}
+	}
+	return positions, nil
+}
+
+// scalerType returns the scaler chosen for a column in LoadOptions.Scalers.
+func scalerType(scalers map[string]string, column string) string {
+	if name, ok := scalers[column]; ok {
+		return name
+	}
+	if name, ok := scalers["*"]; ok {
+		return name
+	}
+	return "minmax"
+}
+
+// newMissingValues sets up the imputers of LoadCSVWithOptions and drops the rows with a
+// missing target or a missing value in a column imputed with ImputeDrop.
+func newMissingValues(header []string, records [][]string, opts LoadOptions, inputColumns, numericInputs, targetColumns []int) (*MissingValues, [][]string, error) {
+	missing := &MissingValues{Tokens: opts.MissingTokens}
+	if missing.Tokens == nil {
+		missing.Tokens = DefaultMissingTokens
+	}
+	dropColumns := append([]int(nil), targetColumns...)
+	named := 0
+	for _, pos := range numericInputs {
+		name := header[inputColumns[pos]]
+		imputer, ok := opts.Imputation[name]
+		if ok {
+			named++
+		} else if imputer, ok = opts.Imputation["*"]; !ok {
+			continue
+		}
+		imputer.Column, imputer.Position = name, pos
+		if imputer.Strategy == ImputeDrop {
+			if imputer.Indicator {
+				return nil, nil, fmt.Errorf("column %q: rows with missing values are dropped, so they need no indicator", name)
+			}
+			dropColumns = append(dropColumns, inputColumns[pos])
+		}
+		missing.Imputers = append(missing.Imputers, imputer)
+	}
+	if _, ok := opts.Imputation["*"]; ok {
+		named++
+	}
+	if named != len(opts.Imputation) {
+		return nil, nil, fmt.Errorf("imputation must name numeric input columns")
+	}
+
+	var kept [][]string
+	for _, record := range records {
+		if missing.keepRow(record, dropColumns) {
+			kept = append(kept, record)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, nil, fmt.Errorf("every row has missing values")
+	}
+	return missing, kept, nil
+}
+
+// resolveColumns finds the columns referred to by name or index, as described on LoadOptions.
+func resolveColumns(header []string, refs []string) ([]int, error) {
+	columns := make([]int, len(refs))
+	for i, ref := range refs {
+		columns[i] = slices.Index(header, ref)
+		if columns[i] >= 0 {
+			continue
+		}
+		index, err := strconv.Atoi(ref)
+		if err != nil {
+			return nil, fmt.Errorf("column %q not found", ref)
+		}
+		if index < 0 {
+			index += len(header)
+		}
+		if index < 0 || index >= len(header) {
+			return nil, fmt.Errorf("column index %d is out of range for %d columns", index, len(header))
+		}
+		columns[i] = index
+	}
+	return columns, nil
+}
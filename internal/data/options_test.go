@@ -0,0 +1,111 @@
+package data_test
+
+import (
+	"math"
+	"os"
+	"reflect"
+	"testing"
+
+	"go-neuralnetwork/internal/data"
+	"go-neuralnetwork/internal/tempfile"
+)
+
+func TestLoadCSVMultipleTargets(t *testing.T) {
+	csvContent := `a,price,b,volume
+1,100,5,1
+2,150,6,3
+3,300,7,5`
+	filePath, err := tempfile.CreateTempFileWithContent("targets-*.csv", csvContent)
+	if err != nil {
+		t.Fatalf("Failed to create temp CSV: %v", err)
+	}
+	defer os.Remove(filePath)
+
+	dataset, err := data.LoadCSVWithOptions(filePath, data.LoadOptions{SplitRatio: 1.0, TargetColumns: []string{"volume", "price"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if dataset.InputSize != 2 || dataset.OutputSize != 2 || dataset.ClassMap != nil {
+		t.Fatalf("Expected 2 inputs and 2 regression outputs, got %d and %d", dataset.InputSize, dataset.OutputSize)
+	}
+	if !reflect.DeepEqual(dataset.TargetNames, []string{"volume", "price"}) {
+		t.Errorf("Unexpected target names %v", dataset.TargetNames)
+	}
+	if !reflect.DeepEqual(dataset.TargetMins, []float64{1, 100}) || !reflect.DeepEqual(dataset.TargetMaxs, []float64{5, 300}) {
+		t.Errorf("Expected per-target ranges, got mins %v and maxs %v", dataset.TargetMins, dataset.TargetMaxs)
+	}
+
+	md := &data.ModelData{TargetScalers: dataset.TargetScalers}
+	for i, input := range dataset.TrainInputs {
+		values := md.DenormalizeTargets(dataset.TrainTargets[i])
+		// Column a is 1, 2 or 3 and determines both targets of its row.
+		row := int(math.Round(input[0]*2)) + 1
+		expected := map[int][]float64{1: {1, 100}, 2: {3, 150}, 3: {5, 300}}[row]
+		if math.Abs(values[0]-expected[0]) > 1e-9 || math.Abs(values[1]-expected[1]) > 1e-9 {
+			t.Errorf("Row %d: expected targets %v, got %v", row, expected, values)
+		}
+	}
+
+	if _, err := data.LoadCSVWithOptions(filePath, data.LoadOptions{SplitRatio: 1.0, TargetColumns: []string{"missing"}}); err == nil {
+		t.Errorf("Expected an error for an unknown target column, got nil")
+	}
+}
+
+func TestLoadCSVTaskTypeAndColumns(t *testing.T) {
+	csvContent := `id,quality,alcohol,acidity
+1,5,9.4,0.7
+2,6,9.8,0.8
+3,5,10.2,0.6
+4,7,11.0,0.5`
+	filePath, err := tempfile.CreateTempFileWithContent("columns-*.csv", csvContent)
+	if err != nil {
+		t.Fatalf("Failed to create temp CSV: %v", err)
+	}
+	defer os.Remove(filePath)
+
+	// Integer-coded classes in the second column, without the id column as an input.
+	dataset, err := data.LoadCSVWithOptions(filePath, data.LoadOptions{
+		SplitRatio:     1.0,
+		TargetColumns:  []string{"1"},
+		TaskType:       data.TaskClassification,
+		ExcludeColumns: []string{"id"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if dataset.InputSize != 2 || dataset.OutputSize != 3 || len(dataset.ClassMap) != 3 {
+		t.Fatalf("Expected 2 inputs and 3 classes, got %d inputs and classes %v", dataset.InputSize, dataset.ClassMap)
+	}
+	if !reflect.DeepEqual(dataset.TargetNames, []string{"quality"}) {
+		t.Errorf("Expected the quality column as target, got %v", dataset.TargetNames)
+	}
+	for i, input := range dataset.TrainInputs {
+		// The highest alcohol is the only row of quality 7.
+		if (input[0] == 1) != (dataset.TrainTargets[i][dataset.ClassMap["7"]] == 1) {
+			t.Errorf("Row with inputs %v has targets %v", input, dataset.TrainTargets[i])
+		}
+	}
+
+	dataset, err = data.LoadCSVWithOptions(filePath, data.LoadOptions{
+		SplitRatio:     1.0,
+		TargetColumns:  []string{"quality"},
+		TaskType:       data.TaskRegression,
+		IncludeColumns: []string{"-1"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if dataset.InputSize != 1 || dataset.ClassMap != nil || dataset.TargetMins[0] != 5 || dataset.TargetMaxs[0] != 7 {
+		t.Errorf("Expected regression on quality from the acidity column, got %+v", dataset)
+	}
+
+	for _, opts := range []data.LoadOptions{
+		{SplitRatio: 1.0, TargetColumns: []string{"9"}},
+		{SplitRatio: 1.0, TaskType: "ranking"},
+		{SplitRatio: 1.0, ExcludeColumns: []string{"missing"}},
+	} {
+		if _, err := data.LoadCSVWithOptions(filePath, opts); err == nil {
+			t.Errorf("Expected an error for options %+v, got nil", opts)
+		}
+	}
+}
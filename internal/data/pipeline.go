@@ -0,0 +1,302 @@
+package data
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Pipeline turns the raw input values of one row into a model input row. It is fitted
+// once on the training rows by the loader that builds the dataset and saved in the model
+// file, so rows seen at prediction time go through exactly the same steps as the
+// training rows. The steps run in this order:
+//
+//  1. missing values of numeric columns are filled in (Missing),
+//  2. engineered features are computed from the filled-in numeric columns (Features),
+//  3. the numeric columns, followed by the engineered features, are scaled (Scalers),
+//  4. encoded columns are turned into features (Encoders),
+//  5. the missing-indicator features are added, and
+//  6. categorical columns are mapped to embedding indices (Vocabularies).
+//
+// Every input column that is neither encoded nor categorical is numeric.
+type Pipeline struct {
+	// Columns names the raw input values, in order.
+	Columns      []string       `json:"columns,omitempty"`
+	Missing      *MissingValues `json:"missing,omitempty"`
+	Features     []Feature      `json:"features,omitempty"`
+	Scalers      Scalers        `json:"scalers"`
+	Encoders     []Encoder      `json:"encoders,omitempty"`
+	Vocabularies []Vocabulary   `json:"vocabularies,omitempty"`
+}
+
+// NewPipeline returns an unfitted pipeline for numeric columns that are min-max scaled.
+func NewPipeline(columns []string) *Pipeline {
+	p := &Pipeline{Columns: columns, Scalers: make(Scalers, len(columns))}
+	for i := range p.Scalers {
+		p.Scalers[i] = &MinMaxScaler{}
+	}
+	return p
+}
+
+// NumInputs returns the number of raw input values of a row.
+func (p *Pipeline) NumInputs() int {
+	return len(p.Scalers) - len(p.Features) + len(p.Encoders) + len(p.Vocabularies)
+}
+
+// NumNumeric returns the number of features ahead of the embedding indices of a
+// transformed row: the scaled values, the encoded features and the missing indicators.
+func (p *Pipeline) NumNumeric() int {
+	return len(p.Scalers) + encodedSize(p.Encoders) + p.Missing.numIndicators()
+}
+
+// Size returns the length of a transformed row.
+func (p *Pipeline) Size() int {
+	return p.NumNumeric() + len(p.Vocabularies)
+}
+
+// Fit learns the imputation values, the encoder categories, the vocabularies and the
+// scalers from the raw input values of the training rows.
+func (p *Pipeline) Fit(rows [][]string) error {
+	if err := p.checkRows(rows); err != nil {
+		return err
+	}
+	if p.Missing != nil {
+		if err := p.Missing.fit(rows, func(row []string) []string { return row }); err != nil {
+			return err
+		}
+	}
+	for e := range p.Encoders {
+		values := make([]string, len(rows))
+		for i, row := range rows {
+			values[i] = row[p.Encoders[e].Position]
+		}
+		if err := p.Encoders[e].fit(values); err != nil {
+			return err
+		}
+	}
+	numeric := make([][]float64, len(rows))
+	for i, row := range rows {
+		values, _, err := p.Missing.fill(row)
+		if err != nil {
+			return err
+		}
+		if numeric[i], err = p.numeric(values); err != nil {
+			return err
+		}
+		for v := range p.Vocabularies {
+			token := values[p.Vocabularies[v].Position]
+			if _, exists := p.Vocabularies[v].Tokens[token]; !exists {
+				p.Vocabularies[v].Tokens[token] = len(p.Vocabularies[v].Tokens) + 1
+			}
+		}
+	}
+	return p.fitScalers(numeric)
+}
+
+// FitValues fits the scalers of a pipeline of numeric columns to the training rows.
+func (p *Pipeline) FitValues(rows [][]float64) error {
+	if !p.numericOnly() {
+		return fmt.Errorf("pipeline has steps besides scaling; fit it to raw values with Fit")
+	}
+	return p.fitScalers(rows)
+}
+
+func (p *Pipeline) fitScalers(rows [][]float64) error {
+	column := make([]float64, len(rows))
+	for i, scaler := range p.Scalers {
+		for r, row := range rows {
+			column[r] = row[i]
+		}
+		if err := scaler.Fit(column); err != nil {
+			return fmt.Errorf("%s scaler of %s: %w", scaler.Type(), p.featureName(i), err)
+		}
+	}
+	return nil
+}
+
+// Transform turns the raw input values of one row into a model input row.
+func (p *Pipeline) Transform(values []string) ([]float64, error) {
+	if len(values) != p.NumInputs() {
+		return nil, fmt.Errorf("expected %d input values, but got %d", p.NumInputs(), len(values))
+	}
+	values, indicators, err := p.Missing.fill(values)
+	if err != nil {
+		return nil, err
+	}
+	numeric, err := p.numeric(values)
+	if err != nil {
+		return nil, err
+	}
+	row := make([]float64, 0, p.Size())
+	for i, val := range numeric {
+		row = append(row, p.Scalers[i].Transform(val))
+	}
+	for e := range p.Encoders {
+		features, err := p.Encoders[e].encode(values[p.Encoders[e].Position])
+		if err != nil {
+			return nil, err
+		}
+		row = append(row, features...)
+	}
+	row = append(row, indicators...)
+	for v := range p.Vocabularies {
+		row = append(row, float64(p.Vocabularies[v].Index(values[p.Vocabularies[v].Position])))
+	}
+	return row, nil
+}
+
+// TransformValues scales the values of a row of numeric columns, for pipelines without
+// other steps.
+func (p *Pipeline) TransformValues(values []float64) ([]float64, error) {
+	if !p.numericOnly() {
+		return nil, fmt.Errorf("pipeline has steps besides scaling; transform raw values with Transform")
+	}
+	if len(values) != len(p.Scalers) {
+		return nil, fmt.Errorf("expected %d input values, but got %d", len(p.Scalers), len(values))
+	}
+	row := make([]float64, len(values))
+	for i, val := range values {
+		row[i] = p.Scalers[i].Transform(val)
+	}
+	return row, nil
+}
+
+// TransformSequence scales the rows of numeric values of one sequence and lays them out
+// like LoadCSVSequences does, for pipelines without other steps than scaling.
+func (p *Pipeline) TransformSequence(rows [][]float64, seqLen int) ([]float64, error) {
+	if !p.numericOnly() {
+		return nil, fmt.Errorf("pipeline has steps besides scaling; sequences must be numeric")
+	}
+	return ScaleSequence(rows, seqLen, p.Scalers)
+}
+
+// fitNumericPipeline fits a min-max pipeline of numeric columns to the first splitIndex
+// rows, the training rows, and transforms all rows in place with it.
+func fitNumericPipeline(columns []string, rows [][]float64, splitIndex int) (*Pipeline, error) {
+	pipeline := NewPipeline(columns)
+	if err := pipeline.FitValues(rows[:splitIndex]); err != nil {
+		return nil, err
+	}
+	return pipeline, pipeline.TransformRows(rows)
+}
+
+// TransformRows transforms rows of numeric values in place with TransformValues.
+func (p *Pipeline) TransformRows(rows [][]float64) error {
+	for i, row := range rows {
+		scaled, err := p.TransformValues(row)
+		if err != nil {
+			return err
+		}
+		rows[i] = scaled
+	}
+	return nil
+}
+
+// numericOnly reports whether the pipeline only scales numeric columns.
+func (p *Pipeline) numericOnly() bool {
+	return len(p.Features) == 0 && len(p.Encoders) == 0 && len(p.Vocabularies) == 0 && p.Missing == nil
+}
+
+// numeric parses the numeric columns of filled-in input values and appends the
+// engineered features.
+func (p *Pipeline) numeric(values []string) ([]float64, error) {
+	skip := make(map[int]bool, len(p.Encoders)+len(p.Vocabularies))
+	for _, e := range p.Encoders {
+		skip[e.Position] = true
+	}
+	for _, v := range p.Vocabularies {
+		skip[v.Position] = true
+	}
+	numeric := make([]float64, 0, len(p.Scalers))
+	parsed := make(map[int]float64, len(values))
+	for i, value := range values {
+		if skip[i] {
+			continue
+		}
+		val, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing float in record %v: %w", values, err)
+		}
+		numeric = append(numeric, val)
+		parsed[i] = val
+	}
+	for _, feature := range p.Features {
+		numeric = append(numeric, feature.compute(parsed))
+	}
+	return numeric, nil
+}
+
+// checkRows checks that every row holds one value per input column.
+func (p *Pipeline) checkRows(rows [][]string) error {
+	for _, row := range rows {
+		if len(row) != p.NumInputs() {
+			return fmt.Errorf("expected %d input values, but got %d", p.NumInputs(), len(row))
+		}
+	}
+	return nil
+}
+
+// featureName names scaled value i for error messages.
+func (p *Pipeline) featureName(i int) string {
+	numNumeric := len(p.Scalers) - len(p.Features)
+	if i >= numNumeric {
+		return fmt.Sprintf("feature %q", p.Features[i-numNumeric].Name)
+	}
+	return fmt.Sprintf("numeric column %d", i+1)
+}
+
+// FeatureOp is the operation that computes an engineered feature.
+type FeatureOp string
+
+const (
+	FeatureSum        FeatureOp = "sum"
+	FeatureDifference FeatureOp = "difference"
+	FeatureProduct    FeatureOp = "product"
+	// FeatureRatio divides the first column by the second; a zero divisor gives 0.
+	FeatureRatio FeatureOp = "ratio"
+	// FeatureSquare squares a single column.
+	FeatureSquare FeatureOp = "square"
+)
+
+// Feature is an extra numeric input computed from the numeric input columns.
+type Feature struct {
+	Name    string    `json:"name"`
+	Op      FeatureOp `json:"op"`
+	Columns []string  `json:"columns"`
+	// Positions are the indices of Columns among the input values.
+	Positions []int `json:"positions"`
+}
+
+// arity returns the number of columns the operation of a feature takes.
+func (f *Feature) arity() int {
+	switch f.Op {
+	case FeatureSquare:
+		return 1
+	case FeatureSum, FeatureDifference, FeatureProduct, FeatureRatio:
+		return 2
+	}
+	return 0
+}
+
+// compute returns the feature from the parsed numeric input values, by position.
+func (f *Feature) compute(values map[int]float64) float64 {
+	a := values[f.Positions[0]]
+	switch f.Op {
+	case FeatureSquare:
+		return a * a
+	}
+	b := values[f.Positions[1]]
+	switch f.Op {
+	case FeatureSum:
+		return a + b
+	case FeatureDifference:
+		return a - b
+	case FeatureProduct:
+		return a * b
+	case FeatureRatio:
+		if b == 0 {
+			return 0
+		}
+		return a / b
+	}
+	return 0
+}
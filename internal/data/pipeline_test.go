@@ -0,0 +1,102 @@
+package data_test
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-neuralnetwork/internal/data"
+	"go-neuralnetwork/internal/tempfile"
+)
+
+func TestPipeline(t *testing.T) {
+	csvContent := `weight,height,site,flag,risk
+60,1.5,a,1,0.1
+80,2.0,b,1,0.4
+,1.6,a,1,0.3
+90,1.8,c,1,0.9`
+	filePath, err := tempfile.CreateTempFileWithContent("pipeline-*.csv", csvContent)
+	if err != nil {
+		t.Fatalf("Failed to create temp CSV: %v", err)
+	}
+	defer os.Remove(filePath)
+
+	dataset, err := data.LoadCSVWithOptions(filePath, data.LoadOptions{
+		SplitRatio: 1.0,
+		Imputation: map[string]data.Imputer{"weight": {Strategy: data.ImputeMean, Indicator: true}},
+		Encoders:   map[string]data.Encoder{"site": {Kind: data.EncodeFrequency}},
+		Features:   []data.Feature{{Name: "ratio", Op: data.FeatureRatio, Columns: []string{"weight", "height"}}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// weight, height, flag and ratio are scaled, then the site frequency and the indicator.
+	if dataset.InputSize != 6 || dataset.Pipeline.NumInputs() != 4 {
+		t.Fatalf("Expected 4 raw values and 6 inputs, got %d and %d", dataset.Pipeline.NumInputs(), dataset.InputSize)
+	}
+
+	path := filepath.Join(t.TempDir(), "model.json")
+	if err := (&data.ModelData{Pipeline: dataset.Pipeline}).SaveModel(path); err != nil {
+		t.Fatalf("Failed to save model: %v", err)
+	}
+	md, err := data.LoadModel(path)
+	if err != nil {
+		t.Fatalf("Failed to load model: %v", err)
+	}
+	// Every raw row is turned into one of the training rows at prediction time.
+	for _, line := range strings.Split(csvContent, "\n")[1:] {
+		values := strings.Split(line, ",")
+		input, err := md.EncodeInput(values[:4])
+		if err != nil {
+			t.Fatalf("Unexpected error for %v: %v", values, err)
+		}
+		found := false
+		for _, row := range dataset.TrainInputs {
+			found = found || reflect.DeepEqual(row, input)
+		}
+		if !found {
+			t.Errorf("Row %v became %v, which is not a training row", values, input)
+		}
+		// The flag column holds a single value, which scales to 0 rather than NaN.
+		if math.IsNaN(input[2]) || input[2] != 0 {
+			t.Errorf("Expected the constant column to scale to 0, got %f", input[2])
+		}
+	}
+
+	for _, feature := range []data.Feature{
+		{Name: "x", Op: "cube", Columns: []string{"weight"}},
+		{Name: "x", Op: data.FeatureSquare, Columns: []string{"weight", "height"}},
+		{Name: "x", Op: data.FeatureProduct, Columns: []string{"weight", "site"}},
+		{Op: data.FeatureSquare, Columns: []string{"weight"}},
+	} {
+		opts := data.LoadOptions{SplitRatio: 1.0, Features: []data.Feature{feature}, Encoders: map[string]data.Encoder{"site": {Kind: data.EncodeFrequency}}}
+		if _, err := data.LoadCSVWithOptions(filePath, opts); err == nil {
+			t.Errorf("Expected an error for feature %+v, got nil", feature)
+		}
+	}
+}
+
+func TestPipelineLegacyModel(t *testing.T) {
+	legacy := `{"inputMins":[0,2],"inputMaxs":[10,4]}`
+	path := filepath.Join(t.TempDir(), "legacy.json")
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write model: %v", err)
+	}
+	md, err := data.LoadModel(path)
+	if err != nil {
+		t.Fatalf("Failed to load model: %v", err)
+	}
+	if md.Pipeline == nil || len(md.Pipeline.Scalers) != 2 {
+		t.Fatalf("Expected the input ranges to become a pipeline, got %+v", md.Pipeline)
+	}
+	input, err := md.EncodeInput([]string{"5", "3"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(input, []float64{0.5, 0.5}) {
+		t.Errorf("Expected [0.5 0.5], got %v", input)
+	}
+}
@@ -0,0 +1,298 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"go-neuralnetwork/internal/metrics"
+)
+
+// Scaler normalizes the values of one numeric column. It is fitted on the training rows
+// only, so that no statistics of the test rows leak into training.
+type Scaler interface {
+	// Type returns the registered name of the scaler.
+	Type() string
+	// Fit learns the scaling parameters from the training values of the column. It
+	// returns an error when there are no values.
+	Fit(values []float64) error
+	// Transform normalizes a value.
+	Transform(x float64) float64
+	// Inverse maps a normalized value back to the scale of the column.
+	Inverse(y float64) float64
+}
+
+// availableScalers maps scaler names to constructors of unfitted scalers.
+var availableScalers = map[string]func() Scaler{
+	"minmax": func() Scaler { return &MinMaxScaler{} },
+	"zscore": func() Scaler { return &StandardScaler{} },
+	"robust": func() Scaler { return &RobustScaler{} },
+	"maxabs": func() Scaler { return &MaxAbsScaler{} },
+	"log1p":  func() Scaler { return &LogScaler{} },
+}
+
+// NewScaler returns an unfitted scaler by name.
+func NewScaler(name string) (Scaler, error) {
+	newScaler, ok := availableScalers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown scaler: %s", name)
+	}
+	return newScaler(), nil
+}
+
+// GetAvailableScalers returns the names of the registered scalers, sorted.
+func GetAvailableScalers() []string {
+	names := make([]string, 0, len(availableScalers))
+	for name := range availableScalers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MinMaxScaler maps [Min, Max] to [0, 1]. A column with a single value maps to 0.
+type MinMaxScaler struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// Type returns the registered name of the scaler.
+func (s *MinMaxScaler) Type() string { return "minmax" }
+
+// Fit records the smallest and largest value.
+func (s *MinMaxScaler) Fit(values []float64) error {
+	if len(values) == 0 {
+		return fmt.Errorf("no values to fit")
+	}
+	s.Min, s.Max = math.Inf(1), math.Inf(-1)
+	for _, val := range values {
+		s.Min = min(s.Min, val)
+		s.Max = max(s.Max, val)
+	}
+	return nil
+}
+
+// Transform returns (x - Min) / (Max - Min).
+func (s *MinMaxScaler) Transform(x float64) float64 {
+	if s.Max-s.Min == 0 {
+		return 0
+	}
+	return (x - s.Min) / (s.Max - s.Min)
+}
+
+// Inverse returns y * (Max - Min) + Min.
+func (s *MinMaxScaler) Inverse(y float64) float64 {
+	return y*(s.Max-s.Min) + s.Min
+}
+
+// StandardScaler centres a column on its mean and divides by its standard deviation
+// (the z-score). A column with a single value maps to 0.
+type StandardScaler struct {
+	Mean float64 `json:"mean"`
+	Std  float64 `json:"std"`
+}
+
+// Type returns the registered name of the scaler.
+func (s *StandardScaler) Type() string { return "zscore" }
+
+// Fit computes the mean and the population standard deviation.
+func (s *StandardScaler) Fit(values []float64) error {
+	if len(values) == 0 {
+		return fmt.Errorf("no values to fit")
+	}
+	s.Mean, s.Std = 0, 0
+	for _, val := range values {
+		s.Mean += val
+	}
+	s.Mean /= float64(len(values))
+	for _, val := range values {
+		s.Std += (val - s.Mean) * (val - s.Mean)
+	}
+	s.Std = math.Sqrt(s.Std / float64(len(values)))
+	return nil
+}
+
+// Transform returns (x - Mean) / Std.
+func (s *StandardScaler) Transform(x float64) float64 {
+	if s.Std == 0 {
+		return 0
+	}
+	return (x - s.Mean) / s.Std
+}
+
+// Inverse returns y * Std + Mean.
+func (s *StandardScaler) Inverse(y float64) float64 {
+	return y*s.Std + s.Mean
+}
+
+// RobustScaler centres a column on its median and divides by its interquartile range,
+// so outliers have little influence on the scaling.
+type RobustScaler struct {
+	Median float64 `json:"median"`
+	IQR    float64 `json:"iqr"`
+}
+
+// Type returns the registered name of the scaler.
+func (s *RobustScaler) Type() string { return "robust" }
+
+// Fit computes the median and the distance between the first and third quartiles.
+func (s *RobustScaler) Fit(values []float64) error {
+	if len(values) == 0 {
+		return fmt.Errorf("no values to fit")
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	s.Median = metrics.Quantile(sorted, 0.5)
+	s.IQR = metrics.Quantile(sorted, 0.75) - metrics.Quantile(sorted, 0.25)
+	return nil
+}
+
+// Transform returns (x - Median) / IQR.
+func (s *RobustScaler) Transform(x float64) float64 {
+	if s.IQR == 0 {
+		return 0
+	}
+	return (x - s.Median) / s.IQR
+}
+
+// Inverse returns y * IQR + Median.
+func (s *RobustScaler) Inverse(y float64) float64 {
+	return y*s.IQR + s.Median
+}
+
+// MaxAbsScaler divides a column by its largest absolute value, mapping it to [-1, 1]
+// without shifting it, so zeros stay zero.
+type MaxAbsScaler struct {
+	MaxAbs float64 `json:"maxAbs"`
+}
+
+// Type returns the registered name of the scaler.
+func (s *MaxAbsScaler) Type() string { return "maxabs" }
+
+// Fit records the largest absolute value.
+func (s *MaxAbsScaler) Fit(values []float64) error {
+	if len(values) == 0 {
+		return fmt.Errorf("no values to fit")
+	}
+	s.MaxAbs = 0
+	for _, val := range values {
+		s.MaxAbs = max(s.MaxAbs, math.Abs(val))
+	}
+	return nil
+}
+
+// Transform returns x / MaxAbs.
+func (s *MaxAbsScaler) Transform(x float64) float64 {
+	if s.MaxAbs == 0 {
+		return 0
+	}
+	return x / s.MaxAbs
+}
+
+// Inverse returns y * MaxAbs.
+func (s *MaxAbsScaler) Inverse(y float64) float64 {
+	return y * s.MaxAbs
+}
+
+// LogScaler takes log(1 + x) and standardizes the result, which suits skewed,
+// non-negative columns such as counts and prices. Negative values are treated as 0.
+type LogScaler struct {
+	StandardScaler
+}
+
+// Type returns the registered name of the scaler.
+func (s *LogScaler) Type() string { return "log1p" }
+
+// Fit standardizes the logarithms of the values, which must not be negative.
+func (s *LogScaler) Fit(values []float64) error {
+	logs := make([]float64, len(values))
+	for i, val := range values {
+		if val < 0 {
+			return fmt.Errorf("log1p scaling needs non-negative values, got %g", val)
+		}
+		logs[i] = math.Log1p(val)
+	}
+	return s.StandardScaler.Fit(logs)
+}
+
+// Transform returns the z-score of log(1 + x).
+func (s *LogScaler) Transform(x float64) float64 {
+	return s.StandardScaler.Transform(math.Log1p(max(x, 0)))
+}
+
+// Inverse undoes the standardization and the logarithm.
+func (s *LogScaler) Inverse(y float64) float64 {
+	return math.Expm1(s.StandardScaler.Inverse(y))
+}
+
+// MinMaxScalers returns min-max scalers with the given ranges, as used before scalers
+// could be chosen per column.
+func MinMaxScalers(mins, maxs []float64) []Scaler {
+	scalers := make([]Scaler, len(mins))
+	for i := range mins {
+		scalers[i] = &MinMaxScaler{Min: mins[i], Max: maxs[i]}
+	}
+	return scalers
+}
+
+// fitScalers fits one scaler per column of rows, with types[i] naming the scaler of column i.
+func fitScalers(rows [][]float64, types []string) ([]Scaler, error) {
+	scalers := make([]Scaler, len(types))
+	column := make([]float64, len(rows))
+	for i, name := range types {
+		scaler, err := NewScaler(name)
+		if err != nil {
+			return nil, err
+		}
+		for r, row := range rows {
+			column[r] = row[i]
+		}
+		if err := scaler.Fit(column); err != nil {
+			return nil, fmt.Errorf("%s scaler: %w", name, err)
+		}
+		scalers[i] = scaler
+	}
+	return scalers, nil
+}
+
+// Scalers is a list of fitted scalers that is serialised with the type of each.
+type Scalers []Scaler
+
+type scalerJSON struct {
+	Type   string          `json:"type"`
+	Config json.RawMessage `json:"config"`
+}
+
+// MarshalJSON encodes every scaler with its type name.
+func (s Scalers) MarshalJSON() ([]byte, error) {
+	encoded := make([]scalerJSON, len(s))
+	for i, scaler := range s {
+		config, err := json.Marshal(scaler)
+		if err != nil {
+			return nil, err
+		}
+		encoded[i] = scalerJSON{Type: scaler.Type(), Config: config}
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON decodes scalers written by MarshalJSON.
+func (s *Scalers) UnmarshalJSON(b []byte) error {
+	var encoded []scalerJSON
+	if err := json.Unmarshal(b, &encoded); err != nil {
+		return err
+	}
+	*s = make(Scalers, len(encoded))
+	for i, e := range encoded {
+		scaler, err := NewScaler(e.Type)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(e.Config, scaler); err != nil {
+			return err
+		}
+		(*s)[i] = scaler
+	}
+	return nil
+}
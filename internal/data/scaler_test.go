@@ -0,0 +1,189 @@
+package data_test
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-neuralnetwork/internal/data"
+	"go-neuralnetwork/internal/tempfile"
+)
+
+func TestScalers(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 100}
+	expected := map[string]float64{
+		"minmax": (3 - 1) / 99.0,
+		"zscore": (3 - 22) / math.Sqrt((21*21+20*20+19*19+18*18+78*78)/5.0),
+		"robust": 0, // 3 is the median
+		"maxabs": 0.03,
+	}
+	for _, name := range data.GetAvailableScalers() {
+		scaler, err := data.NewScaler(name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := scaler.Fit(values); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if want, ok := expected[name]; ok && math.Abs(scaler.Transform(3)-want) > 1e-9 {
+			t.Errorf("%s: expected 3 to scale to %f, got %f", name, want, scaler.Transform(3))
+		}
+		for _, val := range values {
+			if back := scaler.Inverse(scaler.Transform(val)); math.Abs(back-val) > 1e-9 {
+				t.Errorf("%s: %f came back as %f", name, val, back)
+			}
+		}
+	}
+
+	// No scaler can be fitted without training values.
+	for _, name := range data.GetAvailableScalers() {
+		scaler, _ := data.NewScaler(name)
+		if err := scaler.Fit(nil); err == nil {
+			t.Errorf("Expected an error fitting %s to no values, got nil", name)
+		}
+	}
+
+	log, _ := data.NewScaler("log1p")
+	if err := log.Fit([]float64{1, -2}); err == nil {
+		t.Errorf("Expected an error fitting log1p to negative values, got nil")
+	}
+	if _, err := data.NewScaler("quantile"); err == nil {
+		t.Errorf("Expected an error for an unknown scaler, got nil")
+	}
+}
+
+func TestModelDataScalers(t *testing.T) {
+	dir := t.TempDir()
+	robust, _ := data.NewScaler("robust")
+	robust.Fit([]float64{0, 10, 20, 30, 40})
+	md := &data.ModelData{
+		Pipeline:      &data.Pipeline{Scalers: data.Scalers{robust, &data.MaxAbsScaler{MaxAbs: 4}}},
+		TargetScalers: data.Scalers{&data.StandardScaler{Mean: 10, Std: 2}},
+	}
+	path := filepath.Join(dir, "scalers.json")
+	if err := md.SaveModel(path); err != nil {
+		t.Fatalf("Failed to save model: %v", err)
+	}
+	loaded, err := data.LoadModel(path)
+	if err != nil {
+		t.Fatalf("Failed to load model: %v", err)
+	}
+	inputs, err := loaded.ScaleInputs([]float64{30, -2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if inputs[0] != 0.5 || inputs[1] != -0.5 {
+		t.Errorf("Expected inputs [0.5 -0.5], got %v", inputs)
+	}
+	if targets := loaded.DenormalizeTargets([]float64{1.5}); targets[0] != 13 {
+		t.Errorf("Expected the target 13, got %v", targets)
+	}
+	if _, err := loaded.ScaleInputs([]float64{1}); err == nil {
+		t.Errorf("Expected an error for the wrong number of inputs, got nil")
+	}
+
+	// Models saved with min-max ranges load as min-max scalers.
+	legacy := `{"inputMins":[0,5],"inputMaxs":[10,5],"targetMins":[100],"targetMaxs":[200]}`
+	legacyPath := filepath.Join(dir, "legacy.json")
+	if err := os.WriteFile(legacyPath, []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write model: %v", err)
+	}
+	loaded, err = data.LoadModel(legacyPath)
+	if err != nil {
+		t.Fatalf("Failed to load model: %v", err)
+	}
+	if loaded.Pipeline == nil || len(loaded.Pipeline.Scalers) != 2 || len(loaded.TargetScalers) != 1 {
+		t.Fatalf("Expected the ranges to be converted to a pipeline of scalers, got %+v", loaded)
+	}
+	inputs, _ = loaded.ScaleInputs([]float64{2, 5})
+	if inputs[0] != 0.2 || inputs[1] != 0 {
+		t.Errorf("Expected inputs [0.2 0], got %v", inputs)
+	}
+	if targets := loaded.DenormalizeTargets([]float64{0.25}); targets[0] != 125 {
+		t.Errorf("Expected the target 125, got %v", targets)
+	}
+}
+
+func TestLoadCSVScalers(t *testing.T) {
+	csvContent := `a,b,c,y
+1,10,0,5
+2,20,1,6
+3,30,3,7
+4,40,7,8
+5,50,15,9
+6,60,31,10
+7,70,63,11
+8,80,127,12`
+	filePath, err := tempfile.CreateTempFileWithContent("scalers-*.csv", csvContent)
+	if err != nil {
+		t.Fatalf("Failed to create temp CSV: %v", err)
+	}
+	defer os.Remove(filePath)
+
+	dataset, err := data.LoadCSVWithOptions(filePath, data.LoadOptions{
+		SplitRatio: 0.5,
+		Scalers:    map[string]string{"*": "zscore", "c": "log1p", "y": "robust"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	inputs, targets := dataset.Pipeline.Scalers, dataset.TargetScalers
+	types := []string{inputs[0].Type(), inputs[1].Type(), inputs[2].Type(), targets[0].Type()}
+	if types[0] != "zscore" || types[1] != "zscore" || types[2] != "log1p" || types[3] != "robust" {
+		t.Fatalf("Unexpected scaler types %v", types)
+	}
+
+	// Fitted on the training rows only, so the scaled training column has mean 0 and
+	// standard deviation 1.
+	var mean, sq float64
+	for _, input := range dataset.TrainInputs {
+		mean += input[0]
+		sq += input[0] * input[0]
+	}
+	n := float64(len(dataset.TrainInputs))
+	if math.Abs(mean/n) > 1e-9 || math.Abs(sq/n-1) > 1e-9 {
+		t.Errorf("Expected standardized training inputs, got mean %f and variance %f", mean/n, sq/n)
+	}
+	for i, input := range dataset.TrainInputs {
+		a := inputs[0].Inverse(input[0])
+		if y := targets[0].Inverse(dataset.TrainTargets[i][0]); math.Abs(y-(a+4)) > 1e-9 {
+			t.Errorf("Row with a = %f has target %f", a, y)
+		}
+	}
+
+	// Regression heads of multi-task datasets use the scaler of their column too, and
+	// keep it when saved.
+	dataset, err = data.LoadCSVWithOptions(filePath, data.LoadOptions{
+		SplitRatio:    0.5,
+		MultiTask:     true,
+		TargetColumns: []string{"y"},
+		Scalers:       map[string]string{"y": "robust"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	encoded, err := json.Marshal(dataset.Heads[0])
+	if err != nil {
+		t.Fatalf("Failed to encode head: %v", err)
+	}
+	var head data.TaskHead
+	if err := json.Unmarshal(encoded, &head); err != nil {
+		t.Fatalf("Failed to decode head: %v", err)
+	}
+	if len(head.Scaler) != 1 || head.Scaler[0].Type() != "robust" {
+		t.Fatalf("Expected a robust scaler on the head, got %+v", head.Scaler)
+	}
+	inputs = dataset.Pipeline.Scalers
+	for i, input := range dataset.TrainInputs {
+		a := inputs[0].Inverse(input[0])
+		if y := head.Denormalize(dataset.TrainTargets[i]); math.Abs(y-(a+4)) > 1e-9 {
+			t.Errorf("Row with a = %f has head target %f", a, y)
+		}
+	}
+
+	if _, err := data.LoadCSVWithOptions(filePath, data.LoadOptions{SplitRatio: 1.0, Scalers: map[string]string{"a": "quantile"}}); err == nil {
+		t.Errorf("Expected an error for an unknown scaler, got nil")
+	}
+}
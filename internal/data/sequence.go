@@ -0,0 +1,198 @@
+package data
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+)
+
+// LoadCSVSequences loads a CSV file in which consecutive rows with the same value in the
+// idColumn column form one sequence, for example the readings of one sensor or the
+// events of one session. Every sequence becomes one sample of seqLen time steps holding
+// the remaining feature columns; the target is the last column of the sequence's final
+// row, treated as a class name when it is not numeric.
+//
+// Longer sequences keep their last seqLen rows and shorter ones are padded with zero rows
+// at the start. A seqLen of 0 uses the length of the longest sequence. Features are
+// min-max normalized per column with the ranges of the training sequences, and samples
+// are stored time step by time step, with InputShape [seqLen, features].
+func LoadCSVSequences(filePath, idColumn string, seqLen int, splitRatio float64) (*Dataset, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s has no data rows", filePath)
+	}
+
+	idIndex := -1
+	for i, name := range header {
+		if name == idColumn {
+			idIndex = i
+		}
+	}
+	targetIndex := len(header) - 1
+	if idIndex < 0 || idIndex == targetIndex {
+		return nil, fmt.Errorf("sequence ID column %q not found among the input columns", idColumn)
+	}
+	var featureColumns []int
+	for i := 0; i < targetIndex; i++ {
+		if i != idIndex {
+			featureColumns = append(featureColumns, i)
+		}
+	}
+	numFeatures := len(featureColumns)
+
+	// Group consecutive rows that share an ID.
+	var groups [][][]string
+	for i, record := range records {
+		if i == 0 || record[idIndex] != records[i-1][idIndex] {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], record)
+	}
+	if seqLen == 0 {
+		for _, group := range groups {
+			if len(group) > seqLen {
+				seqLen = len(group)
+			}
+		}
+	}
+
+	sequences := make([][][]float64, len(groups))
+	for g, group := range groups {
+		sequences[g] = make([][]float64, len(group))
+		for t, record := range group {
+			sequences[g][t] = make([]float64, numFeatures)
+			for f, col := range featureColumns {
+				val, err := strconv.ParseFloat(record[col], 64)
+				if err != nil {
+					return nil, fmt.Errorf("error parsing float in record %v: %w", record, err)
+				}
+				sequences[g][t][f] = val
+			}
+		}
+	}
+
+	// The target of a sequence is the last column of its final row.
+	var classMap map[string]int
+	lastValues := make([]string, len(groups))
+	for i, group := range groups {
+		lastValues[i] = group[len(group)-1][targetIndex]
+	}
+	if _, err := strconv.ParseFloat(lastValues[0], 64); err != nil {
+		classMap = make(map[string]int)
+		for _, className := range lastValues {
+			if _, exists := classMap[className]; !exists {
+				classMap[className] = len(classMap)
+			}
+		}
+	}
+	targets := make([][]float64, len(groups))
+	outputSize := len(classMap)
+	if classMap == nil {
+		outputSize = 1
+	}
+	for i := range targets {
+		targets[i] = make([]float64, outputSize)
+		if classMap != nil {
+			targets[i][classMap[lastValues[i]]] = 1.0 // One-hot encoding
+			continue
+		}
+		val, err := strconv.ParseFloat(lastValues[i], 64)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing target %q: %w", lastValues[i], err)
+		}
+		targets[i][0] = val
+	}
+
+	// Shuffle the sequences and normalize them with the ranges of every row of the training
+	// sequences, including rows cut off by seqLen; no statistics of the test sequences are used.
+	order := rand.New(rand.NewSource(time.Now().UnixNano())).Perm(len(sequences))
+	shuffled := make([][][]float64, len(order))
+	shuffledTargets := make([][]float64, len(order))
+	for i, j := range order {
+		shuffled[i], shuffledTargets[i] = sequences[j], targets[j]
+	}
+	splitIndex := int(float64(len(shuffled)) * splitRatio)
+	var trainRows [][]float64
+	for _, sequence := range shuffled[:splitIndex] {
+		trainRows = append(trainRows, sequence...)
+	}
+	featureNames := make([]string, numFeatures)
+	for f, col := range featureColumns {
+		featureNames[f] = header[col]
+	}
+	pipeline := NewPipeline(featureNames)
+	if err := pipeline.FitValues(trainRows); err != nil {
+		return nil, err
+	}
+
+	var targetMins, targetMaxs []float64
+	var targetNames []string
+	if classMap == nil {
+		targetNames = []string{header[targetIndex]}
+		targetMins, targetMaxs = columnRanges(shuffledTargets[:splitIndex], 1)
+		normalizeRows(shuffledTargets, targetMins, targetMaxs)
+	}
+	inputs := make([][]float64, len(shuffled))
+	for i, sequence := range shuffled {
+		inputs[i], _ = pipeline.TransformSequence(sequence, seqLen)
+	}
+	trainInputs, trainTargets, testInputs, testTargets := SplitData(inputs, shuffledTargets, splitRatio)
+
+	return &Dataset{
+		TrainInputs:    trainInputs,
+		TrainTargets:   trainTargets,
+		TestInputs:     testInputs,
+		TestTargets:    testTargets,
+		InputSize:      seqLen * numFeatures,
+		OutputSize:     outputSize,
+		Pipeline:       pipeline,
+		TargetMins:     targetMins,
+		TargetMaxs:     targetMaxs,
+		ClassMap:       classMap,
+		InputShape:     []int{seqLen, numFeatures},
+		SequenceLength: seqLen,
+		TargetNames:    targetNames,
+	}, nil
+}
+
+// SequenceInput normalizes the rows of one sequence with the per-feature ranges and lays
+// them out like LoadCSVSequences does: the last seqLen rows, padded with zero rows at the start.
+func SequenceInput(rows [][]float64, seqLen int, inputMins, inputMaxs []float64) ([]float64, error) {
+	return ScaleSequence(rows, seqLen, MinMaxScalers(inputMins, inputMaxs))
+}
+
+// ScaleSequence is SequenceInput with a scaler per feature.
+func ScaleSequence(rows [][]float64, seqLen int, scalers []Scaler) ([]float64, error) {
+	numFeatures := len(scalers)
+	if len(rows) > seqLen {
+		rows = rows[len(rows)-seqLen:]
+	}
+	input := make([]float64, seqLen*numFeatures)
+	offset := seqLen - len(rows)
+	for t, row := range rows {
+		if len(row) != numFeatures {
+			return nil, fmt.Errorf("expected %d values in step %d, but got %d", numFeatures, t+1, len(row))
+		}
+		for f, val := range row {
+			input[(offset+t)*numFeatures+f] = scalers[f].Transform(val)
+		}
+	}
+	return input, nil
+}
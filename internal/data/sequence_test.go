@@ -0,0 +1,107 @@
+package data_test
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"testing"
+
+	"go-neuralnetwork/internal/data"
+	"go-neuralnetwork/internal/tempfile"
+)
+
+func TestLoadCSVSequences(t *testing.T) {
+	csvContent := `x,id,y,label
+0,a,10,up
+1,a,20,up
+2,a,30,up
+5,b,10,down
+3,b,40,down
+4,c,20,up`
+	filePath, err := tempfile.CreateTempFileWithContent("sequences-*.csv", csvContent)
+	if err != nil {
+		t.Fatalf("Failed to create temp CSV: %v", err)
+	}
+	defer os.Remove(filePath)
+
+	dataset, err := data.LoadCSVSequences(filePath, "id", 2, 1.0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(dataset.TrainInputs) != 3 {
+		t.Fatalf("Expected 3 sequences, got %d", len(dataset.TrainInputs))
+	}
+	if !reflect.DeepEqual(dataset.InputShape, []int{2, 2}) || dataset.SequenceLength != 2 {
+		t.Errorf("Expected input shape [2 2] and sequence length 2, got %v and %d", dataset.InputShape, dataset.SequenceLength)
+	}
+	if dataset.OutputSize != 2 || len(dataset.ClassMap) != 2 {
+		t.Errorf("Expected two classes, got %v", dataset.ClassMap)
+	}
+
+	// Sequence "a" keeps its last two rows, "c" is padded with a zero row.
+	expected := map[float64][]float64{
+		0.2: {0.2, 1.0 / 3, 0.4, 2.0 / 3},
+		1.0: {1.0, 0, 0.6, 1},
+		0:   {0, 0, 0.8, 1.0 / 3},
+	}
+	for _, row := range dataset.TrainInputs {
+		want, ok := expected[row[0]]
+		if !ok {
+			t.Errorf("Unexpected sequence %v", row)
+			continue
+		}
+		for i := range want {
+			if diff := row[i] - want[i]; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("Expected sequence %v, got %v", want, row)
+				break
+			}
+		}
+	}
+
+	input, err := dataset.Pipeline.TransformSequence([][]float64{{4, 20}}, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(input, expected[0]) {
+		t.Errorf("Expected %v, got %v", expected[0], input)
+	}
+
+	if _, err := data.LoadCSVSequences(filePath, "missing", 0, 1.0); err == nil {
+		t.Errorf("Expected an error for an unknown ID column, got nil")
+	}
+}
+
+func TestLoadCSVSequencesFitsTrainingRows(t *testing.T) {
+	// Every sequence has its own range of values, so a range fitted on all sequences
+	// would leave the largest training value below 1 whenever the largest sequence is
+	// in the test set.
+	csvContent := "x,id,y\n"
+	for i := 0; i < 8; i++ {
+		for step := 0; step < 2; step++ {
+			csvContent += fmt.Sprintf("%d,s%d,%d\n", 10*i+step, i, i)
+		}
+	}
+	filePath, err := tempfile.CreateTempFileWithContent("sequences-*.csv", csvContent)
+	if err != nil {
+		t.Fatalf("Failed to create temp CSV: %v", err)
+	}
+	defer os.Remove(filePath)
+
+	for run := 0; run < 5; run++ {
+		dataset, err := data.LoadCSVSequences(filePath, "id", 2, 0.5)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		maxInput, maxTarget := 0.0, 0.0
+		for i, row := range dataset.TrainInputs {
+			for _, val := range row {
+				maxInput = math.Max(maxInput, val)
+			}
+			maxTarget = math.Max(maxTarget, dataset.TrainTargets[i][0])
+		}
+		if maxInput != 1 || maxTarget != 1 {
+			t.Fatalf("Expected the ranges to be fitted on the training sequences, got largest input %f and target %f", maxInput, maxTarget)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package data
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// SplitStrategy selects how rows are divided into the training, validation and test sets.
+type SplitStrategy string
+
+const (
+	// SplitRandom shuffles the rows and cuts them by ratio.
+	SplitRandom SplitStrategy = ""
+	// SplitStratified cuts every class by ratio on its own, rounding to the nearest row,
+	// so each set holds the classes in about the same proportions. Every class keeps at
+	// least one training row.
+	SplitStratified SplitStrategy = "stratified"
+	// SplitGroup keeps all rows with the same group ID in the same set.
+	SplitGroup SplitStrategy = "group"
+	// SplitTime keeps the rows in file order: the earliest rows train, the next ones
+	// validate and the latest ones test.
+	SplitTime SplitStrategy = "time"
+)
+
+// Split holds the row indices of the training, validation and test sets.
+type Split struct {
+	Train, Validation, Test []int
+}
+
+// SplitRows divides n rows by strategy. trainRatio and validationRatio are the fractions
+// of rows for training and validation; the test set gets the rest. Stratified splits
+// read the class of every row from labels and group splits its group ID from groups.
+// Training rows are shuffled except for time splits.
+func SplitRows(n int, strategy SplitStrategy, trainRatio, validationRatio float64, labels, groups []string) (Split, error) {
+	if trainRatio < 0 || validationRatio < 0 || trainRatio+validationRatio > 1+1e-9 {
+		return Split{}, fmt.Errorf("split ratios %g and %g must be non-negative and add up to at most 1", trainRatio, validationRatio)
+	}
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	var split Split
+	switch strategy {
+	case SplitRandom, SplitTime:
+		order := r.Perm(n)
+		if strategy == SplitTime {
+			for i := range order {
+				order[i] = i
+			}
+		}
+		split.cut(order, trainRatio, validationRatio)
+	case SplitStratified:
+		if len(labels) != n {
+			return Split{}, fmt.Errorf("stratified splits need the class of every row")
+		}
+		for _, rows := range groupRows(labels) {
+			r.Shuffle(len(rows), func(i, j int) { rows[i], rows[j] = rows[j], rows[i] })
+			split.cutClass(rows, trainRatio, validationRatio)
+		}
+	case SplitGroup:
+		if len(groups) != n {
+			return Split{}, fmt.Errorf("group splits need the group ID of every row")
+		}
+		byGroup := groupRows(groups)
+		r.Shuffle(len(byGroup), func(i, j int) { byGroup[i], byGroup[j] = byGroup[j], byGroup[i] })
+		// Whole groups go to the training set until it holds its share of the rows, then
+		// to the validation set, then to the test set.
+		trainRows := ratioCount(trainRatio, n)
+		validationRows := ratioCount(validationRatio, n)
+		for _, rows := range byGroup {
+			switch {
+			case len(split.Train) < trainRows:
+				split.Train = append(split.Train, rows...)
+			case len(split.Validation) < validationRows:
+				split.Validation = append(split.Validation, rows...)
+			default:
+				split.Test = append(split.Test, rows...)
+			}
+		}
+	default:
+		return Split{}, fmt.Errorf("unknown split strategy %q", strategy)
+	}
+	if strategy != SplitTime {
+		r.Shuffle(len(split.Train), func(i, j int) { split.Train[i], split.Train[j] = split.Train[j], split.Train[i] })
+	}
+	return split, nil
+}
+
+// Fold selects one fold of k-fold cross-validation: the rows of fold Index, out of K,
+// are the test set and the other rows the training set. Folds with the same Seed
+// divide the rows the same way, so the K folds of one Seed cover every row once.
+type Fold struct {
+	K     int
+	Index int
+	Seed  int64
+}
+
+// FoldRows divides n rows for one fold of k-fold cross-validation. SplitRandom assigns
+// shuffled rows to the folds in turn; SplitStratified does so class by class, so every
+// fold holds the classes in about the same proportions; SplitGroup assigns whole groups,
+// each to the fold with the fewest rows so far. The validation set is empty.
+func FoldRows(n int, strategy SplitStrategy, fold Fold, labels, groups []string) (Split, error) {
+	if fold.K < 2 || fold.Index < 0 || fold.Index >= fold.K {
+		return Split{}, fmt.Errorf("invalid fold %d of %d", fold.Index+1, fold.K)
+	}
+	r := rand.New(rand.NewSource(fold.Seed))
+	folds := make([]int, n)
+	switch strategy {
+	case SplitRandom:
+		for i, row := range r.Perm(n) {
+			folds[row] = i % fold.K
+		}
+	case SplitStratified:
+		if len(labels) != n {
+			return Split{}, fmt.Errorf("stratified folds need the class of every row")
+		}
+		// Continue the turn across classes so small classes do not all start at fold 0.
+		next := 0
+		for _, rows := range groupRows(labels) {
+			r.Shuffle(len(rows), func(i, j int) { rows[i], rows[j] = rows[j], rows[i] })
+			for _, row := range rows {
+				folds[row] = next % fold.K
+				next++
+			}
+		}
+	case SplitGroup:
+		if len(groups) != n {
+			return Split{}, fmt.Errorf("group folds need the group ID of every row")
+		}
+		byGroup := groupRows(groups)
+		r.Shuffle(len(byGroup), func(i, j int) { byGroup[i], byGroup[j] = byGroup[j], byGroup[i] })
+		sizes := make([]int, fold.K)
+		for _, rows := range byGroup {
+			smallest := 0
+			for f := range sizes {
+				if sizes[f] < sizes[smallest] {
+					smallest = f
+				}
+			}
+			for _, row := range rows {
+				folds[row] = smallest
+			}
+			sizes[smallest] += len(rows)
+		}
+	default:
+		return Split{}, fmt.Errorf("%q splits cannot be divided into folds", strategy)
+	}
+	var split Split
+	for row, f := range folds {
+		if f == fold.Index {
+			split.Test = append(split.Test, row)
+		} else {
+			split.Train = append(split.Train, row)
+		}
+	}
+	r.Shuffle(len(split.Train), func(i, j int) { split.Train[i], split.Train[j] = split.Train[j], split.Train[i] })
+	return split, nil
+}
+
+// cut appends the first trainRatio of rows to the training set, the next
+// validationRatio to the validation set and the rest to the test set.
+func (s *Split) cut(rows []int, trainRatio, validationRatio float64) {
+	trainEnd := ratioCount(trainRatio, len(rows))
+	validationEnd := min(ratioCount(trainRatio+validationRatio, len(rows)), len(rows))
+	s.assign(rows, trainEnd, validationEnd)
+}
+
+// cutClass cuts the rows of one class like cut, but rounds the counts to the nearest
+// row and keeps at least one training row, so a rare class is not left out of training.
+func (s *Split) cutClass(rows []int, trainRatio, validationRatio float64) {
+	n := float64(len(rows))
+	trainEnd := min(max(int(math.Round(trainRatio*n)), 1), len(rows))
+	if trainRatio == 0 {
+		trainEnd = 0
+	}
+	validationEnd := min(max(int(math.Round((trainRatio+validationRatio)*n)), trainEnd), len(rows))
+	s.assign(rows, trainEnd, validationEnd)
+}
+
+// assign appends rows[:trainEnd] to the training set, rows[trainEnd:validationEnd] to
+// the validation set and the rest to the test set.
+func (s *Split) assign(rows []int, trainEnd, validationEnd int) {
+	s.Train = append(s.Train, rows[:trainEnd]...)
+	s.Validation = append(s.Validation, rows[trainEnd:validationEnd]...)
+	s.Test = append(s.Test, rows[validationEnd:]...)
+}
+
+// ratioCount returns the number of rows out of n that make up the given fraction,
+// rounded down like SplitData does.
+func ratioCount(ratio float64, n int) int {
+	return int(ratio*float64(n) + 1e-9)
+}
+
+// Order returns the row indices of the training, validation and test sets one after the other.
+func (s Split) Order() []int {
+	return append(append(append([]int(nil), s.Train...), s.Validation...), s.Test...)
+}
+
+// groupRows returns the indices of the rows with each key, in order of first appearance.
+func groupRows(keys []string) [][]int {
+	index := make(map[string]int)
+	var groups [][]int
+	for i, key := range keys {
+		g, ok := index[key]
+		if !ok {
+			g = len(groups)
+			index[key] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
@@ -0,0 +1,192 @@
+package data_test
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"go-neuralnetwork/internal/data"
+	"go-neuralnetwork/internal/tempfile"
+)
+
+func TestSplitRows(t *testing.T) {
+	labels := []string{"a", "a", "a", "a", "a", "b", "b", "b", "b", "b", "c", "c", "c", "c", "c"}
+	split, err := data.SplitRows(len(labels), data.SplitStratified, 0.6, 0.2, labels, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for name, rows := range map[string][]int{"train": split.Train, "validation": split.Validation, "test": split.Test} {
+		counts := map[string]int{}
+		for _, i := range rows {
+			counts[labels[i]]++
+		}
+		expected := map[string]int{"a": 1, "b": 1, "c": 1}
+		if name == "train" {
+			expected = map[string]int{"a": 3, "b": 3, "c": 3}
+		}
+		if !reflect.DeepEqual(counts, expected) {
+			t.Errorf("Expected %s classes %v, got %v", name, expected, counts)
+		}
+	}
+
+	// A class with a single row used to be rounded down to no training rows at all.
+	labels = []string{"a", "a", "a", "a", "a", "a", "a", "b", "b", "c"}
+	split, err = data.SplitRows(len(labels), data.SplitStratified, 0.7, 0.1, labels, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	trained := map[string]int{}
+	for _, i := range split.Train {
+		trained[labels[i]]++
+	}
+	if !reflect.DeepEqual(trained, map[string]int{"a": 5, "b": 1, "c": 1}) {
+		t.Errorf("Expected every class in the training set, got %v", trained)
+	}
+
+	groups := []string{"x", "x", "y", "y", "y", "z", "w", "w", "v", "v"}
+	split, err = data.SplitRows(len(groups), data.SplitGroup, 0.5, 0.2, nil, groups)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	set := map[string]string{}
+	for name, rows := range map[string][]int{"train": split.Train, "validation": split.Validation, "test": split.Test} {
+		for _, i := range rows {
+			if other, ok := set[groups[i]]; ok && other != name {
+				t.Errorf("Group %s is in both the %s and the %s set", groups[i], other, name)
+			}
+			set[groups[i]] = name
+		}
+	}
+	if len(split.Train) < 5 || len(split.Order()) != len(groups) {
+		t.Errorf("Expected at least 5 of %d rows for training, got split %+v", len(groups), split)
+	}
+
+	split, err = data.SplitRows(10, data.SplitTime, 0.7, 0.1, nil, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(split, data.Split{Train: []int{0, 1, 2, 3, 4, 5, 6}, Validation: []int{7}, Test: []int{8, 9}}) {
+		t.Errorf("Expected rows in file order, got %+v", split)
+	}
+
+	for _, strategy := range []data.SplitStrategy{data.SplitStratified, data.SplitGroup, "fold"} {
+		if _, err := data.SplitRows(4, strategy, 0.5, 0, nil, nil); err == nil {
+			t.Errorf("Expected an error for strategy %q without its data, got nil", strategy)
+		}
+	}
+	if _, err := data.SplitRows(4, data.SplitRandom, 0.8, 0.3, nil, nil); err == nil {
+		t.Errorf("Expected an error for ratios adding up to more than 1, got nil")
+	}
+}
+
+func TestLoadCSVSplits(t *testing.T) {
+	csvContent := `patient,x,label
+p1,1,a
+p1,2,a
+p2,3,b
+p2,4,b
+p3,5,a
+p3,6,a
+p4,7,b
+p4,8,b
+p5,9,a
+p5,10,b`
+	filePath, err := tempfile.CreateTempFileWithContent("splits-*.csv", csvContent)
+	if err != nil {
+		t.Fatalf("Failed to create temp CSV: %v", err)
+	}
+	defer os.Remove(filePath)
+
+	dataset, err := data.LoadCSVWithOptions(filePath, data.LoadOptions{
+		SplitRatio:      0.6,
+		ValidationRatio: 0.2,
+		Split:           data.SplitGroup,
+		GroupColumn:     "patient",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if dataset.InputSize != 1 {
+		t.Errorf("Expected the group column to be left out of the inputs, got %d inputs", dataset.InputSize)
+	}
+	if len(dataset.TrainInputs) != 6 || len(dataset.ValidationInputs) != 2 || len(dataset.TestInputs) != 2 {
+		t.Errorf("Expected 6, 2 and 2 rows, got %d, %d and %d", len(dataset.TrainInputs), len(dataset.ValidationInputs), len(dataset.TestInputs))
+	}
+
+	dataset, err = data.LoadCSVWithOptions(filePath, data.LoadOptions{SplitRatio: 0.6, Split: data.SplitStratified, ExcludeColumns: []string{"patient"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Three of the five rows of each class train and two test.
+	for _, rows := range [][][]float64{dataset.TrainTargets, dataset.TestTargets} {
+		counts := make([]int, 2)
+		for _, target := range rows {
+			counts[0] += int(target[0])
+			counts[1] += int(target[1])
+		}
+		if counts[0] != counts[1] || counts[0]+counts[1] != len(rows) {
+			t.Errorf("Expected as many rows of both classes, got %v", rows)
+		}
+	}
+
+	for _, opts := range []data.LoadOptions{
+		{SplitRatio: 0.5, Split: data.SplitGroup},
+		{SplitRatio: 0.5, Split: data.SplitStratified, TaskType: data.TaskRegression},
+	} {
+		if _, err := data.LoadCSVWithOptions(filePath, opts); err == nil {
+			t.Errorf("Expected an error for options %+v, got nil", opts)
+		}
+	}
+}
+
+func TestFoldRows(t *testing.T) {
+	labels := []string{"a", "a", "a", "a", "a", "a", "b", "b", "b", "c", "c", "c"}
+	groups := []string{"x", "x", "y", "y", "y", "z", "w", "w", "v", "v", "u", "t"}
+	for _, strategy := range []data.SplitStrategy{data.SplitRandom, data.SplitStratified, data.SplitGroup} {
+		tested := make([]int, len(labels))
+		for i := 0; i < 3; i++ {
+			split, err := data.FoldRows(len(labels), strategy, data.Fold{K: 3, Index: i, Seed: 7}, labels, groups)
+			if err != nil {
+				t.Fatalf("Unexpected error for strategy %q: %v", strategy, err)
+			}
+			if len(split.Train)+len(split.Test) != len(labels) || len(split.Validation) != 0 {
+				t.Errorf("Expected every row in the training or the test set, got %+v", split)
+			}
+			testGroups := map[string]bool{}
+			for _, row := range split.Test {
+				tested[row]++
+				testGroups[groups[row]] = true
+			}
+			if strategy == data.SplitStratified {
+				counts := map[string]int{}
+				for _, row := range split.Test {
+					counts[labels[row]]++
+				}
+				if counts["a"] != 2 || counts["b"] != 1 || counts["c"] != 1 {
+					t.Errorf("Expected 2, 1 and 1 test rows of each class in fold %d, got %v", i, counts)
+				}
+			}
+			if strategy == data.SplitGroup {
+				for _, row := range split.Train {
+					if testGroups[groups[row]] {
+						t.Errorf("Group %s is in both the training and the test set of fold %d", groups[row], i)
+					}
+				}
+			}
+		}
+		for row, n := range tested {
+			if n != 1 {
+				t.Errorf("Expected row %d to be tested once with strategy %q, got %d times", row, strategy, n)
+			}
+		}
+	}
+
+	for _, fold := range []data.Fold{{K: 1}, {K: 3, Index: 3}} {
+		if _, err := data.FoldRows(4, data.SplitRandom, fold, nil, nil); err == nil {
+			t.Errorf("Expected an error for fold %+v, got nil", fold)
+		}
+	}
+	if _, err := data.FoldRows(4, data.SplitTime, data.Fold{K: 2}, nil, nil); err == nil {
+		t.Errorf("Expected an error for time folds, got nil")
+	}
+}
@@ -0,0 +1,136 @@
+package data
+
+import (
+	"fmt"
+	"strconv"
+
+	"go-neuralnetwork/internal/neuralnetwork"
+)
+
+// TaskHead describes one output head of a multi-task dataset: the part of the target row
+// it predicts and how to decode it. Classification heads are one-hot encoded with their
+// own ClassMap; regression heads predict one value normalized by Scaler, whose training
+// range is TargetMin and TargetMax.
+type TaskHead struct {
+	Name      string         `json:"name"`
+	Offset    int            `json:"offset"`
+	Size      int            `json:"size"`
+	ClassMap  map[string]int `json:"classMap,omitempty"`
+	TargetMin float64        `json:"targetMin,omitempty"`
+	TargetMax float64        `json:"targetMax,omitempty"`
+	// Scaler holds the one scaler of a regression head. Heads saved before scalers have
+	// none and are min-max normalized with TargetMin and TargetMax.
+	Scaler Scalers `json:"scaler,omitempty"`
+}
+
+// Output returns the part of a target or prediction row that belongs to the head.
+func (h *TaskHead) Output(row []float64) []float64 {
+	return row[h.Offset : h.Offset+h.Size]
+}
+
+// Class returns the name of the most likely class of a classification head and its score.
+func (h *TaskHead) Class(row []float64) (string, float64) {
+	output := h.Output(row)
+	best := 0
+	for i, val := range output {
+		if val > output[best] {
+			best = i
+		}
+	}
+	for name, index := range h.ClassMap {
+		if index == best {
+			return name, output[best]
+		}
+	}
+	return fmt.Sprintf("Class %d", best), output[best]
+}
+
+// Denormalize returns the prediction of a regression head on the scale of its column.
+func (h *TaskHead) Denormalize(row []float64) float64 {
+	return h.scaler().Inverse(h.Output(row)[0])
+}
+
+// scaler returns the scaler of a regression head.
+func (h *TaskHead) scaler() Scaler {
+	if len(h.Scaler) == 0 {
+		return &MinMaxScaler{Min: h.TargetMin, Max: h.TargetMax}
+	}
+	return h.Scaler[0]
+}
+
+// Spec returns the default output layer of the head: sigmoid units trained with binary
+// cross-entropy for classification and a linear unit trained with MSE for regression.
+func (h *TaskHead) Spec() neuralnetwork.HeadSpec {
+	if h.ClassMap != nil {
+		return neuralnetwork.HeadSpec{Name: h.Name, Outputs: h.Size, Activation: "sigmoid", Loss: "binary_crossentropy", Weight: 1}
+	}
+	return neuralnetwork.HeadSpec{Name: h.Name, Outputs: h.Size, Activation: "linear", Loss: "mse", Weight: 1}
+}
+
+// HeadSpecs returns the default output heads of a multi-task dataset, in target order.
+func (d *Dataset) HeadSpecs() []neuralnetwork.HeadSpec {
+	specs := make([]neuralnetwork.HeadSpec, len(d.Heads))
+	for i := range d.Heads {
+		specs[i] = d.Heads[i].Spec()
+	}
+	return specs
+}
+
+// newTaskHeads makes one head per target column. A column that is not numeric in the
+// first row, or any column when taskType is TaskClassification, becomes a classification
+// head over every value it takes; the others are regression heads whose range and
+// scaler, chosen in scalers like in LoadOptions.Scalers, are fitted on the training rows.
+func newTaskHeads(header []string, records [][]string, trainRecords [][]string, targetColumns []int, taskType TaskType, scalers map[string]string) ([]TaskHead, error) {
+	heads := make([]TaskHead, len(targetColumns))
+	offset := 0
+	for i, col := range targetColumns {
+		head := TaskHead{Name: header[col], Offset: offset, Size: 1}
+		if _, err := strconv.ParseFloat(records[0][col], 64); taskType == TaskClassification || (err != nil && taskType == TaskAuto) {
+			head.ClassMap = make(map[string]int)
+			for _, record := range records {
+				if _, exists := head.ClassMap[record[col]]; !exists {
+					head.ClassMap[record[col]] = len(head.ClassMap)
+				}
+			}
+			head.Size = len(head.ClassMap)
+		} else {
+			values := make([][]float64, len(trainRecords))
+			for r, record := range trainRecords {
+				val, err := strconv.ParseFloat(record[col], 64)
+				if err != nil {
+					return nil, fmt.Errorf("error parsing target %q in record %v: %w", header[col], record, err)
+				}
+				values[r] = []float64{val}
+			}
+			mins, maxs := columnRanges(values, 1)
+			head.TargetMin, head.TargetMax = mins[0], maxs[0]
+			scaler, err := fitScalers(values, []string{scalerType(scalers, header[col])})
+			if err != nil {
+				return nil, fmt.Errorf("target %q: %w", header[col], err)
+			}
+			head.Scaler = scaler
+		}
+		heads[i] = head
+		offset += head.Size
+	}
+	return heads, nil
+}
+
+// encodeTaskTargets builds the target row of a record for the given heads.
+func encodeTaskTargets(heads []TaskHead, record []string, targetColumns []int) ([]float64, error) {
+	last := heads[len(heads)-1]
+	row := make([]float64, last.Offset+last.Size)
+	for i, head := range heads {
+		value := record[targetColumns[i]]
+		if head.ClassMap != nil {
+			row[head.Offset+head.ClassMap[value]] = 1.0 // One-hot encoding
+			continue
+		}
+		val, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing target %q in record %v: %w", head.Name, record, err)
+		}
+		row[head.Offset] = head.scaler().Transform(val)
+	}
+	return row, nil
+}
@@ -0,0 +1,65 @@
+package data_test
+
+import (
+	"math"
+	"os"
+	"testing"
+
+	"go-neuralnetwork/internal/data"
+	"go-neuralnetwork/internal/tempfile"
+)
+
+func TestLoadCSVMultiTask(t *testing.T) {
+	csvContent := `size,species,weight
+1,cat,4
+2,dog,20
+3,cat,6
+4,bird,1`
+	filePath, err := tempfile.CreateTempFileWithContent("tasks-*.csv", csvContent)
+	if err != nil {
+		t.Fatalf("Failed to create temp CSV: %v", err)
+	}
+	defer os.Remove(filePath)
+
+	dataset, err := data.LoadCSVWithOptions(filePath, data.LoadOptions{SplitRatio: 1.0, TargetColumns: []string{"species", "weight"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(dataset.Heads) != 2 || dataset.OutputSize != 4 {
+		t.Fatalf("Expected 2 heads and 4 outputs, got %d and %d", len(dataset.Heads), dataset.OutputSize)
+	}
+	species, weight := dataset.Heads[0], dataset.Heads[1]
+	if species.Size != 3 || species.ClassMap == nil || weight.Offset != 3 || weight.ClassMap != nil {
+		t.Errorf("Unexpected heads %+v", dataset.Heads)
+	}
+	if weight.TargetMin != 1 || weight.TargetMax != 20 {
+		t.Errorf("Expected the weight head to range over [1, 20], got [%f, %f]", weight.TargetMin, weight.TargetMax)
+	}
+	specs := dataset.HeadSpecs()
+	if specs[0].Loss != "binary_crossentropy" || specs[1].Loss != "mse" {
+		t.Errorf("Unexpected default head losses %+v", specs)
+	}
+
+	expected := map[float64]struct {
+		species string
+		weight  float64
+	}{0: {"cat", 4}, 1.0 / 3: {"dog", 20}, 2.0 / 3: {"cat", 6}, 1: {"bird", 1}}
+	for i, input := range dataset.TrainInputs {
+		want := expected[math.Round(input[0]*3)/3]
+		if class, _ := species.Class(dataset.TrainTargets[i]); class != want.species {
+			t.Errorf("Expected species %s, got %s", want.species, class)
+		}
+		if value := weight.Denormalize(dataset.TrainTargets[i]); math.Abs(value-want.weight) > 1e-9 {
+			t.Errorf("Expected weight %f, got %f", want.weight, value)
+		}
+	}
+
+	// A single target only gets a head when multi-task training is requested.
+	dataset, err = data.LoadCSVWithOptions(filePath, data.LoadOptions{SplitRatio: 1.0, TargetColumns: []string{"weight"}, CategoricalColumns: map[string]int{"species": 2}, MultiTask: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(dataset.Heads) != 1 || dataset.TargetMins != nil {
+		t.Errorf("Expected one regression head, got %+v", dataset.Heads)
+	}
+}
@@ -0,0 +1,183 @@
+package data
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// TimeSeriesOptions configures how LoadTimeSeries cuts a time-ordered CSV into samples.
+type TimeSeriesOptions struct {
+	// Lookback is the number of consecutive rows each sample reads.
+	Lookback int `json:"lookback"`
+	// Horizon is the number of future values of the target column each sample predicts.
+	Horizon int `json:"horizon"`
+	// Stride is the number of rows between the starts of consecutive windows.
+	Stride int `json:"stride"`
+	// TargetColumn names the column to forecast; empty means the last column.
+	TargetColumn string `json:"targetColumn,omitempty"`
+	// TargetIndex is the position of the target among the features, set by LoadTimeSeries.
+	TargetIndex int `json:"targetIndex"`
+}
+
+// LoadTimeSeries builds a forecasting dataset from a CSV whose rows are in time order.
+// Every numeric column is a feature; columns that are not numeric in the first row, such
+// as timestamps, are skipped. Each sample holds opts.Lookback rows of features, stored
+// time step by time step, and its targets are the next opts.Horizon values of the target
+// column. Features and targets are min-max normalized with the ranges of the training rows.
+//
+// The data is split by time: the first splitRatio of the rows provide the training
+// windows and the test windows forecast the remaining rows, so no test target is seen
+// during training. Training windows are shuffled, test windows stay in order.
+func LoadTimeSeries(filePath string, opts TimeSeriesOptions, splitRatio float64) (*Dataset, error) {
+	if opts.Lookback < 1 || opts.Horizon < 1 {
+		return nil, fmt.Errorf("lookback and horizon must be positive")
+	}
+	if opts.Stride < 1 {
+		opts.Stride = 1
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < opts.Lookback+opts.Horizon {
+		return nil, fmt.Errorf("%d rows are too few for a lookback of %d and a horizon of %d", len(records), opts.Lookback, opts.Horizon)
+	}
+
+	if opts.TargetColumn == "" {
+		opts.TargetColumn = header[len(header)-1]
+	}
+	var featureColumns []int
+	opts.TargetIndex = -1
+	for i, name := range header {
+		if _, err := strconv.ParseFloat(records[0][i], 64); err != nil {
+			continue
+		}
+		if name == opts.TargetColumn {
+			opts.TargetIndex = len(featureColumns)
+		}
+		featureColumns = append(featureColumns, i)
+	}
+	if opts.TargetIndex < 0 {
+		return nil, fmt.Errorf("target column %q not found among the numeric columns", opts.TargetColumn)
+	}
+	numFeatures := len(featureColumns)
+
+	rows := make([][]float64, len(records))
+	for r, record := range records {
+		rows[r] = make([]float64, numFeatures)
+		for f, col := range featureColumns {
+			val, err := strconv.ParseFloat(record[col], 64)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing float in record %v: %w", record, err)
+			}
+			rows[r][f] = val
+		}
+	}
+
+	splitRow := int(float64(len(rows)) * splitRatio)
+	if splitRow < opts.Lookback+opts.Horizon {
+		return nil, fmt.Errorf("a split ratio of %g leaves %d training rows, too few for a lookback of %d and a horizon of %d", splitRatio, splitRow, opts.Lookback, opts.Horizon)
+	}
+	trainRows := rows[:splitRow]
+	featureNames := make([]string, numFeatures)
+	for f, col := range featureColumns {
+		featureNames[f] = header[col]
+	}
+	pipeline := NewPipeline(featureNames)
+	if err := pipeline.FitValues(trainRows); err != nil {
+		return nil, err
+	}
+	// The targets are scaled like the target column is as an input.
+	targetScaler := pipeline.Scalers[opts.TargetIndex].(*MinMaxScaler)
+	targetMin, targetMax := targetScaler.Min, targetScaler.Max
+	targetMins := make([]float64, opts.Horizon)
+	targetMaxs := make([]float64, opts.Horizon)
+	targetNames := make([]string, opts.Horizon)
+	for h := range targetMins {
+		targetMins[h], targetMaxs[h] = targetMin, targetMax
+		targetNames[h] = fmt.Sprintf("%s t+%d", opts.TargetColumn, h+1)
+	}
+
+	var trainInputs, trainTargets, testInputs, testTargets [][]float64
+	for start := 0; start+opts.Lookback+opts.Horizon <= len(rows); start += opts.Stride {
+		input, _ := pipeline.TransformSequence(rows[start:start+opts.Lookback], opts.Lookback)
+		target := make([]float64, opts.Horizon)
+		for h := range target {
+			if targetMax-targetMin != 0 {
+				target[h] = (rows[start+opts.Lookback+h][opts.TargetIndex] - targetMin) / (targetMax - targetMin)
+			}
+		}
+		switch {
+		case start+opts.Lookback+opts.Horizon <= splitRow:
+			trainInputs = append(trainInputs, input)
+			trainTargets = append(trainTargets, target)
+		case start+opts.Lookback >= splitRow:
+			testInputs = append(testInputs, input)
+			testTargets = append(testTargets, target)
+		}
+	}
+	Shuffle(trainInputs, trainTargets)
+
+	return &Dataset{
+		TrainInputs:    trainInputs,
+		TrainTargets:   trainTargets,
+		TestInputs:     testInputs,
+		TestTargets:    testTargets,
+		InputSize:      opts.Lookback * numFeatures,
+		OutputSize:     opts.Horizon,
+		Pipeline:       pipeline,
+		TargetMins:     targetMins,
+		TargetMaxs:     targetMaxs,
+		InputShape:     []int{opts.Lookback, numFeatures},
+		SequenceLength: opts.Lookback,
+		TimeSeries:     &opts,
+		TargetNames:    targetNames,
+	}, nil
+}
+
+// Forecast predicts the next steps values of the target column of a time-series model
+// from the most recent rows of raw feature values. The model forecasts Horizon values at
+// a time; to go further, each predicted value is appended as a new row, carrying the
+// other features forward from the last row, and the model is run again on the newest rows.
+func (md *ModelData) Forecast(rows [][]float64, steps int) ([]float64, error) {
+	ts := md.TimeSeries
+	if ts == nil {
+		return nil, fmt.Errorf("model was not trained on a time series")
+	}
+	if len(rows) < ts.Lookback {
+		return nil, fmt.Errorf("expected at least %d rows, but got %d", ts.Lookback, len(rows))
+	}
+	history := append([][]float64(nil), rows...)
+	var forecast []float64
+	for len(forecast) < steps {
+		input, err := md.Pipeline.TransformSequence(history, ts.Lookback)
+		if err != nil {
+			return nil, err
+		}
+		for h, val := range md.Model.Predict(input) {
+			if len(forecast) == steps {
+				break
+			}
+			val = md.TargetScalers[h].Inverse(val)
+			forecast = append(forecast, val)
+			next := append([]float64(nil), history[len(history)-1]...)
+			next[ts.TargetIndex] = val
+			history = append(history, next)
+		}
+	}
+	return forecast, nil
+}
@@ -0,0 +1,84 @@
+package data_test
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"go-neuralnetwork/internal/data"
+	"go-neuralnetwork/internal/neuralnetwork"
+	"go-neuralnetwork/internal/tempfile"
+)
+
+func TestLoadTimeSeries(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("date,load,temp\n")
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&b, "2024-01-%02d,%d,%d\n", i+1, i, 100+i)
+	}
+	filePath, err := tempfile.CreateTempFileWithContent("series-*.csv", b.String())
+	if err != nil {
+		t.Fatalf("Failed to create temp CSV: %v", err)
+	}
+	defer os.Remove(filePath)
+
+	dataset, err := data.LoadTimeSeries(filePath, data.TimeSeriesOptions{Lookback: 3, Horizon: 2, Stride: 1, TargetColumn: "load"}, 0.5)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Rows 0-9 are for training, so windows starting at 0..5 train and 7..15 test.
+	if len(dataset.TrainInputs) != 6 || len(dataset.TestInputs) != 9 {
+		t.Errorf("Expected 6 training and 9 test windows, got %d and %d", len(dataset.TrainInputs), len(dataset.TestInputs))
+	}
+	if dataset.InputSize != 6 || dataset.OutputSize != 2 || dataset.TimeSeries.TargetIndex != 0 {
+		t.Errorf("Unexpected layout: input %d, output %d, target index %d", dataset.InputSize, dataset.OutputSize, dataset.TimeSeries.TargetIndex)
+	}
+	// Test windows are in time order and normalized with the training range 0-9.
+	first := dataset.TestInputs[0]
+	if math.Abs(first[0]-7.0/9) > 1e-9 || math.Abs(dataset.TestTargets[0][0]-10.0/9) > 1e-9 {
+		t.Errorf("Unexpected first test window %v with targets %v", first, dataset.TestTargets[0])
+	}
+
+	if _, err := data.LoadTimeSeries(filePath, data.TimeSeriesOptions{Lookback: 3, Horizon: 1, TargetColumn: "date"}, 0.5); err == nil {
+		t.Errorf("Expected an error for a non-numeric target column, got nil")
+	}
+	// Ten training rows leave no complete training window for a lookback of 8 and a horizon of 3.
+	if _, err := data.LoadTimeSeries(filePath, data.TimeSeriesOptions{Lookback: 8, Horizon: 3, TargetColumn: "load"}, 0.5); err == nil {
+		t.Errorf("Expected an error when the split leaves no training windows, got nil")
+	}
+}
+
+func TestForecast(t *testing.T) {
+	// A linear model that predicts the next target as the last target plus 0.1.
+	dense := neuralnetwork.NewDense(4, 1)
+	dense.Weights = [][]float64{{0, 0, 1, 0}}
+	dense.Biases = []float64{0.1}
+	model, err := neuralnetwork.NewSequential("mse", dense)
+	if err != nil {
+		t.Fatalf("Failed to build model: %v", err)
+	}
+	md := &data.ModelData{
+		Model:         model,
+		Pipeline:      &data.Pipeline{Scalers: data.MinMaxScalers([]float64{0, 0}, []float64{1, 1})},
+		TargetScalers: data.MinMaxScalers([]float64{0}, []float64{1}),
+		TimeSeries:    &data.TimeSeriesOptions{Lookback: 2, Horizon: 1, Stride: 1, TargetIndex: 0},
+	}
+
+	forecast, err := md.Forecast([][]float64{{0.1, 5}, {0.2, 5}}, 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []float64{0.3, 0.4, 0.5}
+	for i := range expected {
+		if math.Abs(forecast[i]-expected[i]) > 1e-9 {
+			t.Errorf("Expected forecast %v, got %v", expected, forecast)
+			break
+		}
+	}
+
+	if _, err := md.Forecast([][]float64{{0.1, 5}}, 1); err == nil {
+		t.Errorf("Expected an error for too few rows, got nil")
+	}
+}
@@ -0,0 +1,195 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// Classification holds the scores of a classifier that picks one of several classes.
+type Classification struct {
+	// Confusion counts the samples of every actual class (row) by predicted class (column).
+	Confusion [][]int
+	Accuracy  float64
+	// Classes holds the scores of every class, in class index order.
+	Classes []ClassScores
+	// Macro averages the scores of the classes, Weighted does so weighted by their
+	// support and Micro pools the samples of all classes, which makes its precision,
+	// recall and F1 equal to the accuracy.
+	Macro, Weighted, Micro Averages
+	// ROCAUC and PRAUC are the means of the one-vs-rest areas of the classes that have
+	// both positive and negative samples, or NaN when no class has.
+	ROCAUC, PRAUC float64
+	// LogLoss is the mean negative log-probability of the actual class, with the scores
+	// of every sample normalised to sum to 1.
+	LogLoss float64
+	// Kappa is Cohen's kappa: the agreement between predicted and actual classes beyond
+	// the agreement expected by chance, 1 when perfect and 0 when no better than chance.
+	Kappa float64
+	// MCC is the Matthews correlation coefficient, from -1 to 1.
+	MCC float64
+}
+
+// ClassScores holds the scores of one class, taken as positive against all others.
+type ClassScores struct {
+	Precision, Recall, F1 float64
+	// Support is the number of samples of the class.
+	Support int
+	// ROCAUC is the area under the ROC curve and PRAUC the average precision of the
+	// class's score; both are NaN when the class has no positive or no negative samples.
+	ROCAUC, PRAUC float64
+}
+
+// Averages holds precision, recall and F1 averaged over classes.
+type Averages struct {
+	Precision, Recall, F1 float64
+}
+
+// EvaluateClassification scores predictions against one-hot targets. The predicted
+// class of a sample is the one with the highest score. Precision is 0 for a class that
+// is never predicted and recall 0 for a class that never occurs.
+func EvaluateClassification(scores, actual [][]float64) Classification {
+	if len(actual) == 0 {
+		return Classification{}
+	}
+	numClasses := len(actual[0])
+	result := Classification{Confusion: make([][]int, numClasses), Classes: make([]ClassScores, numClasses)}
+	for c := range result.Confusion {
+		result.Confusion[c] = make([]int, numClasses)
+	}
+	n := float64(len(actual))
+	correct := 0
+	for i, row := range actual {
+		want, got := argmax(row), argmax(scores[i])
+		result.Confusion[want][got]++
+		if want == got {
+			correct++
+		}
+		total := 0.0
+		for _, score := range scores[i] {
+			total += math.Max(score, 0)
+		}
+		p := 1 / float64(numClasses)
+		if total > 0 {
+			p = math.Max(scores[i][want], 0) / total
+		}
+		result.LogLoss -= math.Log(math.Max(p, 1e-15)) / n
+	}
+	result.Accuracy = float64(correct) / n
+	result.Micro = Averages{result.Accuracy, result.Accuracy, result.Accuracy}
+
+	// predicted and support count the samples predicted as and belonging to every class.
+	predicted := make([]int, numClasses)
+	support := make([]int, numClasses)
+	for want, row := range result.Confusion {
+		for got, count := range row {
+			predicted[got] += count
+			support[want] += count
+		}
+	}
+	aucs, aps := 0, 0
+	for c := range result.Classes {
+		tp := result.Confusion[c][c]
+		s := ClassScores{Support: support[c], F1: f1(tp, predicted[c]-tp, support[c]-tp)}
+		if predicted[c] > 0 {
+			s.Precision = float64(tp) / float64(predicted[c])
+		}
+		if support[c] > 0 {
+			s.Recall = float64(tp) / float64(support[c])
+		} else {
+			s.F1 = 0
+		}
+		s.ROCAUC, s.PRAUC = oneVsRest(scores, actual, c)
+		result.Classes[c] = s
+
+		result.Macro.Precision += s.Precision / float64(numClasses)
+		result.Macro.Recall += s.Recall / float64(numClasses)
+		result.Macro.F1 += s.F1 / float64(numClasses)
+		weight := float64(s.Support) / n
+		result.Weighted.Precision += s.Precision * weight
+		result.Weighted.Recall += s.Recall * weight
+		result.Weighted.F1 += s.F1 * weight
+		if !math.IsNaN(s.ROCAUC) {
+			result.ROCAUC += s.ROCAUC
+			aucs++
+		}
+		if !math.IsNaN(s.PRAUC) {
+			result.PRAUC += s.PRAUC
+			aps++
+		}
+	}
+	result.ROCAUC /= float64(aucs)
+	result.PRAUC /= float64(aps)
+	if aucs == 0 {
+		result.ROCAUC, result.PRAUC = math.NaN(), math.NaN()
+	}
+
+	// Both coefficients compare the agreement on the diagonal with the agreement expected
+	// from the predicted and actual class frequencies.
+	var chance, sumPredicted, sumSupport float64
+	for c := range result.Classes {
+		chance += float64(predicted[c]) * float64(support[c])
+		sumPredicted += float64(predicted[c]) * float64(predicted[c])
+		sumSupport += float64(support[c]) * float64(support[c])
+	}
+	if expected := chance / (n * n); expected < 1 {
+		result.Kappa = (result.Accuracy - expected) / (1 - expected)
+	}
+	if denominator := math.Sqrt((n*n - sumPredicted) * (n*n - sumSupport)); denominator > 0 {
+		result.MCC = (float64(correct)*n - chance) / denominator
+	}
+	return result
+}
+
+// oneVsRest returns the area under the ROC curve and the average precision of the
+// score of class c at telling its samples from the others, or NaN for both when the
+// class has no positive or no negative samples.
+func oneVsRest(scores, actual [][]float64, class int) (rocAUC, prAUC float64) {
+	type sample struct {
+		score    float64
+		positive bool
+	}
+	samples := make([]sample, len(actual))
+	positives := 0
+	for i, row := range actual {
+		samples[i] = sample{scores[i][class], argmax(row) == class}
+		if samples[i].positive {
+			positives++
+		}
+	}
+	negatives := len(samples) - positives
+	if positives == 0 || negatives == 0 {
+		return math.NaN(), math.NaN()
+	}
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].score > samples[j].score })
+
+	// Walk the thresholds from the highest score down, taking tied scores together.
+	// The ROC area counts the negatives ranked below every positive, ties as half.
+	var tp, fp int
+	for i := 0; i < len(samples); {
+		j := i
+		var tiedPositives, tiedNegatives int
+		for ; j < len(samples) && samples[j].score == samples[i].score; j++ {
+			if samples[j].positive {
+				tiedPositives++
+			} else {
+				tiedNegatives++
+			}
+		}
+		rocAUC += float64(tiedPositives) * (float64(negatives-fp) - float64(tiedNegatives)/2)
+		tp += tiedPositives
+		fp += tiedNegatives
+		prAUC += float64(tiedPositives) / float64(positives) * float64(tp) / float64(tp+fp)
+		i = j
+	}
+	return rocAUC / float64(positives*negatives), prAUC
+}
+
+func argmax(values []float64) int {
+	best := 0
+	for i, val := range values {
+		if val > values[best] {
+			best = i
+		}
+	}
+	return best
+}
@@ -0,0 +1,76 @@
+package metrics_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"go-neuralnetwork/internal/metrics"
+)
+
+func TestEvaluateClassification(t *testing.T) {
+	actual := [][]float64{{1, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, 1, 0}, {0, 0, 1}, {0, 0, 1}}
+	scores := [][]float64{
+		{0.7, 0.2, 0.1},
+		{0.3, 0.6, 0.1},
+		{0.1, 0.8, 0.1},
+		{0.2, 0.5, 0.3},
+		{0.1, 0.1, 0.8},
+		{0.5, 0.2, 0.3},
+	}
+
+	result := metrics.EvaluateClassification(scores, actual)
+	if expected := [][]int{{1, 1, 0}, {0, 2, 0}, {1, 0, 1}}; !reflect.DeepEqual(result.Confusion, expected) {
+		t.Errorf("Expected confusion matrix %v, got %v", expected, result.Confusion)
+	}
+	// Every class has two samples, so the weighted averages equal the macro averages.
+	// Chance agreement is (2·2 + 2·3 + 2·1) / 36 = 1/3.
+	expected := map[string][2]float64{
+		"Accuracy":          {4.0 / 6, result.Accuracy},
+		"Precision[1]":      {2.0 / 3, result.Classes[1].Precision},
+		"Recall[2]":         {0.5, result.Classes[2].Recall},
+		"F1[1]":             {0.8, result.Classes[1].F1},
+		"Macro.Precision":   {(0.5 + 2.0/3 + 1) / 3, result.Macro.Precision},
+		"Macro.Recall":      {(0.5 + 1 + 0.5) / 3, result.Macro.Recall},
+		"Macro.F1":          {(0.5 + 0.8 + 2.0/3) / 3, result.Macro.F1},
+		"Weighted.F1":       {(0.5 + 0.8 + 2.0/3) / 3, result.Weighted.F1},
+		"Micro.F1":          {4.0 / 6, result.Micro.F1},
+		"Classes[0].ROCAUC": {7.0 / 8, result.Classes[0].ROCAUC},
+		"LogLoss":           {-(math.Log(0.7) + 2*math.Log(0.3) + 2*math.Log(0.8) + math.Log(0.5)) / 6, result.LogLoss},
+		"Kappa":             {0.5, result.Kappa},
+		"MCC":               {12 / math.Sqrt(22*24), result.MCC},
+	}
+	for name, pair := range expected {
+		if math.Abs(pair[0]-pair[1]) > 1e-9 {
+			t.Errorf("%s: expected %f, got %f", name, pair[0], pair[1])
+		}
+	}
+}
+
+func TestOneVsRestAreas(t *testing.T) {
+	// Ranked by score the samples are positive, negative, positive, negative: three of
+	// the four positive-negative pairs are in order, and the precision is 1 at the
+	// first positive and 2/3 at the second.
+	actual := [][]float64{{0, 1}, {1, 0}, {0, 1}, {1, 0}}
+	scores := [][]float64{{0.1, 0.9}, {0.5, 0.5}, {0.6, 0.4}, {0.9, 0.1}}
+
+	result := metrics.EvaluateClassification(scores, actual)
+	if got := result.Classes[1].ROCAUC; math.Abs(got-0.75) > 1e-9 {
+		t.Errorf("Expected ROC-AUC 0.75, got %f", got)
+	}
+	if got := result.Classes[1].PRAUC; math.Abs(got-(0.5+0.5*2.0/3)) > 1e-9 {
+		t.Errorf("Expected PR-AUC %f, got %f", 0.5+0.5*2.0/3, got)
+	}
+
+	// Tied scores count as half a pair in order.
+	tied := metrics.EvaluateClassification([][]float64{{0.5, 0.5}, {0.5, 0.5}}, [][]float64{{0, 1}, {1, 0}})
+	if tied.Classes[1].ROCAUC != 0.5 {
+		t.Errorf("Expected ROC-AUC 0.5 for tied scores, got %f", tied.Classes[1].ROCAUC)
+	}
+
+	// A class without negative samples has no ROC curve.
+	single := metrics.EvaluateClassification([][]float64{{0.8, 0.2}}, [][]float64{{1, 0}})
+	if !math.IsNaN(single.Classes[0].ROCAUC) || !math.IsNaN(single.ROCAUC) {
+		t.Errorf("Expected NaN ROC-AUC with a single class, got %f", single.ROCAUC)
+	}
+}
@@ -0,0 +1,109 @@
+package metrics
+
+// MultiLabel holds the scores of a multi-label classifier, where every sample can carry
+// any number of labels.
+type MultiLabel struct {
+	// SubsetAccuracy is the fraction of samples whose labels are all predicted exactly.
+	SubsetAccuracy float64
+	// HammingLoss is the fraction of sample-label pairs predicted wrongly.
+	HammingLoss float64
+	// MicroF1 is the F1 score of all sample-label pairs pooled together.
+	MicroF1 float64
+	// MacroF1 is the mean of the F1 scores of the individual labels.
+	MacroF1 float64
+}
+
+// EvaluateMultiLabel scores predictions against multi-hot targets. A label is predicted
+// when its score reaches the label's threshold. A label that is neither present nor
+// predicted in any sample has an F1 score of 1.
+func EvaluateMultiLabel(scores, actual [][]float64, thresholds []float64) MultiLabel {
+	if len(actual) == 0 {
+		return MultiLabel{}
+	}
+	numLabels := len(thresholds)
+	tp := make([]int, numLabels)
+	fp := make([]int, numLabels)
+	fn := make([]int, numLabels)
+	exact, wrong := 0, 0
+	for i, row := range actual {
+		allRight := true
+		for l := range thresholds {
+			predicted, present := scores[i][l] >= thresholds[l], row[l] >= 0.5
+			switch {
+			case predicted && present:
+				tp[l]++
+			case predicted:
+				fp[l]++
+			case present:
+				fn[l]++
+			}
+			if predicted != present {
+				allRight = false
+				wrong++
+			}
+		}
+		if allRight {
+			exact++
+		}
+	}
+
+	var result MultiLabel
+	result.SubsetAccuracy = float64(exact) / float64(len(actual))
+	result.HammingLoss = float64(wrong) / float64(len(actual)*numLabels)
+	var sumTP, sumFP, sumFN int
+	for l := range thresholds {
+		result.MacroF1 += f1(tp[l], fp[l], fn[l])
+		sumTP += tp[l]
+		sumFP += fp[l]
+		sumFN += fn[l]
+	}
+	result.MacroF1 /= float64(numLabels)
+	result.MicroF1 = f1(sumTP, sumFP, sumFN)
+	return result
+}
+
+// TuneThresholds picks, for every label, the threshold between 0.05 and 0.95 in steps
+// of 0.05 that gives the best F1 score on the given samples, keeping 0.5 on ties.
+func TuneThresholds(scores, actual [][]float64) []float64 {
+	if len(actual) == 0 {
+		return nil
+	}
+	thresholds := make([]float64, len(actual[0]))
+	for l := range thresholds {
+		thresholds[l] = 0.5
+		best := labelF1(scores, actual, l, 0.5)
+		for step := 1; step < 20; step++ {
+			threshold := float64(step) / 20
+			if score := labelF1(scores, actual, l, threshold); score > best {
+				best, thresholds[l] = score, threshold
+			}
+		}
+	}
+	return thresholds
+}
+
+// labelF1 returns the F1 score of one label at the given threshold.
+func labelF1(scores, actual [][]float64, label int, threshold float64) float64 {
+	var tp, fp, fn int
+	for i, row := range actual {
+		predicted, present := scores[i][label] >= threshold, row[label] >= 0.5
+		switch {
+		case predicted && present:
+			tp++
+		case predicted:
+			fp++
+		case present:
+			fn++
+		}
+	}
+	return f1(tp, fp, fn)
+}
+
+// f1 returns the harmonic mean of precision and recall, or 1 when there was nothing to
+// find and nothing was predicted.
+func f1(tp, fp, fn int) float64 {
+	if tp+fp+fn == 0 {
+		return 1
+	}
+	return 2 * float64(tp) / float64(2*tp+fp+fn)
+}
@@ -0,0 +1,42 @@
+package metrics_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"go-neuralnetwork/internal/metrics"
+)
+
+func TestEvaluateMultiLabel(t *testing.T) {
+	actual := [][]float64{{1, 0, 1}, {0, 1, 0}, {1, 1, 0}}
+	scores := [][]float64{{0.9, 0.2, 0.7}, {0.1, 0.6, 0.4}, {0.8, 0.3, 0.1}}
+
+	result := metrics.EvaluateMultiLabel(scores, actual, []float64{0.5, 0.5, 0.5})
+	// Only the second label of the last sample is missed.
+	expected := metrics.MultiLabel{
+		SubsetAccuracy: 2.0 / 3,
+		HammingLoss:    1.0 / 9,
+		MicroF1:        2 * 4.0 / (2*4 + 0 + 1),
+		MacroF1:        (1 + 2.0/3 + 1) / 3,
+	}
+	for name, pair := range map[string][2]float64{
+		"SubsetAccuracy": {expected.SubsetAccuracy, result.SubsetAccuracy},
+		"HammingLoss":    {expected.HammingLoss, result.HammingLoss},
+		"MicroF1":        {expected.MicroF1, result.MicroF1},
+		"MacroF1":        {expected.MacroF1, result.MacroF1},
+	} {
+		if math.Abs(pair[0]-pair[1]) > 1e-9 {
+			t.Errorf("%s: expected %f, got %f", name, pair[0], pair[1])
+		}
+	}
+
+	// A threshold of at most 0.3 on the second label recovers the missed label.
+	thresholds := metrics.TuneThresholds(scores, actual)
+	if !reflect.DeepEqual(thresholds, []float64{0.5, 0.25, 0.5}) {
+		t.Errorf("Unexpected tuned thresholds %v", thresholds)
+	}
+	if result := metrics.EvaluateMultiLabel(scores, actual, thresholds); result.SubsetAccuracy != 1 {
+		t.Errorf("Expected tuned thresholds to predict every label, got %+v", result)
+	}
+}
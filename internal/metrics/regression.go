@@ -0,0 +1,209 @@
+// Package metrics evaluates model predictions against known targets.
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// Regression holds the error measures of one regression target.
+type Regression struct {
+	MAE  float64
+	MSE  float64
+	RMSE float64
+	// MAPE is the mean absolute error relative to the actual value, as a fraction. Samples
+	// whose actual value is 0 are left out; it is NaN when all are.
+	MAPE float64
+	// MedianAE is the median absolute error, which unlike the MAE ignores a few large errors.
+	MedianAE float64
+	// R2 is the coefficient of determination: 1 for perfect predictions, 0 for
+	// predicting the mean, and negative for worse than the mean.
+	R2 float64
+	// ExplainedVariance is like R2 but ignores a constant offset of the predictions: the
+	// share of the variance of the actual values that the variance of the errors leaves.
+	ExplainedVariance float64
+	// Samples is the number of samples scored.
+	Samples int
+}
+
+// AdjustedR2 returns R² adjusted for the number of input features of the model, which
+// only rises when more inputs improve the fit more than chance would. It is NaN when
+// there are not more samples than inputs plus one.
+func (r Regression) AdjustedR2(predictors int) float64 {
+	if r.Samples-predictors-1 <= 0 {
+		return math.NaN()
+	}
+	return 1 - (1-r.R2)*float64(r.Samples-1)/float64(r.Samples-predictors-1)
+}
+
+// RegressionPerTarget computes the metrics of every output column. predicted and actual
+// hold one row of outputs per sample and must have the same shape.
+func RegressionPerTarget(predicted, actual [][]float64) []Regression {
+	if len(actual) == 0 {
+		return nil
+	}
+	results := make([]Regression, len(actual[0]))
+	for col := range results {
+		mean := 0.0
+		for _, row := range actual {
+			mean += row[col]
+		}
+		mean /= float64(len(actual))
+
+		var absErr, sqErr, total, relErr, sumErr float64
+		relSamples := 0
+		absErrs := make([]float64, len(actual))
+		for i, row := range actual {
+			diff := predicted[i][col] - row[col]
+			absErr += math.Abs(diff)
+			sqErr += diff * diff
+			sumErr += diff
+			total += (row[col] - mean) * (row[col] - mean)
+			absErrs[i] = math.Abs(diff)
+			if row[col] != 0 {
+				relErr += math.Abs(diff / row[col])
+				relSamples++
+			}
+		}
+		n := float64(len(actual))
+		sort.Float64s(absErrs)
+		results[col] = Regression{
+			MAE:      absErr / n,
+			MSE:      sqErr / n,
+			RMSE:     math.Sqrt(sqErr / n),
+			MAPE:     math.NaN(),
+			MedianAE: Quantile(absErrs, 0.5),
+			Samples:  len(actual),
+		}
+		if relSamples > 0 {
+			results[col].MAPE = relErr / float64(relSamples)
+		}
+		if total > 0 {
+			results[col].R2 = 1 - sqErr/total
+			// The variance of the errors is their mean square less their squared mean.
+			meanErr := sumErr / n
+			results[col].ExplainedVariance = 1 - (sqErr/n-meanErr*meanErr)/(total/n)
+		}
+	}
+	return results
+}
+
+// Residuals summarises the residuals, predicted minus actual value, of one regression
+// target.
+type Residuals struct {
+	Mean, Std float64
+	// Min, Q1, Median, Q3 and Max are the quartiles of the residuals.
+	Min, Q1, Median, Q3, Max float64
+	// Histogram counts the residuals in equal-width bins from Min to Max.
+	Histogram []Bin
+	// Largest holds the samples with the largest absolute residuals, largest first.
+	Largest []Residual
+	// ByPrediction groups the samples into equal-width bins of the predicted value, to
+	// show where the model over- or underestimates.
+	ByPrediction []PredictionBin
+}
+
+// Bin is the number of values from Low up to High.
+type Bin struct {
+	Low, High float64
+	Count     int
+}
+
+// Residual is the prediction of one sample, identified by its row index.
+type Residual struct {
+	Index             int
+	Predicted, Actual float64
+	Residual          float64
+}
+
+// PredictionBin holds the residuals of the samples predicted from Low up to High: their
+// mean, which is the bias of those predictions, and their mean absolute value. Both are
+// 0 for an empty bin.
+type PredictionBin struct {
+	Bin
+	MeanResidual, MAE float64
+}
+
+// SummarizeResiduals summarises the residuals of the predictions of one target: their
+// distribution in the given number of bins, the largest of them, and their mean by
+// predicted value in as many bins.
+func SummarizeResiduals(predicted, actual []float64, bins, largest int) Residuals {
+	if len(actual) == 0 {
+		return Residuals{}
+	}
+	bins = max(bins, 1)
+	all := make([]Residual, len(actual))
+	values := make([]float64, len(actual))
+	var r Residuals
+	for i := range actual {
+		all[i] = Residual{Index: i, Predicted: predicted[i], Actual: actual[i], Residual: predicted[i] - actual[i]}
+		values[i] = all[i].Residual
+		r.Mean += values[i] / float64(len(actual))
+	}
+	for _, v := range values {
+		r.Std += (v - r.Mean) * (v - r.Mean) / float64(len(values))
+	}
+	r.Std = math.Sqrt(r.Std)
+	sort.Float64s(values)
+	r.Min, r.Q1, r.Median, r.Q3, r.Max = values[0], Quantile(values, 0.25), Quantile(values, 0.5), Quantile(values, 0.75), values[len(values)-1]
+
+	r.Histogram = make([]Bin, bins)
+	for b := range r.Histogram {
+		r.Histogram[b].Low, r.Histogram[b].High = binEdges(r.Min, r.Max, bins, b)
+	}
+	for _, v := range values {
+		r.Histogram[binIndex(v, r.Min, r.Max, bins)].Count++
+	}
+
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, p := range predicted {
+		low, high = math.Min(low, p), math.Max(high, p)
+	}
+	r.ByPrediction = make([]PredictionBin, bins)
+	for b := range r.ByPrediction {
+		r.ByPrediction[b].Low, r.ByPrediction[b].High = binEdges(low, high, bins, b)
+	}
+	for _, s := range all {
+		bin := &r.ByPrediction[binIndex(s.Predicted, low, high, bins)]
+		bin.Count++
+		bin.MeanResidual += s.Residual
+		bin.MAE += math.Abs(s.Residual)
+	}
+	for b := range r.ByPrediction {
+		if count := float64(r.ByPrediction[b].Count); count > 0 {
+			r.ByPrediction[b].MeanResidual /= count
+			r.ByPrediction[b].MAE /= count
+		}
+	}
+
+	sort.SliceStable(all, func(i, j int) bool { return math.Abs(all[i].Residual) > math.Abs(all[j].Residual) })
+	r.Largest = all[:min(largest, len(all))]
+	return r
+}
+
+// binEdges returns the bounds of bin b of the given number of equal-width bins from low
+// to high.
+func binEdges(low, high float64, bins, b int) (float64, float64) {
+	width := (high - low) / float64(bins)
+	return low + float64(b)*width, low + float64(b+1)*width
+}
+
+// binIndex returns the bin of v among the given number of equal-width bins from low to
+// high, with high itself in the last bin.
+func binIndex(v, low, high float64, bins int) int {
+	if high <= low {
+		return 0
+	}
+	return min(int((v-low)/(high-low)*float64(bins)), bins-1)
+}
+
+// Quantile returns the q-quantile of sorted values, interpolating linearly between the
+// two nearest values.
+func Quantile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	i := int(pos)
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (pos-float64(i))*(sorted[i+1]-sorted[i])
+}
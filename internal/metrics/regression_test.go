@@ -0,0 +1,108 @@
+package metrics_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"go-neuralnetwork/internal/metrics"
+)
+
+func TestRegressionPerTarget(t *testing.T) {
+	actual := [][]float64{{1, 10}, {2, 20}, {3, 30}}
+	predicted := [][]float64{{1, 12}, {2, 18}, {3, 33}}
+
+	results := metrics.RegressionPerTarget(predicted, actual)
+	if len(results) != 2 {
+		t.Fatalf("Expected metrics for 2 targets, got %d", len(results))
+	}
+	if results[0].MAE != 0 || results[0].R2 != 1 {
+		t.Errorf("Expected a perfect first target, got %+v", results[0])
+	}
+
+	// The errors are 2, -2 and 3: their mean is 1 and their variance 17/3 - 1 = 14/3,
+	// against a variance of 200/3 of the actual values.
+	expected := metrics.Regression{
+		MAE:               7.0 / 3,
+		MSE:               17.0 / 3,
+		RMSE:              math.Sqrt(17.0 / 3),
+		MAPE:              (0.2 + 0.1 + 0.1) / 3,
+		MedianAE:          2,
+		R2:                1 - 17.0/200,
+		ExplainedVariance: 1 - 14.0/200,
+	}
+	got := results[1]
+	for name, pair := range map[string][2]float64{
+		"MAE":               {expected.MAE, got.MAE},
+		"MSE":               {expected.MSE, got.MSE},
+		"RMSE":              {expected.RMSE, got.RMSE},
+		"MAPE":              {expected.MAPE, got.MAPE},
+		"MedianAE":          {expected.MedianAE, got.MedianAE},
+		"R2":                {expected.R2, got.R2},
+		"ExplainedVariance": {expected.ExplainedVariance, got.ExplainedVariance},
+		"AdjustedR2":        {1 - (17.0/200)*2, got.AdjustedR2(1)},
+	} {
+		if math.Abs(pair[0]-pair[1]) > 1e-9 {
+			t.Errorf("%s: expected %f, got %f", name, pair[0], pair[1])
+		}
+	}
+
+	if !math.IsNaN(got.AdjustedR2(2)) {
+		t.Errorf("Expected no adjusted R² with as many inputs as samples, got %f", got.AdjustedR2(2))
+	}
+	if zero := metrics.RegressionPerTarget([][]float64{{1}}, [][]float64{{0}}); !math.IsNaN(zero[0].MAPE) {
+		t.Errorf("Expected no MAPE when every actual value is 0, got %f", zero[0].MAPE)
+	}
+
+	if metrics.RegressionPerTarget(nil, nil) != nil {
+		t.Errorf("Expected no metrics without samples")
+	}
+}
+
+func TestSummarizeResiduals(t *testing.T) {
+	// The residuals are -1, 0, 2 and 6.
+	r := metrics.SummarizeResiduals([]float64{1, 2, 4, 8}, []float64{2, 2, 2, 2}, 2, 2)
+	for name, pair := range map[string][2]float64{
+		"Mean":   {1.75, r.Mean},
+		"Std":    {math.Sqrt(28.75 / 4), r.Std},
+		"Min":    {-1, r.Min},
+		"Q1":     {-0.25, r.Q1},
+		"Median": {1, r.Median},
+		"Q3":     {3, r.Q3},
+		"Max":    {6, r.Max},
+	} {
+		if math.Abs(pair[0]-pair[1]) > 1e-9 {
+			t.Errorf("%s: expected %f, got %f", name, pair[0], pair[1])
+		}
+	}
+
+	expectedHistogram := []metrics.Bin{{Low: -1, High: 2.5, Count: 3}, {Low: 2.5, High: 6, Count: 1}}
+	if !reflect.DeepEqual(r.Histogram, expectedHistogram) {
+		t.Errorf("Expected histogram %v, got %v", expectedHistogram, r.Histogram)
+	}
+
+	// Predictions from 1 to 4.5 are too high by 1/3 on average, the prediction of 8 by 6.
+	expectedBins := []metrics.PredictionBin{
+		{Bin: metrics.Bin{Low: 1, High: 4.5, Count: 3}, MeanResidual: 1.0 / 3, MAE: 1},
+		{Bin: metrics.Bin{Low: 4.5, High: 8, Count: 1}, MeanResidual: 6, MAE: 6},
+	}
+	for i, bin := range r.ByPrediction {
+		want := expectedBins[i]
+		if bin.Bin != want.Bin || math.Abs(bin.MeanResidual-want.MeanResidual) > 1e-9 || bin.MAE != want.MAE {
+			t.Errorf("Prediction bin %d: expected %+v, got %+v", i, want, bin)
+		}
+	}
+
+	if len(r.Largest) != 2 || r.Largest[0].Index != 3 || r.Largest[1].Index != 2 || r.Largest[0].Residual != 6 {
+		t.Errorf("Expected the samples 3 and 2 to have the largest residuals, got %+v", r.Largest)
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	sorted := []float64{1, 2, 4, 8}
+	for q, want := range map[float64]float64{0: 1, 0.25: 1.75, 0.5: 3, 1: 8} {
+		if got := metrics.Quantile(sorted, q); math.Abs(got-want) > 1e-12 {
+			t.Errorf("Quantile(%v): expected %f, got %f", q, want, got)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package neuralnetwork
+
+// ActivationLayer applies an activation function element-wise.
+type ActivationLayer struct {
+	Activation string `json:"activation"`
+
+	activationFunc Activation
+	output         []float64
+}
+
+// NewActivationLayer creates a layer for the named activation function.
+func NewActivationLayer(name string) (*ActivationLayer, error) {
+	a := &ActivationLayer{Activation: name}
+	if err := a.initialize(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *ActivationLayer) initialize() error {
+	activation, err := GetActivation(a.Activation)
+	if err != nil {
+		return err
+	}
+	a.activationFunc = activation
+	return nil
+}
+
+// Type returns the registered name of the layer.
+func (a *ActivationLayer) Type() string { return "activation" }
+
+// Forward applies the activation function to every input value.
+func (a *ActivationLayer) Forward(input []float64, training bool) []float64 {
+	a.output = make([]float64, len(input))
+	for i, val := range input {
+		a.output[i] = a.activationFunc.Activate(val)
+	}
+	return a.output
+}
+
+// Backward multiplies the output gradient by the activation derivative.
+// Derivatives are taken with respect to the activated output, as in Activation.
+func (a *ActivationLayer) Backward(outputGrad []float64) []float64 {
+	inputGrad := make([]float64, len(outputGrad))
+	for i, grad := range outputGrad {
+		inputGrad[i] = grad * a.activationFunc.Derivative(a.output[i])
+	}
+	return inputGrad
+}
+
+// Params returns nil as the layer has no trainable parameters.
+func (a *ActivationLayer) Params() [][]float64 { return nil }
+
+// Grads returns nil as the layer has no trainable parameters.
+func (a *ActivationLayer) Grads() [][]float64 { return nil }
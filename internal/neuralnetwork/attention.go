@@ -0,0 +1,416 @@
+package neuralnetwork
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// The layers in this file work on sequences stored time step by time step: SeqLen
+// consecutive blocks of ModelDim values.
+
+// splitRows views a flat sequence as rows of width values without copying.
+func splitRows(flat []float64, width int) [][]float64 {
+	rows := make([][]float64, len(flat)/width)
+	for i := range rows {
+		rows[i] = flat[i*width : (i+1)*width]
+	}
+	return rows
+}
+
+// joinRows flattens rows into one slice.
+func joinRows(rows [][]float64) []float64 {
+	var flat []float64
+	for _, row := range rows {
+		flat = append(flat, row...)
+	}
+	return flat
+}
+
+// linearRows applies weights (out x in) and biases to every row of x.
+func linearRows(x, weights [][]float64, biases []float64) [][]float64 {
+	y := newMatrix(len(x), len(weights))
+	for t, row := range x {
+		for o, w := range weights {
+			sum := biases[o]
+			for i, val := range row {
+				sum += w[i] * val
+			}
+			y[t][o] = sum
+		}
+	}
+	return y
+}
+
+// linearRowsBackward accumulates the weight and bias gradients of linearRows and
+// returns the gradient with respect to x.
+func linearRowsBackward(dy, x, weights, weightGrads [][]float64, biasGrads []float64) [][]float64 {
+	dx := newMatrix(len(x), len(weights[0]))
+	for t, grads := range dy {
+		for o, grad := range grads {
+			biasGrads[o] += grad
+			for i, val := range x[t] {
+				weightGrads[o][i] += grad * val
+				dx[t][i] += grad * weights[o][i]
+			}
+		}
+	}
+	return dx
+}
+
+// randomMatrix creates a rows x cols matrix with normal values of the given scale.
+func randomMatrix(rows, cols int, scale float64) [][]float64 {
+	m := newMatrix(rows, cols)
+	for i := range m {
+		for j := range m[i] {
+			m[i][j] = rand.NormFloat64() * scale
+		}
+	}
+	return m
+}
+
+// MultiHeadAttention is scaled dot-product self-attention over a sequence. Queries,
+// keys and values are linear projections of the input split into Heads heads of
+// ModelDim/Heads values; the heads' outputs are concatenated and projected back to
+// ModelDim. With Causal set, each step only attends to itself and earlier steps.
+type MultiHeadAttention struct {
+	SeqLen        int         `json:"seqLen"`
+	ModelDim      int         `json:"modelDim"`
+	Heads         int         `json:"heads"`
+	Causal        bool        `json:"causal"`
+	QueryWeights  [][]float64 `json:"queryWeights"`
+	KeyWeights    [][]float64 `json:"keyWeights"`
+	ValueWeights  [][]float64 `json:"valueWeights"`
+	OutputWeights [][]float64 `json:"outputWeights"`
+	QueryBiases   []float64   `json:"queryBiases"`
+	KeyBiases     []float64   `json:"keyBiases"`
+	ValueBiases   []float64   `json:"valueBiases"`
+	OutputBiases  []float64   `json:"outputBiases"`
+
+	weightGrads [4][][]float64
+	biasGrads   [4][]float64
+
+	input, queries, keys, values, concat [][]float64
+	attention                            [][][]float64
+}
+
+// NewMultiHeadAttention creates a self-attention layer. modelDim must be divisible by heads.
+func NewMultiHeadAttention(seqLen, modelDim, heads int, causal bool) (*MultiHeadAttention, error) {
+	if heads < 1 || modelDim%heads != 0 {
+		return nil, fmt.Errorf("model dimension %d cannot be split into %d heads", modelDim, heads)
+	}
+	scale := math.Sqrt(1.0 / float64(modelDim))
+	a := &MultiHeadAttention{
+		SeqLen:        seqLen,
+		ModelDim:      modelDim,
+		Heads:         heads,
+		Causal:        causal,
+		QueryWeights:  randomMatrix(modelDim, modelDim, scale),
+		KeyWeights:    randomMatrix(modelDim, modelDim, scale),
+		ValueWeights:  randomMatrix(modelDim, modelDim, scale),
+		OutputWeights: randomMatrix(modelDim, modelDim, scale),
+		QueryBiases:   make([]float64, modelDim),
+		KeyBiases:     make([]float64, modelDim),
+		ValueBiases:   make([]float64, modelDim),
+		OutputBiases:  make([]float64, modelDim),
+	}
+	a.initialize()
+	return a, nil
+}
+
+func (a *MultiHeadAttention) initialize() error {
+	if a.Heads < 1 || a.ModelDim%a.Heads != 0 {
+		return fmt.Errorf("model dimension %d cannot be split into %d heads", a.ModelDim, a.Heads)
+	}
+	for i := range a.weightGrads {
+		a.weightGrads[i] = newMatrix(a.ModelDim, a.ModelDim)
+		a.biasGrads[i] = make([]float64, a.ModelDim)
+	}
+	return nil
+}
+
+// Type returns the registered name of the layer.
+func (a *MultiHeadAttention) Type() string { return "attention" }
+
+// Forward attends every step of the sequence to the others.
+func (a *MultiHeadAttention) Forward(input []float64, training bool) []float64 {
+	a.input = splitRows(input, a.ModelDim)
+	a.queries = linearRows(a.input, a.QueryWeights, a.QueryBiases)
+	a.keys = linearRows(a.input, a.KeyWeights, a.KeyBiases)
+	a.values = linearRows(a.input, a.ValueWeights, a.ValueBiases)
+
+	headDim := a.ModelDim / a.Heads
+	scale := 1 / math.Sqrt(float64(headDim))
+	a.concat = newMatrix(a.SeqLen, a.ModelDim)
+	a.attention = make([][][]float64, a.Heads)
+	for h := 0; h < a.Heads; h++ {
+		offset := h * headDim
+		a.attention[h] = newMatrix(a.SeqLen, a.SeqLen)
+		for i := 0; i < a.SeqLen; i++ {
+			weights := a.attention[h][i]
+			visible := a.SeqLen
+			if a.Causal {
+				visible = i + 1
+			}
+			maxScore := math.Inf(-1)
+			for j := 0; j < visible; j++ {
+				score := 0.0
+				for c := offset; c < offset+headDim; c++ {
+					score += a.queries[i][c] * a.keys[j][c]
+				}
+				weights[j] = score * scale
+				maxScore = math.Max(maxScore, weights[j])
+			}
+			sum := 0.0
+			for j := 0; j < visible; j++ {
+				weights[j] = math.Exp(weights[j] - maxScore)
+				sum += weights[j]
+			}
+			for j := 0; j < visible; j++ {
+				weights[j] /= sum
+				for c := offset; c < offset+headDim; c++ {
+					a.concat[i][c] += weights[j] * a.values[j][c]
+				}
+			}
+		}
+	}
+	return joinRows(linearRows(a.concat, a.OutputWeights, a.OutputBiases))
+}
+
+// Backward propagates the gradient through the output projection, the attention
+// weights and the query, key and value projections.
+func (a *MultiHeadAttention) Backward(outputGrad []float64) []float64 {
+	dConcat := linearRowsBackward(splitRows(outputGrad, a.ModelDim), a.concat, a.OutputWeights, a.weightGrads[3], a.biasGrads[3])
+
+	headDim := a.ModelDim / a.Heads
+	scale := 1 / math.Sqrt(float64(headDim))
+	dQueries := newMatrix(a.SeqLen, a.ModelDim)
+	dKeys := newMatrix(a.SeqLen, a.ModelDim)
+	dValues := newMatrix(a.SeqLen, a.ModelDim)
+	dWeights := make([]float64, a.SeqLen)
+	for h := 0; h < a.Heads; h++ {
+		offset := h * headDim
+		for i := 0; i < a.SeqLen; i++ {
+			weights := a.attention[h][i]
+			// Gradient of the attention weights, then through the softmax.
+			dot := 0.0
+			for j := range dWeights {
+				dWeights[j] = 0
+				for c := offset; c < offset+headDim; c++ {
+					dWeights[j] += dConcat[i][c] * a.values[j][c]
+					dValues[j][c] += weights[j] * dConcat[i][c]
+				}
+				dot += dWeights[j] * weights[j]
+			}
+			for j, weight := range weights {
+				dScore := weight * (dWeights[j] - dot) * scale
+				for c := offset; c < offset+headDim; c++ {
+					dQueries[i][c] += dScore * a.keys[j][c]
+					dKeys[j][c] += dScore * a.queries[i][c]
+				}
+			}
+		}
+	}
+
+	inputGrad := make([]float64, a.SeqLen*a.ModelDim)
+	projections := [][][]float64{a.QueryWeights, a.KeyWeights, a.ValueWeights}
+	for p, grads := range [][][]float64{dQueries, dKeys, dValues} {
+		dx := linearRowsBackward(grads, a.input, projections[p], a.weightGrads[p], a.biasGrads[p])
+		for t, row := range dx {
+			for i, grad := range row {
+				inputGrad[t*a.ModelDim+i] += grad
+			}
+		}
+	}
+	return inputGrad
+}
+
+// Params returns the query, key, value and output weights followed by their biases.
+func (a *MultiHeadAttention) Params() [][]float64 {
+	var params [][]float64
+	for _, weights := range [][][]float64{a.QueryWeights, a.KeyWeights, a.ValueWeights, a.OutputWeights} {
+		params = append(params, weights...)
+	}
+	return append(params, a.QueryBiases, a.KeyBiases, a.ValueBiases, a.OutputBiases)
+}
+
+// Grads returns the gradients aligned with Params.
+func (a *MultiHeadAttention) Grads() [][]float64 {
+	var grads [][]float64
+	for _, weights := range a.weightGrads {
+		grads = append(grads, weights...)
+	}
+	return append(grads, a.biasGrads[:]...)
+}
+
+// PositionalEncoding adds the sinusoidal position signals of "Attention Is All You
+// Need" to a sequence, so that attention can tell the steps apart.
+type PositionalEncoding struct {
+	SeqLen   int `json:"seqLen"`
+	ModelDim int `json:"modelDim"`
+}
+
+// NewPositionalEncoding creates a positional encoding for seqLen steps of modelDim values.
+func NewPositionalEncoding(seqLen, modelDim int) *PositionalEncoding {
+	return &PositionalEncoding{SeqLen: seqLen, ModelDim: modelDim}
+}
+
+// Type returns the registered name of the layer.
+func (p *PositionalEncoding) Type() string { return "posenc" }
+
+// Forward adds sin(pos/10000^(2i/d)) to even and cos of the same angle to odd features.
+func (p *PositionalEncoding) Forward(input []float64, training bool) []float64 {
+	output := make([]float64, len(input))
+	for pos := 0; pos < p.SeqLen; pos++ {
+		for i := 0; i < p.ModelDim; i++ {
+			angle := float64(pos) / math.Pow(10000, float64(i-i%2)/float64(p.ModelDim))
+			signal := math.Sin(angle)
+			if i%2 == 1 {
+				signal = math.Cos(angle)
+			}
+			output[pos*p.ModelDim+i] = input[pos*p.ModelDim+i] + signal
+		}
+	}
+	return output
+}
+
+// Backward returns the gradient unchanged, as the encoding is a constant offset.
+func (p *PositionalEncoding) Backward(outputGrad []float64) []float64 { return outputGrad }
+
+// Params returns nil as the layer has no trainable parameters.
+func (p *PositionalEncoding) Params() [][]float64 { return nil }
+
+// Grads returns nil as the layer has no trainable parameters.
+func (p *PositionalEncoding) Grads() [][]float64 { return nil }
+
+// TimeDistributedDense applies the same fully connected layer to every step of a sequence.
+type TimeDistributedDense struct {
+	SeqLen     int         `json:"seqLen"`
+	NumInputs  int         `json:"numInputs"`
+	NumOutputs int         `json:"numOutputs"`
+	Weights    [][]float64 `json:"weights"`
+	Biases     []float64   `json:"biases"`
+
+	weightGrads [][]float64
+	biasGrads   []float64
+	input       [][]float64
+}
+
+// NewTimeDistributedDense creates a per-step fully connected layer with He-initialized weights.
+func NewTimeDistributedDense(seqLen, inputs, outputs int) *TimeDistributedDense {
+	d := &TimeDistributedDense{
+		SeqLen:     seqLen,
+		NumInputs:  inputs,
+		NumOutputs: outputs,
+		Weights:    randomMatrix(outputs, inputs, math.Sqrt(2.0/float64(inputs))),
+		Biases:     make([]float64, outputs),
+	}
+	d.initialize()
+	return d
+}
+
+func (d *TimeDistributedDense) initialize() error {
+	d.weightGrads = newMatrix(d.NumOutputs, d.NumInputs)
+	d.biasGrads = make([]float64, d.NumOutputs)
+	return nil
+}
+
+// Type returns the registered name of the layer.
+func (d *TimeDistributedDense) Type() string { return "timedense" }
+
+// Forward computes Weights*x + Biases for every step x.
+func (d *TimeDistributedDense) Forward(input []float64, training bool) []float64 {
+	d.input = splitRows(input, d.NumInputs)
+	return joinRows(linearRows(d.input, d.Weights, d.Biases))
+}
+
+// Backward accumulates the gradients of every step and returns the input gradient.
+func (d *TimeDistributedDense) Backward(outputGrad []float64) []float64 {
+	return joinRows(linearRowsBackward(splitRows(outputGrad, d.NumOutputs), d.input, d.Weights, d.weightGrads, d.biasGrads))
+}
+
+// Params returns the weight rows followed by the biases.
+func (d *TimeDistributedDense) Params() [][]float64 {
+	return append(append([][]float64{}, d.Weights...), d.Biases)
+}
+
+// Grads returns the gradients aligned with Params.
+func (d *TimeDistributedDense) Grads() [][]float64 {
+	return append(append([][]float64{}, d.weightGrads...), d.biasGrads)
+}
+
+// TransformerEncoder is a post-norm transformer encoder block:
+//
+//	x = LayerNorm(x + MultiHeadAttention(x))
+//	x = LayerNorm(x + Dense(ReLU(Dense(x))))
+//
+// where the feed-forward dense layers are applied to every step separately.
+type TransformerEncoder struct {
+	Layers []Layer
+}
+
+// NewTransformerEncoder creates an encoder block for seqLen steps of modelDim values with
+// the given number of attention heads and feed-forward width.
+func NewTransformerEncoder(seqLen, modelDim, heads, ffDim int) (*TransformerEncoder, error) {
+	attention, err := NewMultiHeadAttention(seqLen, modelDim, heads, false)
+	if err != nil {
+		return nil, err
+	}
+	relu, err := NewActivationLayer("relu")
+	if err != nil {
+		return nil, err
+	}
+	size := seqLen * modelDim
+	return &TransformerEncoder{Layers: []Layer{
+		NewResidual(size, size, attention),
+		NewLayerNorm(modelDim),
+		NewResidual(size, size,
+			NewTimeDistributedDense(seqLen, modelDim, ffDim),
+			relu,
+			NewTimeDistributedDense(seqLen, ffDim, modelDim)),
+		NewLayerNorm(modelDim),
+	}}, nil
+}
+
+// Type returns the registered name of the layer.
+func (e *TransformerEncoder) Type() string { return "transformer" }
+
+// Forward runs the input through the block.
+func (e *TransformerEncoder) Forward(input []float64, training bool) []float64 {
+	return forwardBlock(e.Layers, input, training)
+}
+
+// Backward propagates the gradient through the block.
+func (e *TransformerEncoder) Backward(outputGrad []float64) []float64 {
+	return backwardBlock(e.Layers, outputGrad)
+}
+
+// Params returns the parameters of every sub-layer.
+func (e *TransformerEncoder) Params() [][]float64 { return blockParams(e.Layers, Layer.Params) }
+
+// Grads returns the gradients aligned with Params.
+func (e *TransformerEncoder) Grads() [][]float64 { return blockParams(e.Layers, Layer.Grads) }
+
+// MarshalJSON encodes the sub-layers with their type names.
+func (e *TransformerEncoder) MarshalJSON() ([]byte, error) {
+	layers, err := marshalLayers(e.Layers)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(blockJSON{Layers: layers})
+}
+
+// UnmarshalJSON decodes a layer written by MarshalJSON.
+func (e *TransformerEncoder) UnmarshalJSON(b []byte) error {
+	var decoded blockJSON
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return err
+	}
+	layers, err := unmarshalLayers(decoded.Layers)
+	if err != nil {
+		return err
+	}
+	e.Layers = layers
+	return nil
+}
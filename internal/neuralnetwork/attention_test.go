@@ -0,0 +1,94 @@
+package neuralnetwork_test
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+
+	"go-neuralnetwork/internal/neuralnetwork"
+)
+
+func TestAttentionGradCheck(t *testing.T) {
+	seq, err := neuralnetwork.BuildSequential([]int{3, 2}, "timedense(4),posenc,attention(2,causal),transformer(2,6)",
+		[]string{"tanh"}, 2, "linear")
+	if err != nil {
+		t.Fatalf("Failed to build model: %v", err)
+	}
+	input := []float64{0.3, -0.5, 0.8, 0.1, -0.2, 0.6}
+	for _, result := range neuralnetwork.GradCheckSequential(seq, input, []float64{0.4, -0.3}, 1e-6) {
+		if result.MaxRelativeError > gradCheckTolerance {
+			t.Errorf("Layer %s: relative gradient error %e exceeds %e", result.Layer, result.MaxRelativeError, gradCheckTolerance)
+		}
+	}
+
+	encoded, err := json.Marshal(seq)
+	if err != nil {
+		t.Fatalf("Failed to encode model: %v", err)
+	}
+	var decoded neuralnetwork.Sequential
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Failed to decode model: %v", err)
+	}
+	if !reflect.DeepEqual(seq.Predict(input), decoded.Predict(input)) {
+		t.Errorf("Decoded model predicts differently from the original")
+	}
+
+	if _, err := neuralnetwork.BuildSequential([]int{3, 5}, "attention(2)", nil, 1, "linear"); err == nil {
+		t.Errorf("Expected an error when the heads do not divide the model dimension, got nil")
+	}
+}
+
+func TestTransformerSequenceCopy(t *testing.T) {
+	// Every sequence of four symbols from a three-symbol alphabet, one-hot encoded per step.
+	const seqLen, symbols = 4, 3
+	var inputs [][]float64
+	for n := 0; n < 81; n++ {
+		input := make([]float64, seqLen*symbols)
+		for pos, code := 0, n; pos < seqLen; pos, code = pos+1, code/symbols {
+			input[pos*symbols+code%symbols] = 1
+		}
+		inputs = append(inputs, input)
+	}
+
+	encoder, err := neuralnetwork.NewTransformerEncoder(seqLen, 8, 2, 16)
+	if err != nil {
+		t.Fatalf("Failed to build encoder: %v", err)
+	}
+	model, err := neuralnetwork.NewSequential("mse",
+		neuralnetwork.NewTimeDistributedDense(seqLen, symbols, 8),
+		neuralnetwork.NewPositionalEncoding(seqLen, 8),
+		encoder,
+		neuralnetwork.NewTimeDistributedDense(seqLen, 8, symbols))
+	if err != nil {
+		t.Fatalf("Failed to build model: %v", err)
+	}
+
+	progress := make(chan any)
+	go model.Train(inputs, inputs, 40, 0.02, 1e-4, progress)
+	for range progress {
+	}
+
+	correct := 0
+	for _, input := range inputs {
+		output := model.Predict(input)
+		copied := true
+		for pos := 0; pos < seqLen; pos++ {
+			best := 0
+			for s := 1; s < symbols; s++ {
+				if output[pos*symbols+s] > output[pos*symbols+best] {
+					best = s
+				}
+			}
+			if input[pos*symbols+best] != 1 {
+				copied = false
+			}
+		}
+		if copied {
+			correct++
+		}
+	}
+	if accuracy := float64(correct) / float64(len(inputs)); accuracy < 0.95 || math.IsNaN(accuracy) {
+		t.Errorf("Expected the sequences to be copied, got %.0f%% correct", accuracy*100)
+	}
+}
@@ -0,0 +1,471 @@
+package neuralnetwork
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BuildSequential builds a model from a comma-separated layer specification such as
+// "conv1d(8,3),maxpool1d(2),gap,16". A plain number is a dense layer of that width.
+// The supported layers are:
+//
+//	N                                  dense layer with N neurons
+//	conv1d(filters,kernel[,stride[,padding[,dilation]]])
+//	maxpool1d(size[,stride])           max pooling along the length
+//	avgpool1d(size[,stride])           average pooling along the length
+//	conv2d(filters,kernel[,stride[,padding]])
+//	maxpool2d(size[,stride])           max pooling over square windows
+//	flatten                            treat an image as a flat vector
+//	gap                                global average pooling of every channel
+//	rnn(units[,seq][,bptt])            simple recurrent layer
+//	lstm(units[,seq][,bptt])           LSTM layer
+//	gru(units[,seq][,bptt])            GRU layer
+//	timedense(units)                   dense layer applied to every step of a sequence
+//	posenc                             sinusoidal positional encoding
+//	attention(heads[,causal])          multi-head self-attention
+//	transformer(heads,ffdim)           transformer encoder block
+//	res(layers)                        residual connection around a nested specification
+//	cat(layers)                        DenseNet-style concatenation of input and nested output
+//	dropout(rate)                      dropout while training
+//	layernorm                          layer normalisation
+//
+// Recurrent and attention layers read a steps x features input and output the last hidden state, or
+// the hidden state of every step when "seq" is given. An integer bptt limits
+// backpropagation through time to chunks of that many steps. A flat input is read as a
+// sequence with one feature per step.
+//
+// A residual block adds its input to its output, through a learned projection when
+// the sizes differ; for example "32,res(32,32),cat(16)". Layers inside nested
+// specifications take their activations from the same list, in order.
+//
+// Every dense or convolutional layer is followed by the next activation from
+// activations; recurrent layers use their own activations. The model ends with a dense
+// layer of outputs neurons and outputActivation. inputShape describes one sample, for
+// example [features], [channels, length], [steps, features] or [channels, height, width].
+func BuildSequential(inputShape []int, spec string, activations []string, outputs int, outputActivation string) (*Sequential, error) {
+	b, err := buildHidden(inputShape, spec, activations)
+	if err != nil {
+		return nil, err
+	}
+	b.layers = append(b.layers, NewDense(b.size(), outputs))
+	activation, err := NewActivationLayer(outputActivation)
+	if err != nil {
+		return nil, err
+	}
+	b.layers = append(b.layers, activation)
+	return NewSequential("mse", b.layers...)
+}
+
+// HeadSpec describes one output head of a multi-task model.
+type HeadSpec struct {
+	Name       string
+	Outputs    int
+	Activation string
+	Loss       string
+	Weight     float64
+}
+
+// BuildMultiTask builds a model whose hidden layers, given in the syntax of
+// BuildSequential, are shared by several output heads. Each head is a dense layer
+// with its own activation, loss and loss weight; the model outputs and the targets
+// it is trained on are the heads' values concatenated in order.
+func BuildMultiTask(inputShape []int, spec string, activations []string, heads []HeadSpec) (*Sequential, error) {
+	b, err := buildHidden(inputShape, spec, activations)
+	if err != nil {
+		return nil, err
+	}
+	outputHeads := make([]*Head, len(heads))
+	for i, h := range heads {
+		activation, err := NewActivationLayer(h.Activation)
+		if err != nil {
+			return nil, fmt.Errorf("head %q: %w", h.Name, err)
+		}
+		outputHeads[i], err = NewHead(h.Name, h.Outputs, h.Loss, h.Weight, NewDense(b.size(), h.Outputs), activation)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return NewSequential("mse", append(b.layers, NewHeads(outputHeads...))...)
+}
+
+// buildHidden parses a layer specification into the hidden layers of a model, which
+// must use every one of activations.
+func buildHidden(inputShape []int, spec string, activations []string) (*builder, error) {
+	b, err := parseHidden(inputShape, spec, activations)
+	if err != nil {
+		return nil, err
+	}
+	if b.nextActivation != len(activations) {
+		return nil, fmt.Errorf("expected %d hidden activations, got %d", b.nextActivation, len(activations))
+	}
+	return b, nil
+}
+
+// parseHidden adds the layers of a specification to a new builder, which records in
+// nextActivation how many of activations they use.
+func parseHidden(inputShape []int, spec string, activations []string) (*builder, error) {
+	b := &builder{shape: append([]int(nil), inputShape...), activations: activations}
+	tokens, err := splitSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	for _, token := range tokens {
+		if err := b.add(token); err != nil {
+			return nil, fmt.Errorf("layer %q: %w", token, err)
+		}
+	}
+	return b, nil
+}
+
+// RepeatActivation returns activation once for every hidden layer of spec that takes an
+// activation, the list BuildSequential needs to use the same activation throughout.
+func RepeatActivation(inputShape []int, spec string, activation string) ([]string, error) {
+	// Every layer that takes an activation is at least one comma-separated part of spec.
+	activations := make([]string, strings.Count(spec, ",")+1)
+	for i := range activations {
+		activations[i] = activation
+	}
+	b, err := parseHidden(inputShape, spec, activations)
+	if err != nil {
+		return nil, err
+	}
+	return activations[:b.nextActivation], nil
+}
+
+// builder tracks the shape of the data flowing through the layers added so far.
+type builder struct {
+	shape          []int
+	layers         []Layer
+	activations    []string
+	nextActivation int
+}
+
+// size returns the number of values in one sample at the current point.
+func (b *builder) size() int {
+	size := 1
+	for _, dim := range b.shape {
+		size *= dim
+	}
+	return size
+}
+
+// sequence returns the current shape as steps x features, treating a flat vector as
+// one feature per step.
+func (b *builder) sequence() (int, int) {
+	if len(b.shape) == 1 {
+		return b.shape[0], 1
+	}
+	return b.shape[0], b.size() / b.shape[0]
+}
+
+// channels returns the current shape as channels x length, treating a flat vector as one channel.
+func (b *builder) channels() (int, int) {
+	if len(b.shape) == 1 {
+		return 1, b.shape[0]
+	}
+	return b.shape[0], b.size() / b.shape[0]
+}
+
+// activate appends the next hidden activation.
+func (b *builder) activate() error {
+	if b.nextActivation >= len(b.activations) {
+		return fmt.Errorf("missing activation function")
+	}
+	activation, err := NewActivationLayer(strings.TrimSpace(b.activations[b.nextActivation]))
+	if err != nil {
+		return err
+	}
+	b.nextActivation++
+	b.layers = append(b.layers, activation)
+	return nil
+}
+
+func (b *builder) add(token string) error {
+	name, args, err := parseToken(token)
+	if err != nil {
+		return err
+	}
+	if width, err := strconv.Atoi(name); err == nil {
+		if width < 1 || len(args) != 0 {
+			return fmt.Errorf("invalid dense layer")
+		}
+		b.layers = append(b.layers, NewDense(b.size(), width))
+		b.shape = []int{width}
+		return b.activate()
+	}
+
+	switch name {
+	case "conv1d":
+		ints, err := intArgs(args, 2, []int{1, 0, 1})
+		if err != nil {
+			return err
+		}
+		if ints[2] < 1 || ints[3] < 0 || ints[4] < 1 {
+			return fmt.Errorf("stride and dilation must be positive and padding non-negative")
+		}
+		channels, length := b.channels()
+		conv := NewConv1D(channels, length, ints[0], ints[1], ints[2], ints[3], ints[4])
+		if conv.OutputLength() < 1 {
+			return fmt.Errorf("kernel does not fit an input of length %d", length)
+		}
+		b.layers = append(b.layers, conv)
+		b.shape = []int{ints[0], conv.OutputLength()}
+		return b.activate()
+	case "maxpool1d", "avgpool1d":
+		ints, err := intArgs(args, 1, []int{0})
+		if err != nil {
+			return err
+		}
+		if ints[1] < 0 {
+			return fmt.Errorf("stride must be positive")
+		}
+		if ints[1] == 0 {
+			ints[1] = ints[0]
+		}
+		channels, length := b.channels()
+		if ints[0] > length {
+			return fmt.Errorf("pool does not fit an input of length %d", length)
+		}
+		var outLength int
+		if name == "maxpool1d" {
+			pool, err := NewMaxPool1D(channels, length, ints[0], ints[1])
+			if err != nil {
+				return err
+			}
+			b.layers = append(b.layers, pool)
+			outLength = pool.OutputLength()
+		} else {
+			pool, err := NewAvgPool1D(channels, length, ints[0], ints[1])
+			if err != nil {
+				return err
+			}
+			b.layers = append(b.layers, pool)
+			outLength = pool.OutputLength()
+		}
+		b.shape = []int{channels, outLength}
+	case "conv2d":
+		ints, err := intArgs(args, 2, []int{1, 0})
+		if err != nil {
+			return err
+		}
+		if ints[2] < 1 || ints[3] < 0 {
+			return fmt.Errorf("stride must be positive and padding non-negative")
+		}
+		if len(b.shape) != 3 {
+			return fmt.Errorf("expected a channels x height x width input, got shape %v", b.shape)
+		}
+		conv := NewConv2D(b.shape[0], b.shape[1], b.shape[2], ints[0], ints[1], ints[2], ints[3])
+		height, width := conv.OutputSize()
+		if height < 1 || width < 1 {
+			return fmt.Errorf("kernel does not fit a %dx%d input", b.shape[1], b.shape[2])
+		}
+		b.layers = append(b.layers, conv)
+		b.shape = []int{ints[0], height, width}
+		return b.activate()
+	case "maxpool2d":
+		ints, err := intArgs(args, 1, []int{0})
+		if err != nil {
+			return err
+		}
+		if ints[1] < 0 {
+			return fmt.Errorf("stride must be positive")
+		}
+		if ints[1] == 0 {
+			ints[1] = ints[0]
+		}
+		if len(b.shape) != 3 {
+			return fmt.Errorf("expected a channels x height x width input, got shape %v", b.shape)
+		}
+		if ints[0] > b.shape[1] || ints[0] > b.shape[2] {
+			return fmt.Errorf("pool does not fit a %dx%d input", b.shape[1], b.shape[2])
+		}
+		pool, err := NewMaxPool2D(b.shape[0], b.shape[1], b.shape[2], ints[0], ints[1])
+		if err != nil {
+			return err
+		}
+		height, width := pool.OutputSize()
+		b.layers = append(b.layers, pool)
+		b.shape = []int{b.shape[0], height, width}
+	case "flatten":
+		b.layers = append(b.layers, NewFlatten(b.size()))
+		b.shape = []int{b.size()}
+	case "gap":
+		channels, length := b.channels()
+		b.layers = append(b.layers, NewGlobalAveragePooling(channels, length))
+		b.shape = []int{channels}
+	case "rnn", "lstm", "gru":
+		if len(args) < 1 || len(args) > 3 {
+			return fmt.Errorf("expected between 1 and 3 arguments, got %d", len(args))
+		}
+		units, err := strconv.Atoi(args[0])
+		if err != nil || units < 1 {
+			return fmt.Errorf("invalid number of units %q", args[0])
+		}
+		returnSequences, bpttSteps := false, 0
+		for _, arg := range args[1:] {
+			if strings.EqualFold(arg, "seq") {
+				returnSequences = true
+				continue
+			}
+			if bpttSteps, err = strconv.Atoi(arg); err != nil || bpttSteps < 1 {
+				return fmt.Errorf("invalid argument %q", arg)
+			}
+		}
+		steps, features := b.sequence()
+		switch name {
+		case "rnn":
+			b.layers = append(b.layers, NewSimpleRNN(features, units, steps, returnSequences, bpttSteps))
+		case "lstm":
+			b.layers = append(b.layers, NewLSTM(features, units, steps, returnSequences, bpttSteps))
+		default:
+			b.layers = append(b.layers, NewGRU(features, units, steps, returnSequences, bpttSteps))
+		}
+		if returnSequences {
+			b.shape = []int{steps, units}
+		} else {
+			b.shape = []int{units}
+		}
+	case "timedense":
+		ints, err := intArgs(args, 1, nil)
+		if err != nil {
+			return err
+		}
+		steps, features := b.sequence()
+		b.layers = append(b.layers, NewTimeDistributedDense(steps, features, ints[0]))
+		b.shape = []int{steps, ints[0]}
+		return b.activate()
+	case "posenc":
+		steps, features := b.sequence()
+		b.layers = append(b.layers, NewPositionalEncoding(steps, features))
+	case "attention":
+		if len(args) < 1 || len(args) > 2 || (len(args) == 2 && !strings.EqualFold(args[1], "causal")) {
+			return fmt.Errorf("expected attention(heads[,causal])")
+		}
+		ints, err := intArgs(args[:1], 1, nil)
+		if err != nil {
+			return err
+		}
+		steps, features := b.sequence()
+		attention, err := NewMultiHeadAttention(steps, features, ints[0], len(args) == 2)
+		if err != nil {
+			return err
+		}
+		b.layers = append(b.layers, attention)
+		b.shape = []int{steps, features}
+	case "transformer":
+		ints, err := intArgs(args, 2, nil)
+		if err != nil {
+			return err
+		}
+		steps, features := b.sequence()
+		encoder, err := NewTransformerEncoder(steps, features, ints[0], ints[1])
+		if err != nil {
+			return err
+		}
+		b.layers = append(b.layers, encoder)
+		b.shape = []int{steps, features}
+	case "res", "cat":
+		if len(args) == 0 {
+			return fmt.Errorf("expected nested layers")
+		}
+		block := &builder{shape: b.shape, activations: b.activations, nextActivation: b.nextActivation}
+		for _, arg := range args {
+			if err := block.add(arg); err != nil {
+				return fmt.Errorf("layer %q: %w", arg, err)
+			}
+		}
+		b.nextActivation = block.nextActivation
+		if name == "res" {
+			b.layers = append(b.layers, NewResidual(b.size(), block.size(), block.layers...))
+			b.shape = block.shape
+		} else {
+			b.layers = append(b.layers, NewDenseConcat(block.layers...))
+			b.shape = []int{b.size() + block.size()}
+		}
+	case "dropout":
+		if len(args) != 1 {
+			return fmt.Errorf("expected a dropout rate")
+		}
+		rate, err := strconv.ParseFloat(args[0], 64)
+		if err != nil || rate < 0 || rate >= 1 {
+			return fmt.Errorf("invalid dropout rate %q", args[0])
+		}
+		b.layers = append(b.layers, NewDropout(rate))
+	case "layernorm":
+		b.layers = append(b.layers, NewLayerNorm(b.shape[len(b.shape)-1]))
+	default:
+		return fmt.Errorf("unknown layer")
+	}
+	return nil
+}
+
+// splitSpec splits a specification on the commas that are not inside parentheses.
+func splitSpec(spec string) ([]string, error) {
+	var tokens []string
+	depth, start := 0, 0
+	for i, r := range spec {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses in %q", spec)
+			}
+		case ',':
+			if depth == 0 {
+				tokens = append(tokens, strings.TrimSpace(spec[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses in %q", spec)
+	}
+	if last := strings.TrimSpace(spec[start:]); last != "" || len(tokens) > 0 {
+		tokens = append(tokens, last)
+	}
+	for _, token := range tokens {
+		if token == "" {
+			return nil, fmt.Errorf("empty layer in %q", spec)
+		}
+	}
+	return tokens, nil
+}
+
+// parseToken splits "name(a,b)" into its name and arguments.
+func parseToken(token string) (string, []string, error) {
+	open := strings.Index(token, "(")
+	if open < 0 {
+		return strings.ToLower(token), nil, nil
+	}
+	if !strings.HasSuffix(token, ")") {
+		return "", nil, fmt.Errorf("expected closing parenthesis")
+	}
+	name := strings.ToLower(strings.TrimSpace(token[:open]))
+	args, err := splitSpec(token[open+1 : len(token)-1])
+	return name, args, err
+}
+
+// intArgs parses at least required integer arguments, filling optional ones from defaults.
+func intArgs(args []string, required int, defaults []int) ([]int, error) {
+	if len(args) < required || len(args) > required+len(defaults) {
+		return nil, fmt.Errorf("expected between %d and %d arguments, got %d", required, required+len(defaults), len(args))
+	}
+	values := make([]int, required+len(defaults))
+	copy(values[required:], defaults)
+	for i, arg := range args {
+		value, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid argument %q", arg)
+		}
+		values[i] = value
+	}
+	for i, value := range values[:required] {
+		if value < 1 {
+			return nil, fmt.Errorf("argument %d must be positive", i+1)
+		}
+	}
+	return values, nil
+}
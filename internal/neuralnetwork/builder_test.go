@@ -0,0 +1,64 @@
+package neuralnetwork_test
+
+import (
+	"reflect"
+	"testing"
+
+	"go-neuralnetwork/internal/neuralnetwork"
+)
+
+func TestBuildSequential(t *testing.T) {
+	seq, err := neuralnetwork.BuildSequential([]int{4}, "8, dropout(0.2), layernorm, 4", []string{"relu", "tanh"}, 3, "sigmoid")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var types []string
+	for _, layer := range seq.Layers {
+		types = append(types, layer.Type())
+	}
+	expected := []string{"dense", "activation", "dropout", "layernorm", "dense", "activation", "dense", "activation"}
+	if !reflect.DeepEqual(types, expected) {
+		t.Errorf("Expected layers %v, got %v", expected, types)
+	}
+	if got := len(seq.Predict([]float64{1, 2, 3, 4})); got != 3 {
+		t.Errorf("Expected 3 outputs, got %d", got)
+	}
+
+	invalid := []struct {
+		name        string
+		spec        string
+		activations []string
+	}{
+		{"unknown_layer", "foo(3)", nil},
+		{"too_few_activations", "8,8", []string{"relu"}},
+		{"too_many_activations", "8", []string{"relu", "relu"}},
+		{"kernel_too_large", "conv1d(2,9)", []string{"relu"}},
+		{"max_pool_too_large", "maxpool1d(5)", nil},
+		{"avg_pool_too_large", "avgpool1d(5,1)", nil},
+		{"unbalanced", "conv1d(2,3", []string{"relu"}},
+		{"bad_dropout", "dropout(1.5)", nil},
+	}
+	for _, tc := range invalid {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := neuralnetwork.BuildSequential([]int{4}, tc.spec, tc.activations, 1, "linear"); err == nil {
+				t.Errorf("Expected an error for %q, got nil", tc.spec)
+			}
+		})
+	}
+}
+
+func TestRepeatActivation(t *testing.T) {
+	activations, err := neuralnetwork.RepeatActivation([]int{3}, "6,res(6,6),res(4),cat(3),layernorm", "relu")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := []string{"relu", "relu", "relu", "relu", "relu"}; !reflect.DeepEqual(activations, expected) {
+		t.Errorf("Expected %v, got %v", expected, activations)
+	}
+	if _, err := neuralnetwork.BuildSequential([]int{3}, "6,res(6,6),res(4),cat(3),layernorm", activations, 2, "linear"); err != nil {
+		t.Errorf("Expected the activations to build the model, got %v", err)
+	}
+	if _, err := neuralnetwork.RepeatActivation([]int{3}, "6", "nope"); err == nil {
+		t.Errorf("Expected an error for an unknown activation, got nil")
+	}
+}
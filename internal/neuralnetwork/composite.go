@@ -0,0 +1,195 @@
+package neuralnetwork
+
+import "encoding/json"
+
+// blockJSON is the serialised form of a composite layer: its nested layers and the
+// projection of a residual connection, if any.
+type blockJSON struct {
+	Layers     []layerJSON `json:"layers"`
+	Projection *Dense      `json:"projection,omitempty"`
+}
+
+// forwardBlock runs the input through a list of layers.
+func forwardBlock(layers []Layer, input []float64, training bool) []float64 {
+	output := input
+	for _, layer := range layers {
+		output = layer.Forward(output, training)
+	}
+	return output
+}
+
+// backwardBlock propagates a gradient through a list of layers in reverse order.
+func backwardBlock(layers []Layer, outputGrad []float64) []float64 {
+	grad := outputGrad
+	for i := len(layers) - 1; i >= 0; i-- {
+		grad = layers[i].Backward(grad)
+	}
+	return grad
+}
+
+// blockParams collects the parameters or gradients of a list of layers.
+func blockParams(layers []Layer, get func(Layer) [][]float64) [][]float64 {
+	var params [][]float64
+	for _, layer := range layers {
+		params = append(params, get(layer)...)
+	}
+	return params
+}
+
+// Residual adds the input of a block of layers to its output. When the block changes
+// the number of values, the input is passed through the Dense Projection first.
+type Residual struct {
+	Layers     []Layer
+	Projection *Dense
+}
+
+// NewResidual wraps layers that turn inputSize values into outputSize values in a
+// residual connection, adding a projection when the sizes differ.
+func NewResidual(inputSize, outputSize int, layers ...Layer) *Residual {
+	r := &Residual{Layers: layers}
+	if inputSize != outputSize {
+		r.Projection = NewDense(inputSize, outputSize)
+	}
+	return r
+}
+
+func (r *Residual) initialize() error {
+	if r.Projection != nil {
+		return r.Projection.initialize()
+	}
+	return nil
+}
+
+// Type returns the registered name of the layer.
+func (r *Residual) Type() string { return "residual" }
+
+// Forward returns block(input) + input, or block(input) + Projection(input).
+func (r *Residual) Forward(input []float64, training bool) []float64 {
+	output := forwardBlock(r.Layers, input, training)
+	shortcut := input
+	if r.Projection != nil {
+		shortcut = r.Projection.Forward(input, training)
+	}
+	sum := make([]float64, len(output))
+	for i := range output {
+		sum[i] = output[i] + shortcut[i]
+	}
+	return sum
+}
+
+// Backward sends the gradient through both the block and the shortcut and adds the results.
+func (r *Residual) Backward(outputGrad []float64) []float64 {
+	blockGrad := backwardBlock(r.Layers, outputGrad)
+	shortcutGrad := outputGrad
+	if r.Projection != nil {
+		shortcutGrad = r.Projection.Backward(outputGrad)
+	}
+	inputGrad := make([]float64, len(blockGrad))
+	for i := range inputGrad {
+		inputGrad[i] = blockGrad[i] + shortcutGrad[i]
+	}
+	return inputGrad
+}
+
+// Params returns the parameters of the block followed by those of the projection.
+func (r *Residual) Params() [][]float64 {
+	params := blockParams(r.Layers, Layer.Params)
+	if r.Projection != nil {
+		params = append(params, r.Projection.Params()...)
+	}
+	return params
+}
+
+// Grads returns the gradients aligned with Params.
+func (r *Residual) Grads() [][]float64 {
+	grads := blockParams(r.Layers, Layer.Grads)
+	if r.Projection != nil {
+		grads = append(grads, r.Projection.Grads()...)
+	}
+	return grads
+}
+
+// MarshalJSON encodes the nested layers with their type names.
+func (r *Residual) MarshalJSON() ([]byte, error) {
+	layers, err := marshalLayers(r.Layers)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(blockJSON{Layers: layers, Projection: r.Projection})
+}
+
+// UnmarshalJSON decodes a layer written by MarshalJSON.
+func (r *Residual) UnmarshalJSON(b []byte) error {
+	var decoded blockJSON
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return err
+	}
+	layers, err := unmarshalLayers(decoded.Layers)
+	if err != nil {
+		return err
+	}
+	r.Layers, r.Projection = layers, decoded.Projection
+	return nil
+}
+
+// DenseConcat is a DenseNet-style connection: it outputs its input followed by the
+// output of its block of layers, so later layers see the features of every earlier one.
+type DenseConcat struct {
+	Layers []Layer
+
+	inputSize int
+}
+
+// NewDenseConcat wraps layers in a concatenating connection.
+func NewDenseConcat(layers ...Layer) *DenseConcat {
+	return &DenseConcat{Layers: layers}
+}
+
+// Type returns the registered name of the layer.
+func (c *DenseConcat) Type() string { return "denseconcat" }
+
+// Forward returns the input with the block's output appended.
+func (c *DenseConcat) Forward(input []float64, training bool) []float64 {
+	c.inputSize = len(input)
+	output := forwardBlock(c.Layers, input, training)
+	return append(append(make([]float64, 0, len(input)+len(output)), input...), output...)
+}
+
+// Backward splits the gradient between the passed-through input and the block.
+func (c *DenseConcat) Backward(outputGrad []float64) []float64 {
+	blockGrad := backwardBlock(c.Layers, outputGrad[c.inputSize:])
+	inputGrad := make([]float64, c.inputSize)
+	for i := range inputGrad {
+		inputGrad[i] = blockGrad[i] + outputGrad[i]
+	}
+	return inputGrad
+}
+
+// Params returns the parameters of the block.
+func (c *DenseConcat) Params() [][]float64 { return blockParams(c.Layers, Layer.Params) }
+
+// Grads returns the gradients aligned with Params.
+func (c *DenseConcat) Grads() [][]float64 { return blockParams(c.Layers, Layer.Grads) }
+
+// MarshalJSON encodes the nested layers with their type names.
+func (c *DenseConcat) MarshalJSON() ([]byte, error) {
+	layers, err := marshalLayers(c.Layers)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(blockJSON{Layers: layers})
+}
+
+// UnmarshalJSON decodes a layer written by MarshalJSON.
+func (c *DenseConcat) UnmarshalJSON(b []byte) error {
+	var decoded blockJSON
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return err
+	}
+	layers, err := unmarshalLayers(decoded.Layers)
+	if err != nil {
+		return err
+	}
+	c.Layers = layers
+	return nil
+}
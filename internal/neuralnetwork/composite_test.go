@@ -0,0 +1,65 @@
+package neuralnetwork_test
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+
+	"go-neuralnetwork/internal/neuralnetwork"
+)
+
+func TestResidualAndConcat(t *testing.T) {
+	seq, err := neuralnetwork.BuildSequential([]int{3}, "6,res(6,6),res(4),cat(3),layernorm",
+		[]string{"tanh", "tanh", "sigmoid", "tanh", "tanh"}, 2, "linear")
+	if err != nil {
+		t.Fatalf("Failed to build model: %v", err)
+	}
+	var types []string
+	for _, layer := range seq.Layers {
+		types = append(types, layer.Type())
+	}
+	expected := []string{"dense", "activation", "residual", "residual", "denseconcat", "layernorm", "dense", "activation"}
+	if !reflect.DeepEqual(types, expected) {
+		t.Errorf("Expected layers %v, got %v", expected, types)
+	}
+	if seq.Layers[2].(*neuralnetwork.Residual).Projection != nil {
+		t.Errorf("Expected no projection when the block keeps the width")
+	}
+	if seq.Layers[3].(*neuralnetwork.Residual).Projection == nil {
+		t.Errorf("Expected a projection when the block changes the width")
+	}
+
+	input := []float64{0.2, -0.7, 0.4}
+	for _, result := range neuralnetwork.GradCheckSequential(seq, input, []float64{0.5, -0.1}, 1e-6) {
+		if result.MaxRelativeError > gradCheckTolerance {
+			t.Errorf("Layer %s: relative gradient error %e exceeds %e", result.Layer, result.MaxRelativeError, gradCheckTolerance)
+		}
+	}
+
+	encoded, err := json.Marshal(seq)
+	if err != nil {
+		t.Fatalf("Failed to encode model: %v", err)
+	}
+	var decoded neuralnetwork.Sequential
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Failed to decode model: %v", err)
+	}
+	if !reflect.DeepEqual(seq.Predict(input), decoded.Predict(input)) {
+		t.Errorf("Decoded model predicts differently from the original")
+	}
+
+	// Training must update the parameters of nested layers through Step.
+	before := decoded.Predict(input)
+	output := decoded.Forward(input, true)
+	_, grad := decoded.ComputeLoss(output, []float64{0.5, -0.1})
+	decoded.Backward(grad)
+	decoded.Step(0.1)
+	if after := decoded.Predict(input); math.Abs(after[0]-before[0]) < 1e-12 {
+		t.Errorf("Expected a training step to change the prediction")
+	}
+
+	if _, err := neuralnetwork.BuildSequential([]int{3}, "res()", nil, 1, "linear"); err == nil {
+		t.Errorf("Expected an error for an empty residual block, got nil")
+	}
+}
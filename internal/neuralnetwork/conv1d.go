@@ -0,0 +1,126 @@
+package neuralnetwork
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Conv1D is a one-dimensional convolution over inputs laid out channel by channel:
+// InChannels blocks of InLength values. The output has the same layout with Filters
+// channels of OutputLength values.
+type Conv1D struct {
+	InChannels int         `json:"inChannels"`
+	InLength   int         `json:"inLength"`
+	Filters    int         `json:"filters"`
+	KernelSize int         `json:"kernelSize"`
+	Stride     int         `json:"stride"`
+	Padding    int         `json:"padding"`
+	Dilation   int         `json:"dilation"`
+	Weights    [][]float64 `json:"weights"`
+	Biases     []float64   `json:"biases"`
+
+	weightGrads [][]float64
+	biasGrads   []float64
+	input       []float64
+}
+
+// NewConv1D creates a convolution with He-initialized kernels. Each row of Weights holds
+// one filter, stored as InChannels consecutive kernels of KernelSize values.
+func NewConv1D(inChannels, inLength, filters, kernelSize, stride, padding, dilation int) *Conv1D {
+	c := &Conv1D{
+		InChannels: inChannels,
+		InLength:   inLength,
+		Filters:    filters,
+		KernelSize: kernelSize,
+		Stride:     stride,
+		Padding:    padding,
+		Dilation:   dilation,
+		Weights:    newMatrix(filters, inChannels*kernelSize),
+		Biases:     make([]float64, filters),
+	}
+	// He initialization for weights
+	heInit := math.Sqrt(2.0 / float64(inChannels*kernelSize))
+	for i := range c.Weights {
+		for j := range c.Weights[i] {
+			c.Weights[i][j] = rand.NormFloat64() * heInit
+		}
+	}
+	c.initialize()
+	return c
+}
+
+func (c *Conv1D) initialize() error {
+	c.weightGrads = newMatrix(c.Filters, c.InChannels*c.KernelSize)
+	c.biasGrads = make([]float64, c.Filters)
+	return nil
+}
+
+// OutputLength returns the number of positions per output channel.
+func (c *Conv1D) OutputLength() int {
+	return (c.InLength+2*c.Padding-c.Dilation*(c.KernelSize-1)-1)/c.Stride + 1
+}
+
+// Type returns the registered name of the layer.
+func (c *Conv1D) Type() string { return "conv1d" }
+
+// inputIndex returns the input position read by kernel tap k at output position o,
+// or -1 when it falls in the zero padding.
+func (c *Conv1D) inputIndex(o, k int) int {
+	pos := o*c.Stride - c.Padding + k*c.Dilation
+	if pos < 0 || pos >= c.InLength {
+		return -1
+	}
+	return pos
+}
+
+// Forward slides every filter across the input.
+func (c *Conv1D) Forward(input []float64, training bool) []float64 {
+	c.input = input
+	outLength := c.OutputLength()
+	output := make([]float64, c.Filters*outLength)
+	for f := 0; f < c.Filters; f++ {
+		for o := 0; o < outLength; o++ {
+			sum := c.Biases[f]
+			for ch := 0; ch < c.InChannels; ch++ {
+				for k := 0; k < c.KernelSize; k++ {
+					if pos := c.inputIndex(o, k); pos >= 0 {
+						sum += c.Weights[f][ch*c.KernelSize+k] * input[ch*c.InLength+pos]
+					}
+				}
+			}
+			output[f*outLength+o] = sum
+		}
+	}
+	return output
+}
+
+// Backward accumulates kernel and bias gradients and returns the input gradient.
+func (c *Conv1D) Backward(outputGrad []float64) []float64 {
+	outLength := c.OutputLength()
+	inputGrad := make([]float64, len(c.input))
+	for f := 0; f < c.Filters; f++ {
+		for o := 0; o < outLength; o++ {
+			grad := outputGrad[f*outLength+o]
+			c.biasGrads[f] += grad
+			for ch := 0; ch < c.InChannels; ch++ {
+				for k := 0; k < c.KernelSize; k++ {
+					if pos := c.inputIndex(o, k); pos >= 0 {
+						c.weightGrads[f][ch*c.KernelSize+k] += grad * c.input[ch*c.InLength+pos]
+						inputGrad[ch*c.InLength+pos] += grad * c.Weights[f][ch*c.KernelSize+k]
+					}
+				}
+			}
+		}
+	}
+	return inputGrad
+}
+
+// Params returns the filters followed by the biases.
+func (c *Conv1D) Params() [][]float64 {
+	return append(append([][]float64{}, c.Weights...), c.Biases)
+}
+
+// Grads returns the gradients aligned with Params.
+func (c *Conv1D) Grads() [][]float64 {
+	return append(append([][]float64{}, c.weightGrads...), c.biasGrads)
+}
@@ -0,0 +1,99 @@
+package neuralnetwork_test
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+
+	"go-neuralnetwork/internal/neuralnetwork"
+)
+
+func TestConv1DForward(t *testing.T) {
+	// Two input channels of length 4, one filter of size 2 with dilation 2 and padding 1.
+	conv := neuralnetwork.NewConv1D(2, 4, 1, 2, 1, 1, 2)
+	conv.Weights = [][]float64{{1, 2, 3, 4}}
+	conv.Biases = []float64{0.5}
+
+	input := []float64{1, 2, 3, 4, 10, 20, 30, 40}
+	if conv.OutputLength() != 4 {
+		t.Fatalf("Expected output length 4, got %d", conv.OutputLength())
+	}
+
+	// Output position o reads input positions o-1 and o+1 of each channel.
+	expected := []float64{
+		0.5 + 2*2 + 4*20,
+		0.5 + 1*1 + 2*3 + 3*10 + 4*30,
+		0.5 + 1*2 + 2*4 + 3*20 + 4*40,
+		0.5 + 1*3 + 3*30,
+	}
+	output := conv.Forward(input, false)
+	for i := range expected {
+		if math.Abs(output[i]-expected[i]) > floatTolerance {
+			t.Errorf("Output %d: expected %f, got %f", i, expected[i], output[i])
+		}
+	}
+}
+
+func TestPooling1D(t *testing.T) {
+	input := []float64{1, 5, 2, 8, 3, 3, 9, 0}
+
+	maxPool, err := neuralnetwork.NewMaxPool1D(2, 4, 2, 2)
+	if err != nil {
+		t.Fatalf("Failed to create MaxPool1D: %v", err)
+	}
+	if got := maxPool.Forward(input, false); !reflect.DeepEqual(got, []float64{5, 8, 3, 9}) {
+		t.Errorf("MaxPool1D: expected [5 8 3 9], got %v", got)
+	}
+	if got := maxPool.Backward([]float64{1, 2, 3, 4}); !reflect.DeepEqual(got, []float64{0, 1, 0, 2, 3, 0, 4, 0}) {
+		t.Errorf("MaxPool1D backward: expected gradient routed to maxima, got %v", got)
+	}
+
+	avgPool, err := neuralnetwork.NewAvgPool1D(2, 4, 2, 2)
+	if err != nil {
+		t.Fatalf("Failed to create AvgPool1D: %v", err)
+	}
+	if got := avgPool.Forward(input, false); !reflect.DeepEqual(got, []float64{3, 5, 3, 4.5}) {
+		t.Errorf("AvgPool1D: expected [3 5 3 4.5], got %v", got)
+	}
+
+	gap := neuralnetwork.NewGlobalAveragePooling(2, 4)
+	if got := gap.Forward(input, false); !reflect.DeepEqual(got, []float64{4, 3.75}) {
+		t.Errorf("GlobalAveragePooling: expected [4 3.75], got %v", got)
+	}
+
+	if _, err := neuralnetwork.NewMaxPool1D(2, 3, 4, 4); err == nil {
+		t.Errorf("Expected an error for a pool larger than the input")
+	}
+}
+
+func TestConv1DGradCheck(t *testing.T) {
+	seq, err := neuralnetwork.BuildSequential([]int{2, 10},
+		"conv1d(3,3,1,1),maxpool1d(2),conv1d(2,2,1,0,2),avgpool1d(2,1),gap",
+		[]string{"tanh", "sigmoid"}, 2, "linear")
+	if err != nil {
+		t.Fatalf("Failed to build model: %v", err)
+	}
+
+	input := make([]float64, 20)
+	for i := range input {
+		input[i] = math.Sin(float64(i) * 0.7)
+	}
+	for _, result := range neuralnetwork.GradCheckSequential(seq, input, []float64{0.5, -0.5}, 1e-6) {
+		if result.MaxRelativeError > gradCheckTolerance {
+			t.Errorf("Layer %s: relative gradient error %e exceeds %e", result.Layer, result.MaxRelativeError, gradCheckTolerance)
+		}
+	}
+
+	encoded, err := json.Marshal(seq)
+	if err != nil {
+		t.Fatalf("Failed to encode model: %v", err)
+	}
+	var decoded neuralnetwork.Sequential
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Failed to decode model: %v", err)
+	}
+	if !reflect.DeepEqual(seq.Predict(input), decoded.Predict(input)) {
+		t.Errorf("Decoded model predicts differently from the original")
+	}
+}
@@ -0,0 +1,232 @@
+package neuralnetwork
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// Conv2D is a two-dimensional convolution over channel-first images: InChannels
+// blocks of Height x Width values stored row by row. The output has the same layout
+// with Filters channels.
+type Conv2D struct {
+	InChannels int         `json:"inChannels"`
+	Height     int         `json:"height"`
+	Width      int         `json:"width"`
+	Filters    int         `json:"filters"`
+	KernelSize int         `json:"kernelSize"`
+	Stride     int         `json:"stride"`
+	Padding    int         `json:"padding"`
+	Weights    [][]float64 `json:"weights"`
+	Biases     []float64   `json:"biases"`
+
+	weightGrads [][]float64
+	biasGrads   []float64
+	input       []float64
+}
+
+// NewConv2D creates a convolution with square He-initialized kernels. Each row of
+// Weights holds one filter as InChannels consecutive KernelSize x KernelSize kernels.
+func NewConv2D(inChannels, height, width, filters, kernelSize, stride, padding int) *Conv2D {
+	c := &Conv2D{
+		InChannels: inChannels,
+		Height:     height,
+		Width:      width,
+		Filters:    filters,
+		KernelSize: kernelSize,
+		Stride:     stride,
+		Padding:    padding,
+		Weights:    newMatrix(filters, inChannels*kernelSize*kernelSize),
+		Biases:     make([]float64, filters),
+	}
+	// He initialization for weights
+	heInit := math.Sqrt(2.0 / float64(inChannels*kernelSize*kernelSize))
+	for i := range c.Weights {
+		for j := range c.Weights[i] {
+			c.Weights[i][j] = rand.NormFloat64() * heInit
+		}
+	}
+	c.initialize()
+	return c
+}
+
+func (c *Conv2D) initialize() error {
+	c.weightGrads = newMatrix(c.Filters, c.InChannels*c.KernelSize*c.KernelSize)
+	c.biasGrads = make([]float64, c.Filters)
+	return nil
+}
+
+// OutputSize returns the height and width of every output channel.
+func (c *Conv2D) OutputSize() (int, int) {
+	return (c.Height+2*c.Padding-c.KernelSize)/c.Stride + 1, (c.Width+2*c.Padding-c.KernelSize)/c.Stride + 1
+}
+
+// Type returns the registered name of the layer.
+func (c *Conv2D) Type() string { return "conv2d" }
+
+// forEachTap calls fn for every kernel tap of output position (oy, ox) that falls
+// inside the image, with the index of the weight and of the input value it multiplies.
+func (c *Conv2D) forEachTap(oy, ox int, fn func(weight, input int)) {
+	for ch := 0; ch < c.InChannels; ch++ {
+		for ky := 0; ky < c.KernelSize; ky++ {
+			y := oy*c.Stride - c.Padding + ky
+			if y < 0 || y >= c.Height {
+				continue
+			}
+			for kx := 0; kx < c.KernelSize; kx++ {
+				x := ox*c.Stride - c.Padding + kx
+				if x < 0 || x >= c.Width {
+					continue
+				}
+				fn((ch*c.KernelSize+ky)*c.KernelSize+kx, (ch*c.Height+y)*c.Width+x)
+			}
+		}
+	}
+}
+
+// Forward slides every filter across the image.
+func (c *Conv2D) Forward(input []float64, training bool) []float64 {
+	c.input = input
+	outHeight, outWidth := c.OutputSize()
+	output := make([]float64, c.Filters*outHeight*outWidth)
+	for f := 0; f < c.Filters; f++ {
+		for oy := 0; oy < outHeight; oy++ {
+			for ox := 0; ox < outWidth; ox++ {
+				sum := c.Biases[f]
+				c.forEachTap(oy, ox, func(w, i int) {
+					sum += c.Weights[f][w] * input[i]
+				})
+				output[(f*outHeight+oy)*outWidth+ox] = sum
+			}
+		}
+	}
+	return output
+}
+
+// Backward accumulates kernel and bias gradients and returns the input gradient.
+func (c *Conv2D) Backward(outputGrad []float64) []float64 {
+	outHeight, outWidth := c.OutputSize()
+	inputGrad := make([]float64, len(c.input))
+	for f := 0; f < c.Filters; f++ {
+		for oy := 0; oy < outHeight; oy++ {
+			for ox := 0; ox < outWidth; ox++ {
+				grad := outputGrad[(f*outHeight+oy)*outWidth+ox]
+				c.biasGrads[f] += grad
+				c.forEachTap(oy, ox, func(w, i int) {
+					c.weightGrads[f][w] += grad * c.input[i]
+					inputGrad[i] += grad * c.Weights[f][w]
+				})
+			}
+		}
+	}
+	return inputGrad
+}
+
+// Params returns the filters followed by the biases.
+func (c *Conv2D) Params() [][]float64 {
+	return append(append([][]float64{}, c.Weights...), c.Biases)
+}
+
+// Grads returns the gradients aligned with Params.
+func (c *Conv2D) Grads() [][]float64 {
+	return append(append([][]float64{}, c.weightGrads...), c.biasGrads)
+}
+
+// MaxPool2D keeps the largest value of each square window of every channel.
+type MaxPool2D struct {
+	Channels int `json:"channels"`
+	Height   int `json:"height"`
+	Width    int `json:"width"`
+	PoolSize int `json:"poolSize"`
+	Stride   int `json:"stride"`
+
+	argmax []int
+}
+
+// NewMaxPool2D creates a max pooling layer for channels x height x width inputs. The
+// pool must fit both the height and the width; a larger one would still give an output
+// size of 1 by integer division.
+func NewMaxPool2D(channels, height, width, poolSize, stride int) (*MaxPool2D, error) {
+	if poolSize < 1 || stride < 1 {
+		return nil, fmt.Errorf("pool size and stride must be positive")
+	}
+	if poolSize > height || poolSize > width {
+		return nil, fmt.Errorf("pool of size %d does not fit a %dx%d input", poolSize, height, width)
+	}
+	return &MaxPool2D{Channels: channels, Height: height, Width: width, PoolSize: poolSize, Stride: stride}, nil
+}
+
+// OutputSize returns the height and width of every output channel.
+func (p *MaxPool2D) OutputSize() (int, int) {
+	return (p.Height-p.PoolSize)/p.Stride + 1, (p.Width-p.PoolSize)/p.Stride + 1
+}
+
+// Type returns the registered name of the layer.
+func (p *MaxPool2D) Type() string { return "maxpool2d" }
+
+// Forward takes the maximum of every window and remembers where it came from.
+func (p *MaxPool2D) Forward(input []float64, training bool) []float64 {
+	outHeight, outWidth := p.OutputSize()
+	p.argmax = make([]int, p.Channels*outHeight*outWidth)
+	output := make([]float64, len(p.argmax))
+	for ch := 0; ch < p.Channels; ch++ {
+		for oy := 0; oy < outHeight; oy++ {
+			for ox := 0; ox < outWidth; ox++ {
+				best := (ch*p.Height+oy*p.Stride)*p.Width + ox*p.Stride
+				for ky := 0; ky < p.PoolSize; ky++ {
+					for kx := 0; kx < p.PoolSize; kx++ {
+						if idx := (ch*p.Height+oy*p.Stride+ky)*p.Width + ox*p.Stride + kx; input[idx] > input[best] {
+							best = idx
+						}
+					}
+				}
+				o := (ch*outHeight+oy)*outWidth + ox
+				p.argmax[o] = best
+				output[o] = input[best]
+			}
+		}
+	}
+	return output
+}
+
+// Backward routes each gradient to the input that won its window.
+func (p *MaxPool2D) Backward(outputGrad []float64) []float64 {
+	inputGrad := make([]float64, p.Channels*p.Height*p.Width)
+	for i, grad := range outputGrad {
+		inputGrad[p.argmax[i]] += grad
+	}
+	return inputGrad
+}
+
+// Params returns nil as the layer has no trainable parameters.
+func (p *MaxPool2D) Params() [][]float64 { return nil }
+
+// Grads returns nil as the layer has no trainable parameters.
+func (p *MaxPool2D) Grads() [][]float64 { return nil }
+
+// Flatten marks the point where multi-dimensional data is treated as a flat vector.
+// Samples are always stored flat, so the layer passes values through unchanged; it
+// exists so that saved models describe the architecture they were built with.
+type Flatten struct {
+	Size int `json:"size"`
+}
+
+// NewFlatten creates a flatten layer for inputs of the given size.
+func NewFlatten(size int) *Flatten {
+	return &Flatten{Size: size}
+}
+
+// Type returns the registered name of the layer.
+func (f *Flatten) Type() string { return "flatten" }
+
+// Forward returns the input unchanged.
+func (f *Flatten) Forward(input []float64, training bool) []float64 { return input }
+
+// Backward returns the gradient unchanged.
+func (f *Flatten) Backward(outputGrad []float64) []float64 { return outputGrad }
+
+// Params returns nil as the layer has no trainable parameters.
+func (f *Flatten) Params() [][]float64 { return nil }
+
+// Grads returns nil as the layer has no trainable parameters.
+func (f *Flatten) Grads() [][]float64 { return nil }
@@ -0,0 +1,73 @@
+package neuralnetwork_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"go-neuralnetwork/internal/neuralnetwork"
+)
+
+func TestConv2DForward(t *testing.T) {
+	conv := neuralnetwork.NewConv2D(1, 3, 3, 1, 2, 1, 0)
+	conv.Weights = [][]float64{{1, 0, 0, 1}}
+	conv.Biases = []float64{1}
+
+	// Each output is the sum of a 2x2 window's main diagonal plus the bias.
+	input := []float64{
+		1, 2, 3,
+		4, 5, 6,
+		7, 8, 9,
+	}
+	expected := []float64{1 + 1 + 5, 1 + 2 + 6, 1 + 4 + 8, 1 + 5 + 9}
+	if got := conv.Forward(input, false); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestMaxPool2D(t *testing.T) {
+	pool, err := neuralnetwork.NewMaxPool2D(1, 4, 4, 2, 2)
+	if err != nil {
+		t.Fatalf("Failed to create MaxPool2D: %v", err)
+	}
+	input := []float64{
+		1, 2, 0, 0,
+		3, 4, 0, 9,
+		5, 0, 1, 1,
+		0, 0, 1, 2,
+	}
+	if got := pool.Forward(input, false); !reflect.DeepEqual(got, []float64{4, 9, 5, 2}) {
+		t.Errorf("Expected [4 9 5 2], got %v", got)
+	}
+	inputGrad := pool.Backward([]float64{1, 1, 1, 1})
+	if inputGrad[5] != 1 || inputGrad[7] != 1 || inputGrad[8] != 1 || inputGrad[15] != 1 {
+		t.Errorf("Expected gradient routed to the maxima, got %v", inputGrad)
+	}
+
+	if _, err := neuralnetwork.NewMaxPool2D(1, 4, 2, 3, 3); err == nil {
+		t.Errorf("Expected an error for a pool wider than the input")
+	}
+	// A pool taller than the input used to pass validation with an output height of 1.
+	if _, err := neuralnetwork.BuildSequential([]int{1, 2, 6}, "maxpool2d(3)", nil, 1, "linear"); err == nil {
+		t.Errorf("Expected an error for a pool taller than the input")
+	}
+}
+
+func TestConv2DGradCheck(t *testing.T) {
+	seq, err := neuralnetwork.BuildSequential([]int{2, 6, 6},
+		"conv2d(3,3,1,1),maxpool2d(2),conv2d(2,2),flatten,4",
+		[]string{"tanh", "tanh", "sigmoid"}, 3, "linear")
+	if err != nil {
+		t.Fatalf("Failed to build model: %v", err)
+	}
+
+	input := make([]float64, 72)
+	for i := range input {
+		input[i] = math.Cos(float64(i) * 1.3)
+	}
+	for _, result := range neuralnetwork.GradCheckSequential(seq, input, []float64{0.1, 0.5, -0.3}, 1e-6) {
+		if result.MaxRelativeError > gradCheckTolerance {
+			t.Errorf("Layer %s: relative gradient error %e exceeds %e", result.Layer, result.MaxRelativeError, gradCheckTolerance)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package neuralnetwork
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Dense is a fully connected layer computing Weights*input + Biases.
+type Dense struct {
+	NumInputs  int         `json:"numInputs"`
+	NumOutputs int         `json:"numOutputs"`
+	Weights    [][]float64 `json:"weights"`
+	Biases     []float64   `json:"biases"`
+
+	weightGrads [][]float64
+	biasGrads   []float64
+	input       []float64
+}
+
+// NewDense creates a fully connected layer with He-initialized weights.
+func NewDense(inputs, outputs int) *Dense {
+	d := &Dense{
+		NumInputs:  inputs,
+		NumOutputs: outputs,
+		Weights:    newMatrix(outputs, inputs),
+		Biases:     make([]float64, outputs),
+	}
+	// He initialization for weights
+	heInit := math.Sqrt(2.0 / float64(inputs))
+	for i := range d.Weights {
+		for j := range d.Weights[i] {
+			d.Weights[i][j] = rand.NormFloat64() * heInit
+		}
+	}
+	d.initialize()
+	return d
+}
+
+func (d *Dense) initialize() error {
+	d.weightGrads = newMatrix(d.NumOutputs, d.NumInputs)
+	d.biasGrads = make([]float64, d.NumOutputs)
+	return nil
+}
+
+// Type returns the registered name of the layer.
+func (d *Dense) Type() string { return "dense" }
+
+// Forward computes the weighted sum of the inputs for every output neuron.
+func (d *Dense) Forward(input []float64, training bool) []float64 {
+	d.input = input
+	output := make([]float64, d.NumOutputs)
+	for i := range output {
+		sum := d.Biases[i]
+		for j, val := range input {
+			sum += val * d.Weights[i][j]
+		}
+		output[i] = sum
+	}
+	return output
+}
+
+// Backward accumulates weight and bias gradients and returns the input gradient.
+func (d *Dense) Backward(outputGrad []float64) []float64 {
+	inputGrad := make([]float64, d.NumInputs)
+	for i, grad := range outputGrad {
+		for j, val := range d.input {
+			d.weightGrads[i][j] += grad * val
+			inputGrad[j] += grad * d.Weights[i][j]
+		}
+		d.biasGrads[i] += grad
+	}
+	return inputGrad
+}
+
+// Params returns the weight rows followed by the biases.
+func (d *Dense) Params() [][]float64 {
+	return append(append([][]float64{}, d.Weights...), d.Biases)
+}
+
+// Grads returns the gradients aligned with Params.
+func (d *Dense) Grads() [][]float64 {
+	return append(append([][]float64{}, d.weightGrads...), d.biasGrads)
+}
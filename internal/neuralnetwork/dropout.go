@@ -0,0 +1,49 @@
+package neuralnetwork
+
+import "math/rand"
+
+// Dropout randomly zeroes inputs during training with probability Rate.
+// Surviving values are scaled by 1/(1-Rate) so no rescaling is needed at inference.
+type Dropout struct {
+	Rate float64 `json:"rate"`
+
+	mask []float64
+}
+
+// NewDropout creates a dropout layer with the given drop probability.
+func NewDropout(rate float64) *Dropout {
+	return &Dropout{Rate: rate}
+}
+
+// Type returns the registered name of the layer.
+func (d *Dropout) Type() string { return "dropout" }
+
+// Forward drops inputs while training and passes them through unchanged otherwise.
+func (d *Dropout) Forward(input []float64, training bool) []float64 {
+	d.mask = make([]float64, len(input))
+	output := make([]float64, len(input))
+	for i, val := range input {
+		if !training {
+			d.mask[i] = 1
+		} else if rand.Float64() >= d.Rate {
+			d.mask[i] = 1 / (1 - d.Rate)
+		}
+		output[i] = val * d.mask[i]
+	}
+	return output
+}
+
+// Backward routes the gradient through the inputs that were kept.
+func (d *Dropout) Backward(outputGrad []float64) []float64 {
+	inputGrad := make([]float64, len(outputGrad))
+	for i, grad := range outputGrad {
+		inputGrad[i] = grad * d.mask[i]
+	}
+	return inputGrad
+}
+
+// Params returns nil as the layer has no trainable parameters.
+func (d *Dropout) Params() [][]float64 { return nil }
+
+// Grads returns nil as the layer has no trainable parameters.
+func (d *Dropout) Grads() [][]float64 { return nil }
@@ -0,0 +1,107 @@
+package neuralnetwork
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Embedding learns a vector for every category of one or more categorical inputs.
+// Inputs hold NumNumeric numeric values followed by one category index per table; the
+// numeric values pass through unchanged and each index is replaced by the matching row
+// of its table, so the output has NumNumeric plus the sum of the embedding dimensions values.
+type Embedding struct {
+	NumNumeric int           `json:"numNumeric"`
+	Tables     [][][]float64 `json:"tables"`
+
+	tableGrads [][][]float64
+	indices    []int
+}
+
+// NewEmbedding creates embedding tables with vocabSizes[i] rows of dims[i] values,
+// initialized with small random values.
+func NewEmbedding(numNumeric int, vocabSizes, dims []int) *Embedding {
+	e := &Embedding{NumNumeric: numNumeric, Tables: make([][][]float64, len(vocabSizes))}
+	for i, size := range vocabSizes {
+		e.Tables[i] = newMatrix(size, dims[i])
+		for _, row := range e.Tables[i] {
+			for j := range row {
+				row[j] = rand.NormFloat64() * 0.05
+			}
+		}
+	}
+	e.initialize()
+	return e
+}
+
+func (e *Embedding) initialize() error {
+	e.tableGrads = make([][][]float64, len(e.Tables))
+	for i, table := range e.Tables {
+		if len(table) == 0 {
+			return fmt.Errorf("embedding table %d has no rows", i)
+		}
+		e.tableGrads[i] = newMatrix(len(table), len(table[0]))
+	}
+	return nil
+}
+
+// OutputSize returns the number of values the layer produces per sample.
+func (e *Embedding) OutputSize() int {
+	size := e.NumNumeric
+	for _, table := range e.Tables {
+		size += len(table[0])
+	}
+	return size
+}
+
+// Type returns the registered name of the layer.
+func (e *Embedding) Type() string { return "embedding" }
+
+// Forward looks up the embedding of every category index. Indices outside a table fall
+// into row 0, the unknown bucket.
+func (e *Embedding) Forward(input []float64, training bool) []float64 {
+	output := append(make([]float64, 0, e.OutputSize()), input[:e.NumNumeric]...)
+	e.indices = make([]int, len(e.Tables))
+	for i, table := range e.Tables {
+		index := int(input[e.NumNumeric+i])
+		if index < 0 || index >= len(table) {
+			index = 0
+		}
+		e.indices[i] = index
+		output = append(output, table[index]...)
+	}
+	return output
+}
+
+// Backward accumulates the gradients of the looked-up rows. Category indices are not
+// differentiable, so their input gradient is zero.
+func (e *Embedding) Backward(outputGrad []float64) []float64 {
+	inputGrad := make([]float64, e.NumNumeric+len(e.Tables))
+	copy(inputGrad, outputGrad[:e.NumNumeric])
+	offset := e.NumNumeric
+	for i, index := range e.indices {
+		row := e.tableGrads[i][index]
+		for j := range row {
+			row[j] += outputGrad[offset+j]
+		}
+		offset += len(row)
+	}
+	return inputGrad
+}
+
+// Params returns the rows of every table.
+func (e *Embedding) Params() [][]float64 {
+	var params [][]float64
+	for _, table := range e.Tables {
+		params = append(params, table...)
+	}
+	return params
+}
+
+// Grads returns the gradients aligned with Params.
+func (e *Embedding) Grads() [][]float64 {
+	var grads [][]float64
+	for _, table := range e.tableGrads {
+		grads = append(grads, table...)
+	}
+	return grads
+}
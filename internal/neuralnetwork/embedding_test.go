@@ -0,0 +1,48 @@
+package neuralnetwork_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"go-neuralnetwork/internal/neuralnetwork"
+)
+
+func TestEmbedding(t *testing.T) {
+	embedding := neuralnetwork.NewEmbedding(1, []int{3, 2}, []int{2, 1})
+	embedding.Tables = [][][]float64{
+		{{0, 0}, {1, 2}, {3, 4}},
+		{{0}, {5}},
+	}
+	if got := embedding.Forward([]float64{0.5, 2, 1}, false); !reflect.DeepEqual(got, []float64{0.5, 3, 4, 5}) {
+		t.Errorf("Expected [0.5 3 4 5], got %v", got)
+	}
+	// Unseen indices fall back to the unknown bucket.
+	if got := embedding.Forward([]float64{0.5, 7, -1}, false); !reflect.DeepEqual(got, []float64{0.5, 0, 0, 0}) {
+		t.Errorf("Expected [0.5 0 0 0], got %v", got)
+	}
+
+	seq, err := neuralnetwork.BuildSequential([]int{embedding.OutputSize()}, "4", []string{"tanh"}, 1, "linear")
+	if err != nil {
+		t.Fatalf("Failed to build model: %v", err)
+	}
+	seq.Layers = append([]neuralnetwork.Layer{neuralnetwork.NewEmbedding(1, []int{3, 2}, []int{2, 1})}, seq.Layers...)
+	input := []float64{0.3, 2, 1}
+	for _, result := range neuralnetwork.GradCheckSequential(seq, input, []float64{0.7}, 1e-6) {
+		if result.MaxRelativeError > gradCheckTolerance {
+			t.Errorf("Layer %s: relative gradient error %e exceeds %e", result.Layer, result.MaxRelativeError, gradCheckTolerance)
+		}
+	}
+
+	encoded, err := json.Marshal(seq)
+	if err != nil {
+		t.Fatalf("Failed to encode model: %v", err)
+	}
+	var decoded neuralnetwork.Sequential
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Failed to decode model: %v", err)
+	}
+	if !reflect.DeepEqual(seq.Predict(input), decoded.Predict(input)) {
+		t.Errorf("Decoded model predicts differently from the original")
+	}
+}
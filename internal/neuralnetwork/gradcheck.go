@@ -0,0 +1,116 @@
+package neuralnetwork
+
+import (
+	"fmt"
+	"math"
+)
+
+// LayerGradCheck reports the outcome of a gradient check for one layer.
+type LayerGradCheck struct {
+	Layer            string
+	MaxRelativeError float64
+}
+
+// GradCheck compares the gradients computed by backpropagation with central finite
+// differences of the loss for every weight and bias of the network. It returns the
+// worst relative error found in each hidden layer and in the output layer.
+func GradCheck(nn *NeuralNetwork, loss Loss, inputs, targets []float64, epsilon float64) []LayerGradCheck {
+	grads := nn.ComputeGradients(inputs, targets, loss)
+	lossAt := func() float64 {
+		_, finalOutputs := nn.FeedForward(inputs)
+		return loss.Compute(finalOutputs, targets)
+	}
+
+	results := make([]LayerGradCheck, 0, len(nn.HiddenLayers)+1)
+	for i := range nn.HiddenLayers {
+		params := append(append([][]float64{}, nn.HiddenWeights[i]...), nn.HiddenBiases[i])
+		analytic := append(append([][]float64{}, grads.HiddenWeights[i]...), grads.HiddenBiases[i])
+		results = append(results, LayerGradCheck{
+			Layer:            fmt.Sprintf("hidden %d", i+1),
+			MaxRelativeError: checkParams(params, analytic, lossAt, epsilon),
+		})
+	}
+	params := append(append([][]float64{}, nn.OutputWeights...), nn.OutputBiases)
+	analytic := append(append([][]float64{}, grads.OutputWeights...), grads.OutputBiases)
+	results = append(results, LayerGradCheck{
+		Layer:            "output",
+		MaxRelativeError: checkParams(params, analytic, lossAt, epsilon),
+	})
+	return results
+}
+
+// GradCheckSequential performs the same check on a Sequential model. The model is
+// run in inference mode so that layers such as Dropout are deterministic. Layers
+// without parameters are omitted from the report.
+func GradCheckSequential(s *Sequential, inputs, targets []float64, epsilon float64) []LayerGradCheck {
+	_, outputGrad := s.ComputeLoss(s.Forward(inputs, false), targets)
+	for _, layer := range s.Layers {
+		zeroGrads(layer)
+	}
+	s.Backward(outputGrad)
+	lossAt := func() float64 {
+		return s.lossFunction().Compute(s.Forward(inputs, false), targets)
+	}
+
+	var results []LayerGradCheck
+	for i, layer := range s.Layers {
+		params := layer.Params()
+		if len(params) == 0 {
+			continue
+		}
+		// Copy the analytic gradients before the perturbed passes run.
+		analytic := make([][]float64, len(params))
+		for j, g := range layer.Grads() {
+			analytic[j] = append([]float64(nil), g...)
+		}
+		results = append(results, LayerGradCheck{
+			Layer:            fmt.Sprintf("%d (%s)", i+1, layer.Type()),
+			MaxRelativeError: checkParams(params, analytic, lossAt, epsilon),
+		})
+	}
+	for _, layer := range s.Layers {
+		zeroGrads(layer)
+	}
+	return results
+}
+
+// checkParams perturbs every parameter in turn and returns the largest relative error
+// between its analytic gradient and the central difference of lossAt, ignoring
+// differences within the rounding noise of the central difference.
+func checkParams(params, analytic [][]float64, lossAt func() float64, epsilon float64) float64 {
+	noise := FiniteDifferenceNoise(lossAt(), epsilon)
+	maxError := 0.0
+	for i, param := range params {
+		for j := range param {
+			original := param[j]
+			param[j] = original + epsilon
+			plus := lossAt()
+			param[j] = original - epsilon
+			minus := lossAt()
+			param[j] = original
+
+			numerical := (plus - minus) / (2 * epsilon)
+			if math.Abs(analytic[i][j]-numerical) > noise {
+				maxError = math.Max(maxError, RelativeError(analytic[i][j], numerical))
+			}
+		}
+	}
+	return maxError
+}
+
+// RelativeError returns |a-b| / (|a|+|b|), the relative difference between two gradient
+// values. The denominator has a floor of 1e-8 so that two gradients that are both zero
+// compare as equal instead of dividing by zero.
+func RelativeError(a, b float64) float64 {
+	return math.Abs(a-b) / math.Max(math.Abs(a)+math.Abs(b), 1e-8)
+}
+
+// FiniteDifferenceNoise estimates the rounding error of a central difference of a loss
+// of the given size with the given step: both evaluations of the loss are off by a few
+// units in the last place, which the division by the step magnifies. A gradient that is
+// exactly zero, such as that of a parameter the loss does not depend on, has a central
+// difference of about this size, whose relative error against zero is meaningless.
+func FiniteDifferenceNoise(loss, epsilon float64) float64 {
+	const unitRoundoff = 0x1p-52
+	return 10 * unitRoundoff * math.Max(math.Abs(loss), 1) / epsilon
+}
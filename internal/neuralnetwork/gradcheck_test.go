@@ -0,0 +1,88 @@
+package neuralnetwork_test
+
+import (
+	"math"
+	"testing"
+
+	"go-neuralnetwork/internal/neuralnetwork"
+)
+
+const gradCheckTolerance = 1e-6
+
+func TestGradCheck(t *testing.T) {
+	inputs := []float64{0.3, -0.7, 0.5}
+	targets := []float64{0.2, 0.9}
+
+	for _, lossName := range neuralnetwork.GetAvailableLosses() {
+		loss, err := neuralnetwork.GetLoss(lossName)
+		if err != nil {
+			t.Fatalf("Failed to get loss %s: %v", lossName, err)
+		}
+		for _, activation := range neuralnetwork.GetAvailableActivations() {
+			t.Run(lossName+"_"+activation, func(t *testing.T) {
+				// Cross-entropy losses need probabilities, so keep a sigmoid output for them.
+				outputActivation := activation
+				if lossName != "mse" {
+					outputActivation = "sigmoid"
+				}
+				nn := neuralnetwork.InitNetwork(3, []int{4, 3}, 2, []string{activation, activation}, outputActivation)
+				// Non-zero biases keep pre-activations away from the ReLU kink at exactly zero,
+				// which a layer of dead units would otherwise pass on to the next layer.
+				for i := range nn.HiddenBiases {
+					for j := range nn.HiddenBiases[i] {
+						nn.HiddenBiases[i][j] = 0.1
+					}
+				}
+				for i := range nn.OutputBiases {
+					nn.OutputBiases[i] = 0.1
+				}
+
+				results := neuralnetwork.GradCheck(nn, loss, inputs, targets, 1e-6)
+				if len(results) != 3 {
+					t.Fatalf("Expected a result for each of the 3 layers, got %d", len(results))
+				}
+				for _, result := range results {
+					if result.MaxRelativeError > gradCheckTolerance {
+						t.Errorf("Layer %s: relative gradient error %e exceeds %e", result.Layer, result.MaxRelativeError, gradCheckTolerance)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestGradCheckSequential(t *testing.T) {
+	tanh, _ := neuralnetwork.NewActivationLayer("tanh")
+	sigmoid, _ := neuralnetwork.NewActivationLayer("sigmoid")
+	seq, err := neuralnetwork.NewSequential("binary_crossentropy",
+		neuralnetwork.NewDense(3, 4),
+		neuralnetwork.NewLayerNorm(4),
+		tanh,
+		neuralnetwork.NewDropout(0.5),
+		neuralnetwork.NewDense(4, 2),
+		sigmoid,
+	)
+	if err != nil {
+		t.Fatalf("Failed to build model: %v", err)
+	}
+
+	results := neuralnetwork.GradCheckSequential(seq, []float64{0.3, -0.7, 0.5}, []float64{1, 0}, 1e-6)
+	if len(results) != 3 {
+		t.Fatalf("Expected a result for each of the 3 parameterised layers, got %d", len(results))
+	}
+	for _, result := range results {
+		if result.MaxRelativeError > gradCheckTolerance {
+			t.Errorf("Layer %s: relative gradient error %e exceeds %e", result.Layer, result.MaxRelativeError, gradCheckTolerance)
+		}
+	}
+}
+
+func TestRelativeError(t *testing.T) {
+	// Small gradients are compared relatively, not absolutely.
+	if got := neuralnetwork.RelativeError(1e-6, 2e-6); math.Abs(got-1.0/3) > 1e-9 {
+		t.Errorf("Expected 1/3, got %e", got)
+	}
+	if got := neuralnetwork.RelativeError(0, 0); got != 0 {
+		t.Errorf("Expected 0 for two zero gradients, got %e", got)
+	}
+}
@@ -0,0 +1,164 @@
+package neuralnetwork
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Head is one output branch of a multi-task model: a stack of layers producing Outputs
+// values, trained with its own loss scaled by Weight.
+type Head struct {
+	Name     string
+	Outputs  int
+	Layers   []Layer
+	LossName string
+	Weight   float64
+
+	loss Loss
+}
+
+// NewHead creates an output head trained with the named loss function.
+func NewHead(name string, outputs int, lossName string, weight float64, layers ...Layer) (*Head, error) {
+	loss, err := GetLoss(lossName)
+	if err != nil {
+		return nil, fmt.Errorf("head %q: %w", name, err)
+	}
+	return &Head{Name: name, Outputs: outputs, Layers: layers, LossName: lossName, Weight: weight, loss: loss}, nil
+}
+
+// Heads runs several output heads on the same input and concatenates their outputs.
+// It is meant to be the last layer of a Sequential model, whose loss it replaces: Heads
+// is also a Loss that splits the prediction and the targets between the heads and sums
+// their weighted losses.
+type Heads struct {
+	Heads []*Head
+}
+
+// NewHeads combines output heads into one layer.
+func NewHeads(heads ...*Head) *Heads {
+	return &Heads{Heads: heads}
+}
+
+// Type returns the registered name of the layer.
+func (h *Heads) Type() string { return "heads" }
+
+// Forward runs every head on the input.
+func (h *Heads) Forward(input []float64, training bool) []float64 {
+	var output []float64
+	for _, head := range h.Heads {
+		output = append(output, forwardBlock(head.Layers, input, training)...)
+	}
+	return output
+}
+
+// Backward sends each head its part of the gradient and sums the resulting input gradients.
+func (h *Heads) Backward(outputGrad []float64) []float64 {
+	var inputGrad []float64
+	offset := 0
+	for _, head := range h.Heads {
+		grad := backwardBlock(head.Layers, outputGrad[offset:offset+head.Outputs])
+		offset += head.Outputs
+		if inputGrad == nil {
+			inputGrad = make([]float64, len(grad))
+		}
+		for i, g := range grad {
+			inputGrad[i] += g
+		}
+	}
+	return inputGrad
+}
+
+// Params returns the parameters of every head in order.
+func (h *Heads) Params() [][]float64 {
+	var params [][]float64
+	for _, head := range h.Heads {
+		params = append(params, blockParams(head.Layers, Layer.Params)...)
+	}
+	return params
+}
+
+// Grads returns the gradients aligned with Params.
+func (h *Heads) Grads() [][]float64 {
+	var grads [][]float64
+	for _, head := range h.Heads {
+		grads = append(grads, blockParams(head.Layers, Layer.Grads)...)
+	}
+	return grads
+}
+
+// Split returns the part of a prediction or target row that belongs to each head.
+func (h *Heads) Split(values []float64) [][]float64 {
+	parts := make([][]float64, len(h.Heads))
+	offset := 0
+	for i, head := range h.Heads {
+		parts[i] = values[offset : offset+head.Outputs]
+		offset += head.Outputs
+	}
+	return parts
+}
+
+// Compute returns the weighted sum of the heads' losses.
+func (h *Heads) Compute(predicted, targets []float64) float64 {
+	predictedParts, targetParts := h.Split(predicted), h.Split(targets)
+	loss := 0.0
+	for i, head := range h.Heads {
+		loss += head.Weight * head.loss.Compute(predictedParts[i], targetParts[i])
+	}
+	return loss
+}
+
+// Gradient returns every head's weighted loss gradient, concatenated.
+func (h *Heads) Gradient(predicted, targets []float64) []float64 {
+	predictedParts, targetParts := h.Split(predicted), h.Split(targets)
+	var grad []float64
+	for i, head := range h.Heads {
+		for _, g := range head.loss.Gradient(predictedParts[i], targetParts[i]) {
+			grad = append(grad, head.Weight*g)
+		}
+	}
+	return grad
+}
+
+type headJSON struct {
+	Name    string      `json:"name"`
+	Outputs int         `json:"outputs"`
+	Layers  []layerJSON `json:"layers"`
+	Loss    string      `json:"loss"`
+	Weight  float64     `json:"weight"`
+}
+
+// MarshalJSON encodes every head with its layers, loss and weight.
+func (h *Heads) MarshalJSON() ([]byte, error) {
+	encoded := make([]headJSON, len(h.Heads))
+	for i, head := range h.Heads {
+		layers, err := marshalLayers(head.Layers)
+		if err != nil {
+			return nil, err
+		}
+		encoded[i] = headJSON{Name: head.Name, Outputs: head.Outputs, Layers: layers, Loss: head.LossName, Weight: head.Weight}
+	}
+	return json.Marshal(struct {
+		Heads []headJSON `json:"heads"`
+	}{encoded})
+}
+
+// UnmarshalJSON decodes a layer written by MarshalJSON.
+func (h *Heads) UnmarshalJSON(b []byte) error {
+	var decoded struct {
+		Heads []headJSON `json:"heads"`
+	}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return err
+	}
+	h.Heads = make([]*Head, len(decoded.Heads))
+	for i, e := range decoded.Heads {
+		layers, err := unmarshalLayers(e.Layers)
+		if err != nil {
+			return err
+		}
+		if h.Heads[i], err = NewHead(e.Name, e.Outputs, e.Loss, e.Weight, layers...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
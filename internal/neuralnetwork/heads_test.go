@@ -0,0 +1,60 @@
+package neuralnetwork_test
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+
+	"go-neuralnetwork/internal/neuralnetwork"
+)
+
+func TestMultiTaskHeads(t *testing.T) {
+	heads := []neuralnetwork.HeadSpec{
+		{Name: "species", Outputs: 3, Activation: "sigmoid", Loss: "binary_crossentropy", Weight: 1},
+		{Name: "weight", Outputs: 1, Activation: "linear", Loss: "mse", Weight: 0.5},
+	}
+	seq, err := neuralnetwork.BuildMultiTask([]int{4}, "6", []string{"tanh"}, heads)
+	if err != nil {
+		t.Fatalf("Failed to build model: %v", err)
+	}
+	input := []float64{0.1, -0.4, 0.8, 0.3}
+	target := []float64{0, 1, 0, 0.7}
+	if output := seq.Predict(input); len(output) != 4 {
+		t.Fatalf("Expected 4 outputs, got %d", len(output))
+	}
+	for _, result := range neuralnetwork.GradCheckSequential(seq, input, target, 1e-6) {
+		if result.MaxRelativeError > gradCheckTolerance {
+			t.Errorf("Layer %s: relative gradient error %e exceeds %e", result.Layer, result.MaxRelativeError, gradCheckTolerance)
+		}
+	}
+
+	// The loss is the weighted sum of the heads' own losses.
+	output := seq.Predict(input)
+	bce, _ := neuralnetwork.GetLoss("binary_crossentropy")
+	mse, _ := neuralnetwork.GetLoss("mse")
+	expected := bce.Compute(output[:3], target[:3]) + 0.5*mse.Compute(output[3:], target[3:])
+	if loss, _ := seq.ComputeLoss(output, target); math.Abs(loss-expected) > 1e-12 {
+		t.Errorf("Expected loss %f, got %f", expected, loss)
+	}
+
+	encoded, err := json.Marshal(seq)
+	if err != nil {
+		t.Fatalf("Failed to encode model: %v", err)
+	}
+	var decoded neuralnetwork.Sequential
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Failed to decode model: %v", err)
+	}
+	if !reflect.DeepEqual(seq.Predict(input), decoded.Predict(input)) {
+		t.Errorf("Decoded model predicts differently from the original")
+	}
+	if loss, _ := decoded.ComputeLoss(output, target); math.Abs(loss-expected) > 1e-12 {
+		t.Errorf("Expected the decoded model to keep the head losses, got loss %f", loss)
+	}
+
+	heads[1].Loss = "hinge"
+	if _, err := neuralnetwork.BuildMultiTask([]int{4}, "6", []string{"tanh"}, heads); err == nil {
+		t.Errorf("Expected an error for an unknown head loss, got nil")
+	}
+}
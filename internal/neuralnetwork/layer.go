@@ -0,0 +1,126 @@
+package neuralnetwork
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Layer is a single building block of a Sequential model.
+//
+// Layers work on one sample at a time. Forward caches whatever it needs so that
+// the following call to Backward can compute gradients for that same sample.
+type Layer interface {
+	// Type returns the name the layer is registered under for serialisation.
+	Type() string
+	// Forward computes the layer output for a single input sample.
+	Forward(input []float64, training bool) []float64
+	// Backward takes the gradient of the loss with respect to the layer output,
+	// accumulates parameter gradients and returns the gradient with respect to the input.
+	Backward(outputGrad []float64) []float64
+	// Params returns the trainable parameters of the layer.
+	Params() [][]float64
+	// Grads returns the accumulated gradients, aligned with Params.
+	Grads() [][]float64
+}
+
+// initializer is implemented by layers that need to rebuild unexported state
+// after being decoded from JSON.
+type initializer interface {
+	initialize() error
+}
+
+// availableLayers maps layer type names to constructors of empty layers used for decoding.
+var availableLayers = map[string]func() Layer{
+	"dense":         func() Layer { return &Dense{} },
+	"activation":    func() Layer { return &ActivationLayer{} },
+	"dropout":       func() Layer { return &Dropout{} },
+	"layernorm":     func() Layer { return &LayerNorm{} },
+	"conv1d":        func() Layer { return &Conv1D{} },
+	"maxpool1d":     func() Layer { return &MaxPool1D{} },
+	"avgpool1d":     func() Layer { return &AvgPool1D{} },
+	"globalavgpool": func() Layer { return &GlobalAveragePooling{} },
+	"conv2d":        func() Layer { return &Conv2D{} },
+	"maxpool2d":     func() Layer { return &MaxPool2D{} },
+	"flatten":       func() Layer { return &Flatten{} },
+	"simplernn":     func() Layer { return &SimpleRNN{} },
+	"lstm":          func() Layer { return &LSTM{} },
+	"gru":           func() Layer { return &GRU{} },
+	"embedding":     func() Layer { return &Embedding{} },
+	"residual":      func() Layer { return &Residual{} },
+	"denseconcat":   func() Layer { return &DenseConcat{} },
+	"attention":     func() Layer { return &MultiHeadAttention{} },
+	"posenc":        func() Layer { return &PositionalEncoding{} },
+	"timedense":     func() Layer { return &TimeDistributedDense{} },
+	"transformer":   func() Layer { return &TransformerEncoder{} },
+	"heads":         func() Layer { return &Heads{} },
+}
+
+// GetAvailableLayers returns a sorted list of registered layer type names.
+func GetAvailableLayers() []string {
+	keys := make([]string, 0, len(availableLayers))
+	for k := range availableLayers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// layerJSON is the serialised form of a layer: its type name and its exported fields.
+type layerJSON struct {
+	Type   string          `json:"type"`
+	Config json.RawMessage `json:"config"`
+}
+
+// marshalLayers encodes a list of layers together with their type names.
+func marshalLayers(layers []Layer) ([]layerJSON, error) {
+	encoded := make([]layerJSON, len(layers))
+	for i, layer := range layers {
+		config, err := json.Marshal(layer)
+		if err != nil {
+			return nil, fmt.Errorf("encoding %s layer: %w", layer.Type(), err)
+		}
+		encoded[i] = layerJSON{Type: layer.Type(), Config: config}
+	}
+	return encoded, nil
+}
+
+// unmarshalLayers decodes layers written by marshalLayers.
+func unmarshalLayers(encoded []layerJSON) ([]Layer, error) {
+	layers := make([]Layer, len(encoded))
+	for i, e := range encoded {
+		newLayer, ok := availableLayers[e.Type]
+		if !ok {
+			return nil, fmt.Errorf("unknown layer type: %s", e.Type)
+		}
+		layer := newLayer()
+		if err := json.Unmarshal(e.Config, layer); err != nil {
+			return nil, fmt.Errorf("decoding %s layer: %w", e.Type, err)
+		}
+		if init, ok := layer.(initializer); ok {
+			if err := init.initialize(); err != nil {
+				return nil, err
+			}
+		}
+		layers[i] = layer
+	}
+	return layers, nil
+}
+
+// zeroGrads resets every gradient slice of a layer to zero.
+func zeroGrads(layer Layer) {
+	for _, g := range layer.Grads() {
+		for i := range g {
+			g[i] = 0
+		}
+	}
+}
+
+// newMatrix allocates a rows x cols matrix.
+func newMatrix(rows, cols int) [][]float64 {
+	m := make([][]float64, rows)
+	for i := range m {
+		m[i] = make([]float64, cols)
+	}
+	return m
+}
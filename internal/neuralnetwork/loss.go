@@ -0,0 +1,111 @@
+package neuralnetwork
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Loss is an interface for loss functions comparing a prediction with its target.
+type Loss interface {
+	Compute(predicted, targets []float64) float64
+	Gradient(predicted, targets []float64) []float64
+}
+
+// probabilityEpsilon keeps probabilities away from 0 and 1 before taking logarithms.
+const probabilityEpsilon = 1e-12
+
+// MeanSquaredError is half the summed squared error, matching the error reported by Train.
+type MeanSquaredError struct{}
+
+// Compute calculates the loss for a single sample.
+func (m *MeanSquaredError) Compute(predicted, targets []float64) float64 {
+	loss := 0.0
+	for i, target := range targets {
+		loss += 0.5 * (target - predicted[i]) * (target - predicted[i])
+	}
+	return loss
+}
+
+// Gradient calculates the derivative of the loss with respect to each prediction.
+func (m *MeanSquaredError) Gradient(predicted, targets []float64) []float64 {
+	grad := make([]float64, len(predicted))
+	for i, target := range targets {
+		grad[i] = predicted[i] - target
+	}
+	return grad
+}
+
+// BinaryCrossEntropy is the loss for independent probabilities, usually after a sigmoid.
+type BinaryCrossEntropy struct{}
+
+// Compute calculates the loss for a single sample.
+func (b *BinaryCrossEntropy) Compute(predicted, targets []float64) float64 {
+	loss := 0.0
+	for i, target := range targets {
+		p := clampProbability(predicted[i])
+		loss -= target*math.Log(p) + (1-target)*math.Log(1-p)
+	}
+	return loss
+}
+
+// Gradient calculates the derivative of the loss with respect to each prediction.
+func (b *BinaryCrossEntropy) Gradient(predicted, targets []float64) []float64 {
+	grad := make([]float64, len(predicted))
+	for i, target := range targets {
+		p := clampProbability(predicted[i])
+		grad[i] = (p - target) / (p * (1 - p))
+	}
+	return grad
+}
+
+// CategoricalCrossEntropy is the loss for one-hot targets.
+type CategoricalCrossEntropy struct{}
+
+// Compute calculates the loss for a single sample.
+func (c *CategoricalCrossEntropy) Compute(predicted, targets []float64) float64 {
+	loss := 0.0
+	for i, target := range targets {
+		loss -= target * math.Log(clampProbability(predicted[i]))
+	}
+	return loss
+}
+
+// Gradient calculates the derivative of the loss with respect to each prediction.
+func (c *CategoricalCrossEntropy) Gradient(predicted, targets []float64) []float64 {
+	grad := make([]float64, len(predicted))
+	for i, target := range targets {
+		grad[i] = -target / clampProbability(predicted[i])
+	}
+	return grad
+}
+
+func clampProbability(p float64) float64 {
+	return math.Min(math.Max(p, probabilityEpsilon), 1-probabilityEpsilon)
+}
+
+// availableLosses holds all available loss functions.
+var availableLosses = map[string]Loss{
+	"mse":                      &MeanSquaredError{},
+	"binary_crossentropy":      &BinaryCrossEntropy{},
+	"categorical_crossentropy": &CategoricalCrossEntropy{},
+}
+
+// GetLoss returns a loss function by name.
+func GetLoss(name string) (Loss, error) {
+	loss, ok := availableLosses[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown loss function: %s", name)
+	}
+	return loss, nil
+}
+
+// GetAvailableLosses returns a sorted list of available loss function names.
+func GetAvailableLosses() []string {
+	keys := make([]string, 0, len(availableLosses))
+	for k := range availableLosses {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -89,11 +89,9 @@ func TestFeedForward(t *testing.T) {
 func TestSaveAndLoadModel(t *testing.T) {
 	originalNN := neuralnetwork.InitNetwork(2, []int{2, 2}, 1, []string{"relu", "tanh"}, "linear")
 	originalMD := &data.ModelData{
-		NN:         originalNN,
-		TargetMins: []float64{1.0},
-		TargetMaxs: []float64{10.0},
-		InputMins:  []float64{0.0, 0.0},
-		InputMaxs:  []float64{1.0, 1.0},
+		NN:            originalNN,
+		TargetScalers: data.MinMaxScalers([]float64{1.0}, []float64{10.0}),
+		Pipeline:      &data.Pipeline{Scalers: data.MinMaxScalers([]float64{0.0, 0.0}, []float64{1.0, 1.0})},
 	}
 
 	filePath, err := tempfile.CreateTempFileWithContent("model-*.json", "")
@@ -118,33 +118,43 @@ func (nn *NeuralNetwork) FeedForward(inputs []float64) ([][]float64, []float64)
 	return hiddenOutputs, finalOutputs
 }
 
-// Backpropagate performs the backpropagation algorithm to update the weights and biases of the network.
-func (nn *NeuralNetwork) Backpropagate(inputs []float64, targets []float64, hiddenOutputs [][]float64, finalOutputs []float64, learningRate float64) {
-	// Calculate output layer errors and deltas
-	outputErrors := make([]float64, nn.NumOutputs)
+// Gradients holds the derivatives of a loss with respect to every weight and bias of a NeuralNetwork.
+type Gradients struct {
+	HiddenWeights [][][]float64
+	HiddenBiases  [][]float64
+	OutputWeights [][]float64
+	OutputBiases  []float64
+}
+
+// ComputeGradients calculates the gradients of the given loss for a single sample without
+// updating the network.
+func (nn *NeuralNetwork) ComputeGradients(inputs []float64, targets []float64, loss Loss) *Gradients {
+	hiddenOutputs, finalOutputs := nn.FeedForward(inputs)
+	return nn.gradients(inputs, hiddenOutputs, finalOutputs, loss.Gradient(finalOutputs, targets))
+}
+
+// gradients backpropagates the gradient of the loss with respect to the network outputs.
+func (nn *NeuralNetwork) gradients(inputs []float64, hiddenOutputs [][]float64, finalOutputs []float64, outputGrad []float64) *Gradients {
+	// Calculate output layer deltas
 	outputDeltas := make([]float64, nn.NumOutputs)
-	for i := range outputErrors {
-		outputErrors[i] = targets[i] - finalOutputs[i]
-		outputDeltas[i] = outputErrors[i] * nn.outputActivationFunc.Derivative(finalOutputs[i])
+	for i := range outputDeltas {
+		outputDeltas[i] = outputGrad[i] * nn.outputActivationFunc.Derivative(finalOutputs[i])
 	}
 
 	// Calculate hidden layer errors and deltas
-	hiddenErrors := make([][]float64, len(nn.HiddenLayers))
 	hiddenDeltas := make([][]float64, len(nn.HiddenLayers))
 	nextLayerDeltas := outputDeltas
 	nextLayerWeights := nn.OutputWeights
 
 	for i := len(nn.HiddenLayers) - 1; i >= 0; i-- {
 		layerSize := nn.HiddenLayers[i]
-		hiddenErrors[i] = make([]float64, layerSize)
 		hiddenDeltas[i] = make([]float64, layerSize)
-		for j := range hiddenErrors[i] {
+		for j := range hiddenDeltas[i] {
 			sum := 0.0
 			for k, delta := range nextLayerDeltas {
 				sum += delta * nextLayerWeights[k][j]
 			}
-			hiddenErrors[i][j] = sum
-			hiddenDeltas[i][j] = hiddenErrors[i][j] * nn.hiddenActivationFuncs[i].Derivative(hiddenOutputs[i][j])
+			hiddenDeltas[i][j] = sum * nn.hiddenActivationFuncs[i].Derivative(hiddenOutputs[i][j])
 		}
 
 		if i > 0 {
@@ -153,28 +163,66 @@ func (nn *NeuralNetwork) Backpropagate(inputs []float64, targets []float64, hidd
 		}
 	}
 
-	// Update output weights and biases
-	lastHiddenLayerOutput := hiddenOutputs[len(hiddenOutputs)-1]
-	for i := range nn.OutputWeights {
+	grads := &Gradients{
+		HiddenWeights: make([][][]float64, len(nn.HiddenLayers)),
+		HiddenBiases:  hiddenDeltas,
+		OutputWeights: make([][]float64, nn.NumOutputs),
+		OutputBiases:  outputDeltas,
+	}
+
+	// Output weight gradients
+	lastHiddenLayerOutput := inputs
+	if len(hiddenOutputs) > 0 {
+		lastHiddenLayerOutput = hiddenOutputs[len(hiddenOutputs)-1]
+	}
+	for i := range grads.OutputWeights {
+		grads.OutputWeights[i] = make([]float64, len(lastHiddenLayerOutput))
 		for j, val := range lastHiddenLayerOutput {
-			nn.OutputWeights[i][j] += learningRate * outputDeltas[i] * val
+			grads.OutputWeights[i][j] = outputDeltas[i] * val
 		}
-		nn.OutputBiases[i] += learningRate * outputDeltas[i]
 	}
 
-	// Update hidden weights and biases
-	for i := len(nn.HiddenLayers) - 1; i >= 0; i-- {
-		var prevLayerOutput []float64
-		if i == 0 {
-			prevLayerOutput = inputs
-		} else {
+	// Hidden weight gradients
+	for i := range nn.HiddenLayers {
+		prevLayerOutput := inputs
+		if i > 0 {
 			prevLayerOutput = hiddenOutputs[i-1]
 		}
+		grads.HiddenWeights[i] = make([][]float64, len(nn.HiddenWeights[i]))
 		for j := range nn.HiddenWeights[i] {
+			grads.HiddenWeights[i][j] = make([]float64, len(prevLayerOutput))
 			for k, val := range prevLayerOutput {
-				nn.HiddenWeights[i][j][k] += learningRate * hiddenDeltas[i][j] * val
+				grads.HiddenWeights[i][j][k] = hiddenDeltas[i][j] * val
+			}
+		}
+	}
+	return grads
+}
+
+// Backpropagate performs the backpropagation algorithm to update the weights and biases of the network.
+// The gradients are those of the squared error used by Train.
+func (nn *NeuralNetwork) Backpropagate(inputs []float64, targets []float64, hiddenOutputs [][]float64, finalOutputs []float64, learningRate float64) {
+	outputErrors := make([]float64, nn.NumOutputs)
+	for i := range outputErrors {
+		outputErrors[i] = finalOutputs[i] - targets[i]
+	}
+	grads := nn.gradients(inputs, hiddenOutputs, finalOutputs, outputErrors)
+
+	// Update output weights and biases
+	for i := range nn.OutputWeights {
+		for j := range nn.OutputWeights[i] {
+			nn.OutputWeights[i][j] -= learningRate * grads.OutputWeights[i][j]
+		}
+		nn.OutputBiases[i] -= learningRate * grads.OutputBiases[i]
+	}
+
+	// Update hidden weights and biases
+	for i := range nn.HiddenWeights {
+		for j := range nn.HiddenWeights[i] {
+			for k := range nn.HiddenWeights[i][j] {
+				nn.HiddenWeights[i][j][k] -= learningRate * grads.HiddenWeights[i][j][k]
 			}
-			nn.HiddenBiases[i][j] += learningRate * hiddenDeltas[i][j]
+			nn.HiddenBiases[i][j] -= learningRate * grads.HiddenBiases[i][j]
 		}
 	}
 }
@@ -0,0 +1,102 @@
+package neuralnetwork
+
+import "math"
+
+// LayerNorm normalises its input to zero mean and unit variance, then applies a
+// learned scale (Gamma) and shift (Beta).
+//
+// Inputs longer than Size are treated as consecutive groups of Size values, each
+// normalised separately with the shared Gamma and Beta. This is how per-timestep
+// normalisation of sequence data is expressed.
+type LayerNorm struct {
+	Size    int       `json:"size"`
+	Gamma   []float64 `json:"gamma"`
+	Beta    []float64 `json:"beta"`
+	Epsilon float64   `json:"epsilon"`
+
+	gammaGrads []float64
+	betaGrads  []float64
+	normalized []float64
+	invStd     []float64
+}
+
+// NewLayerNorm creates a layer normalisation over groups of size values.
+func NewLayerNorm(size int) *LayerNorm {
+	l := &LayerNorm{
+		Size:    size,
+		Gamma:   make([]float64, size),
+		Beta:    make([]float64, size),
+		Epsilon: 1e-5,
+	}
+	for i := range l.Gamma {
+		l.Gamma[i] = 1
+	}
+	l.initialize()
+	return l
+}
+
+func (l *LayerNorm) initialize() error {
+	l.gammaGrads = make([]float64, l.Size)
+	l.betaGrads = make([]float64, l.Size)
+	return nil
+}
+
+// Type returns the registered name of the layer.
+func (l *LayerNorm) Type() string { return "layernorm" }
+
+// Forward normalises each group of Size values.
+func (l *LayerNorm) Forward(input []float64, training bool) []float64 {
+	groups := len(input) / l.Size
+	l.normalized = make([]float64, len(input))
+	l.invStd = make([]float64, groups)
+	output := make([]float64, len(input))
+	for g := 0; g < groups; g++ {
+		values := input[g*l.Size : (g+1)*l.Size]
+		mean := 0.0
+		for _, val := range values {
+			mean += val
+		}
+		mean /= float64(l.Size)
+		variance := 0.0
+		for _, val := range values {
+			variance += (val - mean) * (val - mean)
+		}
+		variance /= float64(l.Size)
+		l.invStd[g] = 1 / math.Sqrt(variance+l.Epsilon)
+		for i, val := range values {
+			idx := g*l.Size + i
+			l.normalized[idx] = (val - mean) * l.invStd[g]
+			output[idx] = l.Gamma[i]*l.normalized[idx] + l.Beta[i]
+		}
+	}
+	return output
+}
+
+// Backward accumulates Gamma and Beta gradients and returns the input gradient.
+func (l *LayerNorm) Backward(outputGrad []float64) []float64 {
+	inputGrad := make([]float64, len(outputGrad))
+	n := float64(l.Size)
+	for g := range l.invStd {
+		sumGrad, sumGradNorm := 0.0, 0.0
+		normGrads := make([]float64, l.Size)
+		for i := range normGrads {
+			idx := g*l.Size + i
+			l.gammaGrads[i] += outputGrad[idx] * l.normalized[idx]
+			l.betaGrads[i] += outputGrad[idx]
+			normGrads[i] = outputGrad[idx] * l.Gamma[i]
+			sumGrad += normGrads[i]
+			sumGradNorm += normGrads[i] * l.normalized[idx]
+		}
+		for i, grad := range normGrads {
+			idx := g*l.Size + i
+			inputGrad[idx] = l.invStd[g] / n * (n*grad - sumGrad - l.normalized[idx]*sumGradNorm)
+		}
+	}
+	return inputGrad
+}
+
+// Params returns Gamma and Beta.
+func (l *LayerNorm) Params() [][]float64 { return [][]float64{l.Gamma, l.Beta} }
+
+// Grads returns the gradients aligned with Params.
+func (l *LayerNorm) Grads() [][]float64 { return [][]float64{l.gammaGrads, l.betaGrads} }
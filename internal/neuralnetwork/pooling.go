@@ -0,0 +1,186 @@
+package neuralnetwork
+
+import "fmt"
+
+// MaxPool1D keeps the largest value of each window along the length of every channel.
+type MaxPool1D struct {
+	Channels int `json:"channels"`
+	InLength int `json:"inLength"`
+	PoolSize int `json:"poolSize"`
+	Stride   int `json:"stride"`
+
+	argmax   []int
+	inputLen int
+}
+
+// NewMaxPool1D creates a max pooling layer for channels x inLength inputs. The pool must
+// fit the input.
+func NewMaxPool1D(channels, inLength, poolSize, stride int) (*MaxPool1D, error) {
+	if err := checkPool1D(inLength, poolSize, stride); err != nil {
+		return nil, err
+	}
+	return &MaxPool1D{Channels: channels, InLength: inLength, PoolSize: poolSize, Stride: stride}, nil
+}
+
+// OutputLength returns the number of positions per output channel.
+func (p *MaxPool1D) OutputLength() int {
+	return (p.InLength-p.PoolSize)/p.Stride + 1
+}
+
+// Type returns the registered name of the layer.
+func (p *MaxPool1D) Type() string { return "maxpool1d" }
+
+// Forward takes the maximum of every window and remembers where it came from.
+func (p *MaxPool1D) Forward(input []float64, training bool) []float64 {
+	outLength := p.OutputLength()
+	p.inputLen = len(input)
+	p.argmax = make([]int, p.Channels*outLength)
+	output := make([]float64, p.Channels*outLength)
+	for ch := 0; ch < p.Channels; ch++ {
+		for o := 0; o < outLength; o++ {
+			best := ch*p.InLength + o*p.Stride
+			for k := 1; k < p.PoolSize; k++ {
+				if idx := ch*p.InLength + o*p.Stride + k; input[idx] > input[best] {
+					best = idx
+				}
+			}
+			p.argmax[ch*outLength+o] = best
+			output[ch*outLength+o] = input[best]
+		}
+	}
+	return output
+}
+
+// Backward routes each gradient to the input that won its window.
+func (p *MaxPool1D) Backward(outputGrad []float64) []float64 {
+	inputGrad := make([]float64, p.inputLen)
+	for i, grad := range outputGrad {
+		inputGrad[p.argmax[i]] += grad
+	}
+	return inputGrad
+}
+
+// Params returns nil as the layer has no trainable parameters.
+func (p *MaxPool1D) Params() [][]float64 { return nil }
+
+// Grads returns nil as the layer has no trainable parameters.
+func (p *MaxPool1D) Grads() [][]float64 { return nil }
+
+// checkPool1D validates the pool size and stride of a 1D pooling layer. A pool larger
+// than the input would still give an output length of 1 by integer division.
+func checkPool1D(inLength, poolSize, stride int) error {
+	if poolSize < 1 || stride < 1 {
+		return fmt.Errorf("pool size and stride must be positive")
+	}
+	if poolSize > inLength {
+		return fmt.Errorf("pool of size %d does not fit an input of length %d", poolSize, inLength)
+	}
+	return nil
+}
+
+// AvgPool1D averages each window along the length of every channel.
+type AvgPool1D struct {
+	Channels int `json:"channels"`
+	InLength int `json:"inLength"`
+	PoolSize int `json:"poolSize"`
+	Stride   int `json:"stride"`
+}
+
+// NewAvgPool1D creates an average pooling layer for channels x inLength inputs. The pool must
+// fit the input.
+func NewAvgPool1D(channels, inLength, poolSize, stride int) (*AvgPool1D, error) {
+	if err := checkPool1D(inLength, poolSize, stride); err != nil {
+		return nil, err
+	}
+	return &AvgPool1D{Channels: channels, InLength: inLength, PoolSize: poolSize, Stride: stride}, nil
+}
+
+// OutputLength returns the number of positions per output channel.
+func (p *AvgPool1D) OutputLength() int {
+	return (p.InLength-p.PoolSize)/p.Stride + 1
+}
+
+// Type returns the registered name of the layer.
+func (p *AvgPool1D) Type() string { return "avgpool1d" }
+
+// Forward averages every window.
+func (p *AvgPool1D) Forward(input []float64, training bool) []float64 {
+	outLength := p.OutputLength()
+	output := make([]float64, p.Channels*outLength)
+	for ch := 0; ch < p.Channels; ch++ {
+		for o := 0; o < outLength; o++ {
+			start := ch*p.InLength + o*p.Stride
+			sum := 0.0
+			for k := 0; k < p.PoolSize; k++ {
+				sum += input[start+k]
+			}
+			output[ch*outLength+o] = sum / float64(p.PoolSize)
+		}
+	}
+	return output
+}
+
+// Backward spreads each gradient evenly over its window.
+func (p *AvgPool1D) Backward(outputGrad []float64) []float64 {
+	outLength := p.OutputLength()
+	inputGrad := make([]float64, p.Channels*p.InLength)
+	for ch := 0; ch < p.Channels; ch++ {
+		for o := 0; o < outLength; o++ {
+			start := ch*p.InLength + o*p.Stride
+			for k := 0; k < p.PoolSize; k++ {
+				inputGrad[start+k] += outputGrad[ch*outLength+o] / float64(p.PoolSize)
+			}
+		}
+	}
+	return inputGrad
+}
+
+// Params returns nil as the layer has no trainable parameters.
+func (p *AvgPool1D) Params() [][]float64 { return nil }
+
+// Grads returns nil as the layer has no trainable parameters.
+func (p *AvgPool1D) Grads() [][]float64 { return nil }
+
+// GlobalAveragePooling reduces every channel to its mean value. It works for any
+// channel-first input, whether each channel holds a sequence or an image.
+type GlobalAveragePooling struct {
+	Channels int `json:"channels"`
+	Size     int `json:"size"`
+}
+
+// NewGlobalAveragePooling creates a pooling layer for channels blocks of size values.
+func NewGlobalAveragePooling(channels, size int) *GlobalAveragePooling {
+	return &GlobalAveragePooling{Channels: channels, Size: size}
+}
+
+// Type returns the registered name of the layer.
+func (p *GlobalAveragePooling) Type() string { return "globalavgpool" }
+
+// Forward averages each channel.
+func (p *GlobalAveragePooling) Forward(input []float64, training bool) []float64 {
+	output := make([]float64, p.Channels)
+	for ch := range output {
+		for _, val := range input[ch*p.Size : (ch+1)*p.Size] {
+			output[ch] += val
+		}
+		output[ch] /= float64(p.Size)
+	}
+	return output
+}
+
+// Backward spreads each channel gradient evenly over the channel.
+func (p *GlobalAveragePooling) Backward(outputGrad []float64) []float64 {
+	inputGrad := make([]float64, p.Channels*p.Size)
+	for ch, grad := range outputGrad {
+		for i := ch * p.Size; i < (ch+1)*p.Size; i++ {
+			inputGrad[i] = grad / float64(p.Size)
+		}
+	}
+	return inputGrad
+}
+
+// Params returns nil as the layer has no trainable parameters.
+func (p *GlobalAveragePooling) Params() [][]float64 { return nil }
+
+// Grads returns nil as the layer has no trainable parameters.
+func (p *GlobalAveragePooling) Grads() [][]float64 { return nil }
@@ -0,0 +1,393 @@
+package neuralnetwork
+
+import (
+	"math"
+	"math/rand"
+)
+
+// recurrent holds the configuration and weights shared by the recurrent layers.
+//
+// Inputs are sequences of Steps time steps with InputSize features each, stored time
+// step by time step. The layer outputs the hidden state of the last step, or the hidden
+// states of every step when ReturnSequences is set.
+//
+// The weights of all gates are stacked: InputWeights has gates*Units rows of InputSize
+// values and RecurrentWeights has gates*Units rows of Units values.
+type recurrent struct {
+	InputSize        int         `json:"inputSize"`
+	Units            int         `json:"units"`
+	Steps            int         `json:"steps"`
+	ReturnSequences  bool        `json:"returnSequences"`
+	BPTTSteps        int         `json:"bpttSteps"`
+	InputWeights     [][]float64 `json:"inputWeights"`
+	RecurrentWeights [][]float64 `json:"recurrentWeights"`
+	Biases           []float64   `json:"biases"`
+
+	inputWeightGrads     [][]float64
+	recurrentWeightGrads [][]float64
+	biasGrads            []float64
+}
+
+// newRecurrent allocates and initializes the weights for the given number of gates.
+func newRecurrent(gates, inputSize, units, steps int, returnSequences bool, bpttSteps int) recurrent {
+	r := recurrent{
+		InputSize:        inputSize,
+		Units:            units,
+		Steps:            steps,
+		ReturnSequences:  returnSequences,
+		BPTTSteps:        bpttSteps,
+		InputWeights:     newMatrix(gates*units, inputSize),
+		RecurrentWeights: newMatrix(gates*units, units),
+		Biases:           make([]float64, gates*units),
+	}
+	inputScale := math.Sqrt(1.0 / float64(inputSize))
+	recurrentScale := math.Sqrt(1.0 / float64(units))
+	for i := range r.InputWeights {
+		for j := range r.InputWeights[i] {
+			r.InputWeights[i][j] = rand.NormFloat64() * inputScale
+		}
+		for j := range r.RecurrentWeights[i] {
+			r.RecurrentWeights[i][j] = rand.NormFloat64() * recurrentScale
+		}
+	}
+	r.initialize()
+	return r
+}
+
+func (r *recurrent) initialize() error {
+	r.inputWeightGrads = newMatrix(len(r.InputWeights), r.InputSize)
+	r.recurrentWeightGrads = newMatrix(len(r.RecurrentWeights), r.Units)
+	r.biasGrads = make([]float64, len(r.Biases))
+	return nil
+}
+
+// OutputSize returns the number of values the layer produces per sample.
+func (r *recurrent) OutputSize() int {
+	if r.ReturnSequences {
+		return r.Steps * r.Units
+	}
+	return r.Units
+}
+
+// step returns the input features of time step t.
+func (r *recurrent) step(input []float64, t int) []float64 {
+	return input[t*r.InputSize : (t+1)*r.InputSize]
+}
+
+// preactivation computes InputWeights*x + RecurrentWeights*h + Biases for the given gate.
+func (r *recurrent) preactivation(gate int, x, h []float64) []float64 {
+	z := make([]float64, r.Units)
+	for u := range z {
+		row := gate*r.Units + u
+		sum := r.Biases[row]
+		for j, val := range x {
+			sum += r.InputWeights[row][j] * val
+		}
+		for j, val := range h {
+			sum += r.RecurrentWeights[row][j] * val
+		}
+		z[u] = sum
+	}
+	return z
+}
+
+// accumulate adds the gradients of a gate's pre-activation dz to the weight gradients and
+// adds its contribution to the input and hidden state gradients.
+func (r *recurrent) accumulate(gate int, dz, x, h, dx, dh []float64) {
+	for u, grad := range dz {
+		row := gate*r.Units + u
+		r.biasGrads[row] += grad
+		for j, val := range x {
+			r.inputWeightGrads[row][j] += grad * val
+			dx[j] += grad * r.InputWeights[row][j]
+		}
+		for j, val := range h {
+			r.recurrentWeightGrads[row][j] += grad * val
+			if dh != nil {
+				dh[j] += grad * r.RecurrentWeights[row][j]
+			}
+		}
+	}
+}
+
+// collect builds the layer output from the hidden states of every step.
+func (r *recurrent) collect(hidden [][]float64) []float64 {
+	if !r.ReturnSequences {
+		return append([]float64(nil), hidden[len(hidden)-1]...)
+	}
+	output := make([]float64, 0, r.Steps*r.Units)
+	for _, h := range hidden[1:] {
+		output = append(output, h...)
+	}
+	return output
+}
+
+// outputGradAt returns the part of the output gradient that belongs to step t.
+func (r *recurrent) outputGradAt(outputGrad []float64, t int) []float64 {
+	if r.ReturnSequences {
+		return outputGrad[t*r.Units : (t+1)*r.Units]
+	}
+	if t == r.Steps-1 {
+		return outputGrad
+	}
+	return nil
+}
+
+// truncate reports whether the gradient carried back from step t to step t-1 should be
+// dropped. With BPTTSteps set the sequence is processed backwards in chunks of that many
+// steps and no gradient flows between chunks.
+func (r *recurrent) truncate(t int) bool {
+	return r.BPTTSteps > 0 && (r.Steps-t)%r.BPTTSteps == 0
+}
+
+// Params returns the input weights, recurrent weights and biases.
+func (r *recurrent) Params() [][]float64 {
+	params := append(append([][]float64{}, r.InputWeights...), r.RecurrentWeights...)
+	return append(params, r.Biases)
+}
+
+// Grads returns the gradients aligned with Params.
+func (r *recurrent) Grads() [][]float64 {
+	grads := append(append([][]float64{}, r.inputWeightGrads...), r.recurrentWeightGrads...)
+	return append(grads, r.biasGrads)
+}
+
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+// SimpleRNN is a fully connected recurrent layer: h_t = tanh(W x_t + U h_{t-1} + b).
+type SimpleRNN struct {
+	recurrent
+
+	input  []float64
+	hidden [][]float64
+}
+
+// NewSimpleRNN creates a simple recurrent layer. bpttSteps limits how many steps gradients
+// are carried back through time; zero means the whole sequence.
+func NewSimpleRNN(inputSize, units, steps int, returnSequences bool, bpttSteps int) *SimpleRNN {
+	return &SimpleRNN{recurrent: newRecurrent(1, inputSize, units, steps, returnSequences, bpttSteps)}
+}
+
+// Type returns the registered name of the layer.
+func (l *SimpleRNN) Type() string { return "simplernn" }
+
+// Forward runs the sequence through the layer. hidden[t+1] is the state after step t.
+func (l *SimpleRNN) Forward(input []float64, training bool) []float64 {
+	l.input = input
+	l.hidden = [][]float64{make([]float64, l.Units)}
+	for t := 0; t < l.Steps; t++ {
+		h := l.preactivation(0, l.step(input, t), l.hidden[t])
+		for u := range h {
+			h[u] = math.Tanh(h[u])
+		}
+		l.hidden = append(l.hidden, h)
+	}
+	return l.collect(l.hidden)
+}
+
+// Backward propagates the gradient back through time.
+func (l *SimpleRNN) Backward(outputGrad []float64) []float64 {
+	inputGrad := make([]float64, len(l.input))
+	dhNext := make([]float64, l.Units)
+	for t := l.Steps - 1; t >= 0; t-- {
+		dh := dhNext
+		if g := l.outputGradAt(outputGrad, t); g != nil {
+			for u := range dh {
+				dh[u] += g[u]
+			}
+		}
+		h := l.hidden[t+1]
+		dz := make([]float64, l.Units)
+		for u := range dz {
+			dz[u] = dh[u] * (1 - h[u]*h[u])
+		}
+		dhNext = make([]float64, l.Units)
+		if l.truncate(t) {
+			l.accumulate(0, dz, l.step(l.input, t), l.hidden[t], inputGrad[t*l.InputSize:(t+1)*l.InputSize], nil)
+		} else {
+			l.accumulate(0, dz, l.step(l.input, t), l.hidden[t], inputGrad[t*l.InputSize:(t+1)*l.InputSize], dhNext)
+		}
+	}
+	return inputGrad
+}
+
+// LSTM is a long short-term memory layer with input, forget, cell and output gates,
+// stacked in that order in the weight matrices.
+type LSTM struct {
+	recurrent
+
+	input               []float64
+	hidden, cells       [][]float64
+	inputs, forgets, gs [][]float64
+	outputs, tanhCells  [][]float64
+}
+
+// NewLSTM creates an LSTM layer. The forget gate bias starts at one so that the cell
+// state is carried forward early in training.
+func NewLSTM(inputSize, units, steps int, returnSequences bool, bpttSteps int) *LSTM {
+	l := &LSTM{recurrent: newRecurrent(4, inputSize, units, steps, returnSequences, bpttSteps)}
+	for u := 0; u < units; u++ {
+		l.Biases[units+u] = 1
+	}
+	return l
+}
+
+// Type returns the registered name of the layer.
+func (l *LSTM) Type() string { return "lstm" }
+
+// Forward runs the sequence through the layer. hidden[t+1] and cells[t+1] are the states after step t.
+func (l *LSTM) Forward(input []float64, training bool) []float64 {
+	l.input = input
+	l.hidden = [][]float64{make([]float64, l.Units)}
+	l.cells = [][]float64{make([]float64, l.Units)}
+	l.inputs, l.forgets, l.gs, l.outputs, l.tanhCells = nil, nil, nil, nil, nil
+	for t := 0; t < l.Steps; t++ {
+		x, hPrev, cPrev := l.step(input, t), l.hidden[t], l.cells[t]
+		i := l.preactivation(0, x, hPrev)
+		f := l.preactivation(1, x, hPrev)
+		g := l.preactivation(2, x, hPrev)
+		o := l.preactivation(3, x, hPrev)
+		c := make([]float64, l.Units)
+		tanhC := make([]float64, l.Units)
+		h := make([]float64, l.Units)
+		for u := range h {
+			i[u], f[u], g[u], o[u] = sigmoid(i[u]), sigmoid(f[u]), math.Tanh(g[u]), sigmoid(o[u])
+			c[u] = f[u]*cPrev[u] + i[u]*g[u]
+			tanhC[u] = math.Tanh(c[u])
+			h[u] = o[u] * tanhC[u]
+		}
+		l.inputs, l.forgets, l.gs, l.outputs = append(l.inputs, i), append(l.forgets, f), append(l.gs, g), append(l.outputs, o)
+		l.cells, l.tanhCells, l.hidden = append(l.cells, c), append(l.tanhCells, tanhC), append(l.hidden, h)
+	}
+	return l.collect(l.hidden)
+}
+
+// Backward propagates the gradient back through time.
+func (l *LSTM) Backward(outputGrad []float64) []float64 {
+	inputGrad := make([]float64, len(l.input))
+	dhNext := make([]float64, l.Units)
+	dcNext := make([]float64, l.Units)
+	for t := l.Steps - 1; t >= 0; t-- {
+		dh := dhNext
+		if g := l.outputGradAt(outputGrad, t); g != nil {
+			for u := range dh {
+				dh[u] += g[u]
+			}
+		}
+		i, f, g, o := l.inputs[t], l.forgets[t], l.gs[t], l.outputs[t]
+		di, df, dg, do := make([]float64, l.Units), make([]float64, l.Units), make([]float64, l.Units), make([]float64, l.Units)
+		dcPrev := make([]float64, l.Units)
+		for u := range dh {
+			dc := dcNext[u] + dh[u]*o[u]*(1-l.tanhCells[t][u]*l.tanhCells[t][u])
+			do[u] = dh[u] * l.tanhCells[t][u] * o[u] * (1 - o[u])
+			di[u] = dc * g[u] * i[u] * (1 - i[u])
+			df[u] = dc * l.cells[t][u] * f[u] * (1 - f[u])
+			dg[u] = dc * i[u] * (1 - g[u]*g[u])
+			dcPrev[u] = dc * f[u]
+		}
+
+		x, hPrev := l.step(l.input, t), l.hidden[t]
+		dx := inputGrad[t*l.InputSize : (t+1)*l.InputSize]
+		dhNext = make([]float64, l.Units)
+		carry := dhNext
+		if l.truncate(t) {
+			carry = nil
+			dcPrev = make([]float64, l.Units)
+		}
+		for gate, dz := range [][]float64{di, df, dg, do} {
+			l.accumulate(gate, dz, x, hPrev, dx, carry)
+		}
+		dcNext = dcPrev
+	}
+	return inputGrad
+}
+
+// GRU is a gated recurrent unit layer with update, reset and candidate gates, stacked in
+// that order in the weight matrices. The reset gate is applied to the previous hidden
+// state before the recurrent weights of the candidate.
+type GRU struct {
+	recurrent
+
+	input                  []float64
+	hidden                 [][]float64
+	updates, resets, cands [][]float64
+	resetHidden            [][]float64
+}
+
+// NewGRU creates a GRU layer.
+func NewGRU(inputSize, units, steps int, returnSequences bool, bpttSteps int) *GRU {
+	return &GRU{recurrent: newRecurrent(3, inputSize, units, steps, returnSequences, bpttSteps)}
+}
+
+// Type returns the registered name of the layer.
+func (l *GRU) Type() string { return "gru" }
+
+// Forward runs the sequence through the layer. hidden[t+1] is the state after step t.
+func (l *GRU) Forward(input []float64, training bool) []float64 {
+	l.input = input
+	l.hidden = [][]float64{make([]float64, l.Units)}
+	l.updates, l.resets, l.cands, l.resetHidden = nil, nil, nil, nil
+	for t := 0; t < l.Steps; t++ {
+		x, hPrev := l.step(input, t), l.hidden[t]
+		z := l.preactivation(0, x, hPrev)
+		r := l.preactivation(1, x, hPrev)
+		rh := make([]float64, l.Units)
+		for u := range r {
+			z[u], r[u] = sigmoid(z[u]), sigmoid(r[u])
+			rh[u] = r[u] * hPrev[u]
+		}
+		n := l.preactivation(2, x, rh)
+		h := make([]float64, l.Units)
+		for u := range n {
+			n[u] = math.Tanh(n[u])
+			h[u] = (1-z[u])*n[u] + z[u]*hPrev[u]
+		}
+		l.updates, l.resets, l.cands, l.resetHidden = append(l.updates, z), append(l.resets, r), append(l.cands, n), append(l.resetHidden, rh)
+		l.hidden = append(l.hidden, h)
+	}
+	return l.collect(l.hidden)
+}
+
+// Backward propagates the gradient back through time.
+func (l *GRU) Backward(outputGrad []float64) []float64 {
+	inputGrad := make([]float64, len(l.input))
+	dhNext := make([]float64, l.Units)
+	for t := l.Steps - 1; t >= 0; t-- {
+		dh := dhNext
+		if g := l.outputGradAt(outputGrad, t); g != nil {
+			for u := range dh {
+				dh[u] += g[u]
+			}
+		}
+		x, hPrev := l.step(l.input, t), l.hidden[t]
+		z, r, n := l.updates[t], l.resets[t], l.cands[t]
+		dx := inputGrad[t*l.InputSize : (t+1)*l.InputSize]
+
+		dz, dn := make([]float64, l.Units), make([]float64, l.Units)
+		dhPrev := make([]float64, l.Units)
+		for u := range dh {
+			dn[u] = dh[u] * (1 - z[u]) * (1 - n[u]*n[u])
+			dz[u] = dh[u] * (hPrev[u] - n[u]) * z[u] * (1 - z[u])
+			dhPrev[u] = dh[u] * z[u]
+		}
+
+		// The candidate sees r*hPrev, so its recurrent gradient is split between r and hPrev.
+		dResetHidden := make([]float64, l.Units)
+		l.accumulate(2, dn, x, l.resetHidden[t], dx, dResetHidden)
+		dr := make([]float64, l.Units)
+		for u := range dr {
+			dr[u] = dResetHidden[u] * hPrev[u] * r[u] * (1 - r[u])
+			dhPrev[u] += dResetHidden[u] * r[u]
+		}
+		l.accumulate(0, dz, x, hPrev, dx, dhPrev)
+		l.accumulate(1, dr, x, hPrev, dx, dhPrev)
+
+		if l.truncate(t) {
+			dhPrev = make([]float64, l.Units)
+		}
+		dhNext = dhPrev
+	}
+	return inputGrad
+}
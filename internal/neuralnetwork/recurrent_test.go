@@ -0,0 +1,66 @@
+package neuralnetwork_test
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+
+	"go-neuralnetwork/internal/neuralnetwork"
+)
+
+func TestRecurrentGradCheck(t *testing.T) {
+	specs := []string{"rnn(4)", "lstm(3)", "gru(3)", "lstm(3,seq),gru(2)", "gru(3,seq),layernorm,rnn(2,seq)"}
+	input := make([]float64, 10)
+	for i := range input {
+		input[i] = math.Sin(float64(i) * 0.9)
+	}
+
+	for _, spec := range specs {
+		t.Run(spec, func(t *testing.T) {
+			seq, err := neuralnetwork.BuildSequential([]int{5, 2}, spec, nil, 2, "tanh")
+			if err != nil {
+				t.Fatalf("Failed to build model: %v", err)
+			}
+			for _, result := range neuralnetwork.GradCheckSequential(seq, input, []float64{0.3, -0.2}, 1e-6) {
+				if result.MaxRelativeError > gradCheckTolerance {
+					t.Errorf("Layer %s: relative gradient error %e exceeds %e", result.Layer, result.MaxRelativeError, gradCheckTolerance)
+				}
+			}
+
+			encoded, err := json.Marshal(seq)
+			if err != nil {
+				t.Fatalf("Failed to encode model: %v", err)
+			}
+			var decoded neuralnetwork.Sequential
+			if err := json.Unmarshal(encoded, &decoded); err != nil {
+				t.Fatalf("Failed to decode model: %v", err)
+			}
+			if !reflect.DeepEqual(seq.Predict(input), decoded.Predict(input)) {
+				t.Errorf("Decoded model predicts differently from the original")
+			}
+		})
+	}
+}
+
+func TestTruncatedBPTT(t *testing.T) {
+	layers := []neuralnetwork.Layer{
+		neuralnetwork.NewSimpleRNN(1, 3, 6, false, 2),
+		neuralnetwork.NewLSTM(1, 3, 6, false, 2),
+		neuralnetwork.NewGRU(1, 3, 6, false, 2),
+	}
+	input := []float64{0.1, -0.4, 0.7, 0.2, -0.9, 0.5}
+	for _, layer := range layers {
+		layer.Forward(input, true)
+		inputGrad := layer.Backward([]float64{1, 1, 1})
+		// Only the last two steps receive gradient from the final hidden state.
+		for step, grad := range inputGrad {
+			if step < 4 && grad != 0 {
+				t.Errorf("%s: expected no gradient at step %d, got %f", layer.Type(), step, grad)
+			}
+			if step >= 4 && grad == 0 {
+				t.Errorf("%s: expected a gradient at step %d", layer.Type(), step)
+			}
+		}
+	}
+}
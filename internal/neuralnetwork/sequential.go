@@ -0,0 +1,200 @@
+package neuralnetwork
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Sequential is a model built from a stack of layers applied one after another.
+type Sequential struct {
+	Layers   []Layer
+	LossName string
+
+	loss Loss
+}
+
+// NewSequential creates a model from the given layers, trained with the named loss function.
+func NewSequential(lossName string, layers ...Layer) (*Sequential, error) {
+	s := &Sequential{Layers: layers, LossName: lossName}
+	if err := s.SetLoss(lossName); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// InitSequential builds the same dense architecture as InitNetwork out of Dense and
+// ActivationLayer layers, trained with mean squared error.
+func InitSequential(inputs int, hiddenLayers []int, outputs int, hiddenActivations []string, outputActivation string) (*Sequential, error) {
+	if len(hiddenActivations) != len(hiddenLayers) {
+		return nil, fmt.Errorf("expected %d hidden activations, got %d", len(hiddenLayers), len(hiddenActivations))
+	}
+	var layers []Layer
+	prevLayerSize := inputs
+	for i, layerSize := range hiddenLayers {
+		activation, err := NewActivationLayer(hiddenActivations[i])
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, NewDense(prevLayerSize, layerSize), activation)
+		prevLayerSize = layerSize
+	}
+	activation, err := NewActivationLayer(outputActivation)
+	if err != nil {
+		return nil, err
+	}
+	layers = append(layers, NewDense(prevLayerSize, outputs), activation)
+	return NewSequential("mse", layers...)
+}
+
+// NewSequentialFromNetwork converts a NeuralNetwork into an equivalent Sequential model.
+// The returned model shares its weight and bias storage with nn.
+func NewSequentialFromNetwork(nn *NeuralNetwork) (*Sequential, error) {
+	var layers []Layer
+	prevLayerSize := nn.NumInputs
+	for i, layerSize := range nn.HiddenLayers {
+		dense := &Dense{NumInputs: prevLayerSize, NumOutputs: layerSize, Weights: nn.HiddenWeights[i], Biases: nn.HiddenBiases[i]}
+		dense.initialize()
+		activation, err := NewActivationLayer(nn.HiddenActivations[i])
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, dense, activation)
+		prevLayerSize = layerSize
+	}
+	dense := &Dense{NumInputs: prevLayerSize, NumOutputs: nn.NumOutputs, Weights: nn.OutputWeights, Biases: nn.OutputBiases}
+	dense.initialize()
+	activation, err := NewActivationLayer(nn.OutputActivation)
+	if err != nil {
+		return nil, err
+	}
+	layers = append(layers, dense, activation)
+	return NewSequential("mse", layers...)
+}
+
+// SetLoss sets the loss function used for training.
+func (s *Sequential) SetLoss(name string) error {
+	loss, err := GetLoss(name)
+	if err != nil {
+		return err
+	}
+	s.LossName = name
+	s.loss = loss
+	return nil
+}
+
+// Forward runs the input through every layer.
+func (s *Sequential) Forward(input []float64, training bool) []float64 {
+	output := input
+	for _, layer := range s.Layers {
+		output = layer.Forward(output, training)
+	}
+	return output
+}
+
+// Predict runs the model in inference mode.
+func (s *Sequential) Predict(input []float64) []float64 {
+	return s.Forward(input, false)
+}
+
+// Backward propagates the loss gradient through the layers in reverse order,
+// accumulating parameter gradients along the way.
+func (s *Sequential) Backward(outputGrad []float64) []float64 {
+	grad := outputGrad
+	for i := len(s.Layers) - 1; i >= 0; i-- {
+		grad = s.Layers[i].Backward(grad)
+	}
+	return grad
+}
+
+// Step applies one gradient descent update with the accumulated gradients and resets them.
+func (s *Sequential) Step(learningRate float64) {
+	for _, layer := range s.Layers {
+		grads := layer.Grads()
+		for i, param := range layer.Params() {
+			for j := range param {
+				param[j] -= learningRate * grads[i][j]
+			}
+		}
+		zeroGrads(layer)
+	}
+}
+
+// ComputeLoss returns the loss for a single sample and its gradient with respect to the output.
+func (s *Sequential) ComputeLoss(predicted, targets []float64) (float64, []float64) {
+	loss := s.lossFunction()
+	return loss.Compute(predicted, targets), loss.Gradient(predicted, targets)
+}
+
+// lossFunction returns the loss the model is trained with: that of the last layer if it
+// is also a Loss, as Heads is, and the named loss otherwise.
+func (s *Sequential) lossFunction() Loss {
+	if len(s.Layers) > 0 {
+		if loss, ok := s.Layers[len(s.Layers)-1].(Loss); ok {
+			return loss
+		}
+	}
+	return s.loss
+}
+
+// NumParams returns the total number of trainable parameters.
+func (s *Sequential) NumParams() int {
+	count := 0
+	for _, layer := range s.Layers {
+		for _, param := range layer.Params() {
+			count += len(param)
+		}
+	}
+	return count
+}
+
+// Train trains the model with per-sample gradient descent. It mirrors NeuralNetwork.Train:
+// the average loss of every epoch is sent on progressChan, which is closed when training ends.
+func (s *Sequential) Train(inputs, targets [][]float64, epochs int, learningRate float64, errorGoal float64, progressChan chan<- any) {
+	defer close(progressChan)
+
+	for range make([]struct{}, epochs) {
+		totalError := 0.0
+		for i := range inputs {
+			output := s.Forward(inputs[i], true)
+			loss, grad := s.ComputeLoss(output, targets[i])
+			s.Backward(grad)
+			s.Step(learningRate)
+			totalError += loss
+		}
+		avgError := totalError / float64(len(inputs))
+
+		progressChan <- avgError
+
+		if avgError < errorGoal {
+			break
+		}
+	}
+}
+
+type sequentialJSON struct {
+	Layers []layerJSON `json:"layers"`
+	Loss   string      `json:"loss"`
+}
+
+// MarshalJSON encodes the model as a list of typed layers and the loss name.
+func (s *Sequential) MarshalJSON() ([]byte, error) {
+	layers, err := marshalLayers(s.Layers)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(sequentialJSON{Layers: layers, Loss: s.LossName})
+}
+
+// UnmarshalJSON decodes a model written by MarshalJSON.
+func (s *Sequential) UnmarshalJSON(b []byte) error {
+	var decoded sequentialJSON
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return err
+	}
+	layers, err := unmarshalLayers(decoded.Layers)
+	if err != nil {
+		return err
+	}
+	s.Layers = layers
+	return s.SetLoss(decoded.Loss)
+}
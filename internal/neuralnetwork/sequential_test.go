@@ -0,0 +1,176 @@
+package neuralnetwork_test
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"reflect"
+	"testing"
+
+	"go-neuralnetwork/internal/data"
+	"go-neuralnetwork/internal/neuralnetwork"
+	"go-neuralnetwork/internal/tempfile"
+)
+
+const floatTolerance = 1e-9
+
+func TestSequentialMatchesNeuralNetwork(t *testing.T) {
+	nn := &neuralnetwork.NeuralNetwork{
+		NumInputs:         2,
+		HiddenLayers:      []int{2},
+		NumOutputs:        1,
+		HiddenWeights:     [][][]float64{{{0.1, 0.2}, {0.3, 0.4}}},
+		OutputWeights:     [][]float64{{0.5, 0.6}},
+		HiddenBiases:      [][]float64{{0.0, 0.0}},
+		OutputBiases:      []float64{0.0},
+		HiddenActivations: []string{"sigmoid"},
+		OutputActivation:  "sigmoid",
+	}
+	nn.SetActivationFunctions()
+
+	// Convert a copy so both models can be trained independently.
+	encoded, _ := json.Marshal(nn)
+	var nnCopy neuralnetwork.NeuralNetwork
+	json.Unmarshal(encoded, &nnCopy)
+	nnCopy.SetActivationFunctions()
+	seq, err := neuralnetwork.NewSequentialFromNetwork(&nnCopy)
+	if err != nil {
+		t.Fatalf("Failed to convert network: %v", err)
+	}
+
+	inputs := []float64{1.0, 0.5}
+	targets := []float64{1.0}
+
+	_, expected := nn.FeedForward(inputs)
+	got := seq.Predict(inputs)
+	if math.Abs(expected[0]-got[0]) > 1e-12 {
+		t.Fatalf("Prediction mismatch: expected %f, got %f", expected[0], got[0])
+	}
+
+	// One step of plain gradient descent with MSE must match Backpropagate.
+	hiddenOutputs, finalOutputs := nn.FeedForward(inputs)
+	nn.Backpropagate(inputs, targets, hiddenOutputs, finalOutputs, 0.1)
+	_, grad := seq.ComputeLoss(seq.Forward(inputs, true), targets)
+	seq.Backward(grad)
+	seq.Step(0.1)
+
+	dense := seq.Layers[0].(*neuralnetwork.Dense)
+	for i := range nn.HiddenWeights[0] {
+		for j := range nn.HiddenWeights[0][i] {
+			if math.Abs(nn.HiddenWeights[0][i][j]-dense.Weights[i][j]) > 1e-12 {
+				t.Errorf("Hidden weight [%d][%d] mismatch: expected %f, got %f", i, j, nn.HiddenWeights[0][i][j], dense.Weights[i][j])
+			}
+		}
+	}
+	output := seq.Layers[2].(*neuralnetwork.Dense)
+	if math.Abs(nn.OutputBiases[0]-output.Biases[0]) > 1e-12 {
+		t.Errorf("Output bias mismatch: expected %f, got %f", nn.OutputBiases[0], output.Biases[0])
+	}
+}
+
+func TestSequentialJSONRoundTrip(t *testing.T) {
+	relu, _ := neuralnetwork.NewActivationLayer("relu")
+	seq, err := neuralnetwork.NewSequential("binary_crossentropy",
+		neuralnetwork.NewDense(3, 4),
+		neuralnetwork.NewLayerNorm(4),
+		relu,
+		neuralnetwork.NewDropout(0.25),
+		neuralnetwork.NewDense(4, 2),
+	)
+	if err != nil {
+		t.Fatalf("Failed to build model: %v", err)
+	}
+
+	encoded, err := json.Marshal(seq)
+	if err != nil {
+		t.Fatalf("Failed to encode model: %v", err)
+	}
+	var decoded neuralnetwork.Sequential
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Failed to decode model: %v", err)
+	}
+
+	if decoded.LossName != "binary_crossentropy" {
+		t.Errorf("Expected loss binary_crossentropy, got %s", decoded.LossName)
+	}
+	if len(decoded.Layers) != len(seq.Layers) {
+		t.Fatalf("Expected %d layers, got %d", len(seq.Layers), len(decoded.Layers))
+	}
+	for i := range seq.Layers {
+		if !reflect.DeepEqual(seq.Layers[i].Params(), decoded.Layers[i].Params()) {
+			t.Errorf("Layer %d (%s) parameters do not match after decoding", i, seq.Layers[i].Type())
+		}
+	}
+
+	input := []float64{0.1, -0.4, 0.7}
+	if !reflect.DeepEqual(seq.Predict(input), decoded.Predict(input)) {
+		t.Errorf("Decoded model predicts differently from the original")
+	}
+}
+
+func TestLoadLegacyModel(t *testing.T) {
+	nn := neuralnetwork.InitNetwork(2, []int{3}, 1, []string{"tanh"}, "linear")
+	// Models of this version only held the dense network and the input ranges.
+	legacy, err := json.Marshal(map[string]any{
+		"neuralNetwork": nn,
+		"inputMins":     []float64{0, 0},
+		"inputMaxs":     []float64{1, 1},
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode model: %v", err)
+	}
+	filePath, err := tempfile.CreateTempFileWithContent("model-*.json", string(legacy))
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(filePath)
+
+	loaded, err := data.LoadModel(filePath)
+	if err != nil {
+		t.Fatalf("Failed to load model: %v", err)
+	}
+	if loaded.Model == nil {
+		t.Fatal("Expected legacy model to be converted to a Sequential model")
+	}
+	if loaded.Pipeline == nil || len(loaded.Pipeline.Scalers) != 2 {
+		t.Errorf("Expected the input ranges to be converted to a pipeline of 2 scalers, got %+v", loaded.Pipeline)
+	}
+
+	input := []float64{0.3, 0.9}
+	_, expected := nn.FeedForward(input)
+	got := loaded.Model.Predict(input)
+	if math.Abs(expected[0]-got[0]) > 1e-12 {
+		t.Errorf("Converted model prediction mismatch: expected %f, got %f", expected[0], got[0])
+	}
+}
+
+func TestDropout(t *testing.T) {
+	dropout := neuralnetwork.NewDropout(0.5)
+	input := []float64{1, 2, 3, 4}
+
+	if got := dropout.Forward(input, false); !reflect.DeepEqual(got, input) {
+		t.Errorf("Expected dropout to be a no-op at inference, got %v", got)
+	}
+
+	output := dropout.Forward(input, true)
+	for i, val := range output {
+		if val != 0 && math.Abs(val-2*input[i]) > floatTolerance {
+			t.Errorf("Expected kept value to be scaled to %f, got %f", 2*input[i], val)
+		}
+	}
+}
+
+func TestLayerNorm(t *testing.T) {
+	norm := neuralnetwork.NewLayerNorm(2)
+	output := norm.Forward([]float64{1, 3, 10, 20}, false)
+
+	for g := 0; g < 2; g++ {
+		mean := (output[2*g] + output[2*g+1]) / 2
+		if math.Abs(mean) > 1e-6 {
+			t.Errorf("Group %d: expected zero mean, got %f", g, mean)
+		}
+		if math.Abs(output[2*g]+1) > 1e-3 || math.Abs(output[2*g+1]-1) > 1e-3 {
+			t.Errorf("Group %d: expected normalised values close to [-1 1], got %v", g, output[2*g:2*g+2])
+		}
+	}
+}
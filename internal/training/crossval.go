@@ -0,0 +1,144 @@
+package training
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+	"time"
+
+	"go-neuralnetwork/internal/data"
+)
+
+// CVOptions configures CrossValidate.
+type CVOptions struct {
+	// Folds is the number of folds k; every row is tested once per repeat.
+	Folds int
+	// Repeats runs k-fold cross-validation that many times with differently shuffled
+	// folds; 0 means once.
+	Repeats int
+	// Parallel is the number of folds trained at the same time; 0 means one per CPU.
+	Parallel int
+	// Seed divides the rows of the first repeat into folds; later repeats use the
+	// following seeds. 0 picks a seed from the clock.
+	Seed int64
+}
+
+// FoldResult holds the test scores of the model trained on one fold.
+type FoldResult struct {
+	Repeat, Fold int
+	Metrics      []Metric
+	Err          error
+}
+
+// Summary is the mean and standard deviation of one metric over all folds.
+type Summary struct {
+	Name      string
+	Mean, Std float64
+	Values    []float64
+}
+
+// CVResult holds the scores of every fold and their summary per metric.
+type CVResult struct {
+	Folds     []FoldResult
+	Summaries []Summary
+}
+
+// CrossValidate runs k-fold cross-validation: for every fold it calls load to get the
+// dataset whose test rows are that fold, trains a fresh network with the same Config on
+// the other rows and scores it with Evaluate. Stratified or group folds come from the
+// Split strategy of the LoadOptions that load passes the fold to.
+//
+// Folds are trained in parallel. The result of every fold is sent to progress as soon
+// as it is done, and progress is closed at the end; it may be nil. A fold that fails
+// fails the whole run.
+func CrossValidate(load func(data.Fold) (*data.Dataset, error), cfg Config, opts CVOptions, progress chan<- FoldResult) (*CVResult, error) {
+	if progress != nil {
+		defer close(progress)
+	}
+	if opts.Folds < 2 {
+		return nil, fmt.Errorf("cross-validation needs at least 2 folds, got %d", opts.Folds)
+	}
+	repeats := max(opts.Repeats, 1)
+	parallel := opts.Parallel
+	if parallel < 1 {
+		parallel = runtime.NumCPU()
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	results := make([]FoldResult, repeats*opts.Folds)
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for i := range results {
+		results[i] = FoldResult{Repeat: i / opts.Folds, Fold: i % opts.Folds}
+		wg.Add(1)
+		go func(result *FoldResult) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			result.Metrics, result.Err = runFold(load, cfg, data.Fold{K: opts.Folds, Index: result.Fold, Seed: seed + int64(result.Repeat)})
+			if progress != nil {
+				mu.Lock()
+				progress <- *result
+				mu.Unlock()
+			}
+		}(&results[i])
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		if result.Err != nil {
+			return nil, fmt.Errorf("repeat %d, fold %d: %w", result.Repeat+1, result.Fold+1, result.Err)
+		}
+	}
+	return &CVResult{Folds: results, Summaries: Summarize(results)}, nil
+}
+
+// runFold trains and scores the model of one fold.
+func runFold(load func(data.Fold) (*data.Dataset, error), cfg Config, fold data.Fold) ([]Metric, error) {
+	dataset, err := load(fold)
+	if err != nil {
+		return nil, err
+	}
+	md, err := Train(dataset, cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+	return Evaluate(md, dataset), nil
+}
+
+// Summarize returns the mean and the sample standard deviation of every metric over the
+// folds, in the order of the metrics of the first fold.
+func Summarize(results []FoldResult) []Summary {
+	if len(results) == 0 {
+		return nil
+	}
+	summaries := make([]Summary, len(results[0].Metrics))
+	for i, metric := range results[0].Metrics {
+		summaries[i].Name = metric.Name
+		for _, result := range results {
+			summaries[i].Values = append(summaries[i].Values, result.Metrics[i].Value)
+		}
+		summaries[i].Mean, summaries[i].Std = meanStd(summaries[i].Values)
+	}
+	return summaries
+}
+
+// meanStd returns the mean and the sample standard deviation of values.
+func meanStd(values []float64) (mean, std float64) {
+	for _, val := range values {
+		mean += val
+	}
+	mean /= float64(len(values))
+	if len(values) < 2 {
+		return mean, 0
+	}
+	for _, val := range values {
+		std += (val - mean) * (val - mean)
+	}
+	return mean, math.Sqrt(std / float64(len(values)-1))
+}
@@ -0,0 +1,85 @@
+package training_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"go-neuralnetwork/internal/data"
+	"go-neuralnetwork/internal/tempfile"
+	"go-neuralnetwork/internal/training"
+)
+
+func TestCrossValidate(t *testing.T) {
+	var csv strings.Builder
+	csv.WriteString("x,y,label\n")
+	for i := 0; i < 12; i++ {
+		label := "low"
+		if i >= 6 {
+			label = "high"
+		}
+		fmt.Fprintf(&csv, "%d,%d,%s\n", i, 12-i, label)
+	}
+	filePath, err := tempfile.CreateTempFileWithContent("crossval-*.csv", csv.String())
+	if err != nil {
+		t.Fatalf("Failed to create temp CSV: %v", err)
+	}
+	defer os.Remove(filePath)
+
+	load := func(fold data.Fold) (*data.Dataset, error) {
+		return data.LoadCSVWithOptions(filePath, data.LoadOptions{Split: data.SplitStratified, Fold: &fold})
+	}
+	cfg := training.Config{Layers: "4", HiddenActivations: []string{"tanh"}, OutputActivation: "sigmoid", Epochs: 5, LearningRate: 0.05}
+	progress := make(chan training.FoldResult)
+	done := make(chan int)
+	go func() {
+		n := 0
+		for range progress {
+			n++
+		}
+		done <- n
+	}()
+	result, err := training.CrossValidate(load, cfg, training.CVOptions{Folds: 3, Repeats: 2, Parallel: 2, Seed: 1}, progress)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if n := <-done; n != 6 || len(result.Folds) != 6 {
+		t.Errorf("Expected 6 fold results, got %d sent and %d returned", n, len(result.Folds))
+	}
+	names := map[string]bool{}
+	for _, summary := range result.Summaries {
+		names[summary.Name] = true
+		if len(summary.Values) != 6 || summary.Std < 0 {
+			t.Errorf("Expected 6 values and a non-negative deviation for %s, got %+v", summary.Name, summary)
+		}
+	}
+	if !names["loss"] || !names["accuracy"] {
+		t.Errorf("Expected loss and accuracy summaries, got %+v", result.Summaries)
+	}
+
+	if _, err := training.CrossValidate(load, cfg, training.CVOptions{Folds: 1}, nil); err == nil {
+		t.Errorf("Expected an error for a single fold, got nil")
+	}
+	cfg.Layers = "bogus("
+	if _, err := training.CrossValidate(load, cfg, training.CVOptions{Folds: 2}, nil); err == nil {
+		t.Errorf("Expected an error for an invalid architecture, got nil")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	summaries := training.Summarize([]training.FoldResult{
+		{Metrics: []training.Metric{{Name: "accuracy", Value: 0.5}}},
+		{Metrics: []training.Metric{{Name: "accuracy", Value: 0.7}}},
+		{Metrics: []training.Metric{{Name: "accuracy", Value: 0.9}}},
+	})
+	if len(summaries) != 1 || summaries[0].Name != "accuracy" {
+		t.Fatalf("Expected one accuracy summary, got %+v", summaries)
+	}
+	if diff := summaries[0].Mean - 0.7; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("Expected mean 0.7, got %f", summaries[0].Mean)
+	}
+	if diff := summaries[0].Std - 0.2; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("Expected standard deviation 0.2, got %f", summaries[0].Std)
+	}
+}
@@ -0,0 +1,178 @@
+package training
+
+import (
+	"go-neuralnetwork/internal/data"
+	"go-neuralnetwork/internal/metrics"
+)
+
+// Metric is one named score of a model on a test set.
+type Metric struct {
+	Name  string
+	Value float64
+}
+
+// Evaluation holds the test results of a trained model. One of Heads, MultiLabel,
+// Classification and Regression is set, by the kind of model.
+type Evaluation struct {
+	// Loss is the mean loss of the model on the rows.
+	Loss float64
+	// Heads holds the results of every head of a multi-task model.
+	Heads []HeadEvaluation
+	// MultiLabel holds the scores of a multi-label model.
+	MultiLabel *metrics.MultiLabel
+	// Classification holds the scores of a classification model.
+	Classification *metrics.Classification
+	// Regression and Residuals hold the metrics and the residual summary of every target
+	// of a regression model, on the scale of the target column, named by TargetNames.
+	Regression  []metrics.Regression
+	Residuals   []metrics.Residuals
+	TargetNames []string
+	// Inputs is the number of input features, for the adjusted R² of regression models.
+	Inputs int
+}
+
+// HeadEvaluation is the test result of one output head of a multi-task model: the
+// scores of a classification head or the metrics of a regression head on the scale of
+// its column.
+type HeadEvaluation struct {
+	Name           string
+	Classification *metrics.Classification
+	Regression     *metrics.Regression
+}
+
+// residualBins is the number of bins of the residual histogram and of the residuals by
+// prediction, and largestResiduals the number of largest errors listed.
+const (
+	residualBins     = 8
+	largestResiduals = 5
+)
+
+// Evaluate scores a trained model on the test rows of its dataset: the mean loss, then
+// the accuracy, macro and weighted F1, log loss, Cohen's kappa and MCC of classification
+// models, the MAE, RMSE, MAPE, median absolute error, R², adjusted R² and explained
+// variance of every target of regression models on the scale of the target column, the
+// subset accuracy, Hamming loss and micro and macro F1 of multi-label models, and the
+// scores of every head of multi-task models.
+func Evaluate(md *data.ModelData, dataset *data.Dataset) []Metric {
+	return EvaluateRows(md, dataset.TestInputs, dataset.TestTargets)
+}
+
+// EvaluateRows scores a trained model like Evaluate, on the given rows.
+func EvaluateRows(md *data.ModelData, inputs, targets [][]float64) []Metric {
+	return Assess(md, inputs, targets).Metrics()
+}
+
+// Assess evaluates a trained model on the given rows and returns its full results,
+// from which Evaluate takes its scores.
+func Assess(md *data.ModelData, inputs, targets [][]float64) *Evaluation {
+	e := &Evaluation{Loss: MeanLoss(md.Model, inputs, targets)}
+	predictions := make([][]float64, len(inputs))
+	for i, input := range inputs {
+		predictions[i] = md.Model.Predict(input)
+	}
+	if len(inputs) > 0 {
+		e.Inputs = len(inputs[0])
+	}
+
+	switch {
+	case len(md.Heads) > 0:
+		for _, head := range md.Heads {
+			result := HeadEvaluation{Name: head.Name}
+			if head.ClassMap != nil {
+				var scores, actual [][]float64
+				for i, prediction := range predictions {
+					scores = append(scores, head.Output(prediction))
+					actual = append(actual, head.Output(targets[i]))
+				}
+				r := metrics.EvaluateClassification(scores, actual)
+				result.Classification = &r
+			} else {
+				var predicted, actual [][]float64
+				for i, prediction := range predictions {
+					predicted = append(predicted, []float64{head.Denormalize(prediction)})
+					actual = append(actual, []float64{head.Denormalize(targets[i])})
+				}
+				if r := metrics.RegressionPerTarget(predicted, actual); len(r) > 0 {
+					result.Regression = &r[0]
+				}
+			}
+			e.Heads = append(e.Heads, result)
+		}
+	case md.MultiLabel:
+		r := metrics.EvaluateMultiLabel(predictions, targets, md.Thresholds)
+		e.MultiLabel = &r
+	case md.ClassMap != nil:
+		r := metrics.EvaluateClassification(predictions, targets)
+		e.Classification = &r
+	default:
+		// Regression: compare the de-normalised predictions with the targets.
+		var predicted, actual [][]float64
+		for i, prediction := range predictions {
+			predicted = append(predicted, md.DenormalizeTargets(prediction))
+			actual = append(actual, md.DenormalizeTargets(targets[i]))
+		}
+		e.Regression = metrics.RegressionPerTarget(predicted, actual)
+		e.TargetNames = md.TargetNames
+		for col := range e.Regression {
+			p := make([]float64, len(actual))
+			a := make([]float64, len(actual))
+			for i := range actual {
+				p[i], a[i] = predicted[i][col], actual[i][col]
+			}
+			e.Residuals = append(e.Residuals, metrics.SummarizeResiduals(p, a, residualBins, largestResiduals))
+		}
+	}
+	return e
+}
+
+// Metrics returns the named scores of the evaluation, as listed by Evaluate. The
+// metrics of regression targets and heads are prefixed with their names.
+func (e *Evaluation) Metrics() []Metric {
+	scores := []Metric{{Name: "loss", Value: e.Loss}}
+	for _, head := range e.Heads {
+		if r := head.Classification; r != nil {
+			scores = append(scores, Metric{head.Name + " accuracy", r.Accuracy})
+		} else if r := head.Regression; r != nil {
+			scores = append(scores, regressionMetrics(head.Name+" ", *r, e.Inputs)...)
+		}
+	}
+	if r := e.MultiLabel; r != nil {
+		scores = append(scores,
+			Metric{"subset accuracy", r.SubsetAccuracy},
+			Metric{"hamming loss", r.HammingLoss},
+			Metric{"micro F1", r.MicroF1},
+			Metric{"macro F1", r.MacroF1})
+	}
+	if r := e.Classification; r != nil {
+		scores = append(scores,
+			Metric{"accuracy", r.Accuracy},
+			Metric{"macro F1", r.Macro.F1},
+			Metric{"weighted F1", r.Weighted.F1},
+			Metric{"log loss", r.LogLoss},
+			Metric{"kappa", r.Kappa},
+			Metric{"MCC", r.MCC})
+	}
+	for i, r := range e.Regression {
+		prefix := ""
+		if i < len(e.TargetNames) {
+			prefix = e.TargetNames[i] + " "
+		}
+		scores = append(scores, regressionMetrics(prefix, r, e.Inputs)...)
+	}
+	return scores
+}
+
+// regressionMetrics returns the MAE, RMSE, MAPE, median absolute error, R², R² adjusted
+// for the given number of inputs and explained variance of one target, prefixed with
+// prefix.
+func regressionMetrics(prefix string, r metrics.Regression, predictors int) []Metric {
+	return []Metric{
+		{prefix + "MAE", r.MAE},
+		{prefix + "RMSE", r.RMSE},
+		{prefix + "MAPE", r.MAPE},
+		{prefix + "median AE", r.MedianAE},
+		{prefix + "R²", r.R2},
+		{prefix + "adjusted R²", r.AdjustedR2(predictors)},
+		{prefix + "explained variance", r.ExplainedVariance},
+	}
+}
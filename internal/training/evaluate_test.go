@@ -0,0 +1,69 @@
+package training_test
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-neuralnetwork/internal/data"
+	"go-neuralnetwork/internal/tempfile"
+	"go-neuralnetwork/internal/training"
+)
+
+func TestAssess(t *testing.T) {
+	var csv strings.Builder
+	csv.WriteString("x,label,y\n")
+	for i := 0; i < 20; i++ {
+		label := "low"
+		if i >= 10 {
+			label = "high"
+		}
+		fmt.Fprintf(&csv, "%d,%s,%d\n", i, label, 2*i+1)
+	}
+	filePath, err := tempfile.CreateTempFileWithContent("evaluate-*.csv", csv.String())
+	if err != nil {
+		t.Fatalf("Failed to create temp CSV: %v", err)
+	}
+	defer os.Remove(filePath)
+
+	cfg := training.Config{Layers: "4", HiddenActivations: []string{"tanh"}, OutputActivation: "sigmoid", Epochs: 5, LearningRate: 0.05}
+	for _, tc := range []struct {
+		name    string
+		opts    data.LoadOptions
+		check   func(*training.Evaluation) bool
+		metrics []string
+	}{
+		{"regression", data.LoadOptions{SplitRatio: 0.75, ExcludeColumns: []string{"label"}},
+			func(e *training.Evaluation) bool {
+				return len(e.Regression) == 1 && len(e.Residuals) == 1 && e.Classification == nil && e.Inputs == 1
+			},
+			[]string{"loss", "y MAE", "y RMSE", "y MAPE", "y median AE", "y R²", "y adjusted R²", "y explained variance"}},
+		{"classification", data.LoadOptions{SplitRatio: 0.75, TargetColumns: []string{"label"}, ExcludeColumns: []string{"y"}},
+			func(e *training.Evaluation) bool { return e.Classification != nil && len(e.Regression) == 0 },
+			[]string{"loss", "accuracy", "macro F1", "weighted F1", "log loss", "kappa", "MCC"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dataset, err := data.LoadCSVWithOptions(filePath, tc.opts)
+			if err != nil {
+				t.Fatalf("Failed to load CSV: %v", err)
+			}
+			md, err := training.Train(dataset, cfg, nil)
+			if err != nil {
+				t.Fatalf("Failed to train: %v", err)
+			}
+			e := training.Assess(md, dataset.TestInputs, dataset.TestTargets)
+			if !tc.check(e) {
+				t.Errorf("Unexpected evaluation %+v", e)
+			}
+			var names []string
+			for _, m := range training.Evaluate(md, dataset) {
+				names = append(names, m.Name)
+			}
+			if !reflect.DeepEqual(names, tc.metrics) {
+				t.Errorf("Expected metrics %v, got %v", tc.metrics, names)
+			}
+		})
+	}
+}
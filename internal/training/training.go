@@ -0,0 +1,161 @@
+// Package training builds, trains and scores networks for the datasets of the data
+// package, for single runs as well as for cross-validation.
+package training
+
+import (
+	"fmt"
+
+	"go-neuralnetwork/internal/data"
+	"go-neuralnetwork/internal/metrics"
+	"go-neuralnetwork/internal/neuralnetwork"
+)
+
+// Config holds the architecture and hyperparameters of a training run.
+type Config struct {
+	// Layers and HiddenActivations describe the hidden layers, as BuildSequential reads them.
+	Layers            string
+	HiddenActivations []string
+	// OutputActivation is the activation of the output layer of single-task models.
+	OutputActivation string
+	// Heads overrides the output layer of the named heads of multi-task datasets.
+	Heads        map[string]neuralnetwork.HeadSpec
+	Epochs       int
+	LearningRate float64
+	ErrorGoal    float64
+}
+
+// BuildModel builds an untrained network for a dataset: a multi-task model for datasets
+// with heads, sigmoid outputs trained with binary cross-entropy for multi-label datasets
+// and a single output layer otherwise, after an embedding layer when the dataset has
+// categorical columns.
+func BuildModel(dataset *data.Dataset, cfg Config) (*neuralnetwork.Sequential, error) {
+	inputShape := dataset.InputShape
+	embedding := dataset.EmbeddingLayer()
+	if embedding != nil {
+		inputShape = []int{embedding.OutputSize()}
+	}
+	var nn *neuralnetwork.Sequential
+	var err error
+	if len(dataset.Heads) > 0 {
+		heads := dataset.HeadSpecs()
+		for i := range heads {
+			if setting, ok := cfg.Heads[heads[i].Name]; ok {
+				heads[i].Activation, heads[i].Loss, heads[i].Weight = setting.Activation, setting.Loss, setting.Weight
+			}
+		}
+		for name := range cfg.Heads {
+			if !hasHead(dataset.Heads, name) {
+				return nil, fmt.Errorf("head settings name %q, which is not a target column", name)
+			}
+		}
+		nn, err = neuralnetwork.BuildMultiTask(inputShape, cfg.Layers, cfg.HiddenActivations, heads)
+	} else if dataset.MultiLabel {
+		// Every label is an independent yes/no decision.
+		nn, err = neuralnetwork.BuildSequential(inputShape, cfg.Layers, cfg.HiddenActivations, dataset.OutputSize, "sigmoid")
+		if err == nil {
+			err = nn.SetLoss("binary_crossentropy")
+		}
+	} else {
+		nn, err = neuralnetwork.BuildSequential(inputShape, cfg.Layers, cfg.HiddenActivations, dataset.OutputSize, cfg.OutputActivation)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if embedding != nil {
+		nn.Layers = append([]neuralnetwork.Layer{embedding}, nn.Layers...)
+	}
+	return nn, nil
+}
+
+// InputShape returns the shape of the samples the hidden layers of a model built by
+// BuildModel read: the dataset's input shape, or the embedded width of its inputs when
+// it has categorical columns.
+func InputShape(dataset *data.Dataset) []int {
+	if embedding := dataset.EmbeddingLayer(); embedding != nil {
+		return []int{embedding.OutputSize()}
+	}
+	return dataset.InputShape
+}
+
+func hasHead(heads []data.TaskHead, name string) bool {
+	for _, head := range heads {
+		if head.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Train trains a model built for the dataset on its training rows. The loss of every
+// epoch is sent to progress, which is closed when training ends; it may be nil.
+func Train(dataset *data.Dataset, cfg Config, progress chan<- any) (*data.ModelData, error) {
+	nn, err := BuildModel(dataset, cfg)
+	if err != nil {
+		if progress != nil {
+			close(progress)
+		}
+		return nil, err
+	}
+	Fit(nn, dataset, cfg, progress)
+	return NewModelData(nn, dataset), nil
+}
+
+// Fit trains nn on the training rows of the dataset for cfg.Epochs more epochs. The loss
+// of every epoch is sent to progress, which is closed when training ends; it may be nil.
+func Fit(nn *neuralnetwork.Sequential, dataset *data.Dataset, cfg Config, progress chan<- any) {
+	if progress == nil {
+		discard := make(chan any)
+		go func() {
+			for range discard {
+			}
+		}()
+		progress = discard
+	}
+	nn.Train(dataset.TrainInputs, dataset.TrainTargets, cfg.Epochs, cfg.LearningRate, cfg.ErrorGoal, progress)
+}
+
+// NewModelData bundles a trained model with what it needs from the dataset to predict
+// from raw values. Multi-label thresholds are tuned on the validation rows, or the
+// training rows when there are none.
+func NewModelData(nn *neuralnetwork.Sequential, dataset *data.Dataset) *data.ModelData {
+	modelData := &data.ModelData{
+		Model:         nn,
+		Pipeline:      dataset.Pipeline,
+		TargetScalers: dataset.RegressionScalers(),
+		ClassMap:      dataset.ClassMap,
+
+		InputShape:          dataset.InputShape,
+		InterleavedChannels: dataset.InterleavedChannels,
+		SequenceLength:      dataset.SequenceLength,
+		TimeSeries:          dataset.TimeSeries,
+		TargetNames:         dataset.TargetNames,
+		Heads:               dataset.Heads,
+		MultiLabel:          dataset.MultiLabel,
+		Thresholds:          dataset.Thresholds,
+	}
+	if dataset.MultiLabel && len(dataset.TrainInputs) > 0 {
+		inputs, targets := dataset.TrainInputs, dataset.TrainTargets
+		if len(dataset.ValidationInputs) > 0 {
+			inputs, targets = dataset.ValidationInputs, dataset.ValidationTargets
+		}
+		scores := make([][]float64, len(inputs))
+		for i, input := range inputs {
+			scores[i] = nn.Predict(input)
+		}
+		modelData.Thresholds = metrics.TuneThresholds(scores, targets)
+	}
+	return modelData
+}
+
+// MeanLoss returns the mean loss of the model on the given rows, or 0 when there are none.
+func MeanLoss(nn *neuralnetwork.Sequential, inputs, targets [][]float64) float64 {
+	total := 0.0
+	for i, input := range inputs {
+		loss, _ := nn.ComputeLoss(nn.Predict(input), targets[i])
+		total += loss
+	}
+	if len(inputs) == 0 {
+		return 0
+	}
+	return total / float64(len(inputs))
+}
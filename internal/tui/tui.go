@@ -4,11 +4,16 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"go-neuralnetwork/internal/data"
+	"go-neuralnetwork/internal/metrics"
 	"go-neuralnetwork/internal/neuralnetwork"
+	"go-neuralnetwork/internal/training"
+	"go-neuralnetwork/internal/tuning"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -27,43 +32,105 @@ type (
 	trainingFinishedMsg struct {
 		modelData *data.ModelData
 		testData  *data.Dataset
+		// validationLoss is the mean loss on the validation rows, if there are any.
+		validationLoss float64
+	}
+	evaluationFinishedMsg struct {
+		evaluation *training.Evaluation
+	}
+	predictionResultMsg struct {
+		result []float64
+		names  []string
 	}
-	evaluationFinishedMsg             struct{ accuracy float64 }
-	predictionResultMsg               struct{ result float64 }
 	predictionResultClassificationMsg struct{ result string }
+	predictionResultForecastMsg       struct{ result []float64 }
+	predictionResultHeadsMsg          struct{ result []string }
+	crossValidationStartedMsg         struct{ folds int }
+	foldCompletedMsg                  struct{ result training.FoldResult }
+	crossValidationFinishedMsg        struct{ result *training.CVResult }
+	tuningStartedMsg                  struct{ metric string }
+	trialCompletedMsg                 struct{ trial tuning.Trial }
 	errorMsg                          struct{ err error }
+	tuningFinishedMsg                 struct {
+		result    *tuning.Result
+		evolution *tuning.Evolution
+		// test holds the scores of the best model on the test rows.
+		test []training.Metric
+	}
 )
 
 func (m *Model) runTraining() tea.Cmd {
 	return func() tea.Msg {
 		// --- Input Validation ---
-		csvIndex, err := strconv.Atoi(m.trainingForm.inputs[0].Value())
+		csvIndex, err := strconv.Atoi(m.trainingForm.inputs[fieldCSV].Value())
 		if err != nil || csvIndex < 1 || csvIndex > len(m.trainingForm.csvFiles) {
-			return errorMsg{fmt.Errorf("invalid CSV file selection")}
+			return errorMsg{fmt.Errorf("invalid dataset selection")}
 		}
 		csvPath := m.trainingForm.csvFiles[csvIndex-1]
-		layersStr := m.trainingForm.inputs[1].Value()
-		if layersStr == "" {
-			layersStr = "20,20"
-		}
-		hiddenLayers := []int{}
-		for _, s := range strings.Split(layersStr, ",") {
-			i, err := strconv.Atoi(strings.TrimSpace(s))
+
+		// With a tuning strategy the architecture and training fields list alternatives
+		// separated by "|"; the first of each is the base setting.
+		var space *tuning.Space
+		var tuneOptions tuning.Options
+		var evolveOptions *tuning.EvolveOptions
+		if tuneStr := strings.TrimSpace(m.trainingForm.inputs[fieldTuning].Value()); tuneStr != "" && tuneStr != "none" {
+			searchSpace, err := parseSearchSpace(m.trainingForm.inputs[fieldLayers].Value(), m.trainingForm.inputs[fieldActivations].Value(),
+				m.trainingForm.inputs[fieldOutputActivation].Value(), m.trainingForm.inputs[fieldLearningRate].Value(), m.trainingForm.inputs[fieldEpochs].Value())
 			if err != nil {
-				return errorMsg{fmt.Errorf("invalid hidden layers: %w", err)}
+				return errorMsg{fmt.Errorf("invalid search space: %w", err)}
+			}
+			space = &searchSpace
+			if strategy, _, _ := strings.Cut(tuneStr, ":"); strategy == "evolve" {
+				opts, err := parseEvolution(tuneStr)
+				if err != nil {
+					return errorMsg{fmt.Errorf("invalid architecture search settings: %w", err)}
+				}
+				// Layers evolve within the width range and choose from the hidden activations.
+				opts.Width = searchSpace.Width
+				for _, alternative := range searchSpace.Activations {
+					for _, activation := range strings.Split(alternative, ",") {
+						if activation = strings.TrimSpace(activation); !slices.Contains(opts.Activations, activation) {
+							opts.Activations = append(opts.Activations, activation)
+						}
+					}
+				}
+				evolveOptions = &opts
+			} else {
+				tuneOptions, err = parseTuning(tuneStr)
+				if err != nil {
+					return errorMsg{fmt.Errorf("invalid tuning settings: %w", err)}
+				}
+				if tuneOptions.Strategy == tuning.Bayesian {
+					// Keep the trials so that running the same search again resumes it.
+					if err := os.MkdirAll(tuningHistoryDir, 0o755); err != nil {
+						return errorMsg{err}
+					}
+					tuneOptions.History = tuningHistoryPath(csvPath, tuneOptions.MetricName())
+				}
+			}
+		}
+		value := func(field int) string {
+			v := m.trainingForm.inputs[field].Value()
+			if space != nil {
+				v, _, _ = strings.Cut(v, "|")
 			}
-			hiddenLayers = append(hiddenLayers, i)
+			return strings.TrimSpace(v)
 		}
-		activationsStr := m.trainingForm.inputs[2].Value()
+
+		layersStr := value(fieldLayers)
+		if layersStr == "" {
+			layersStr = "20,20"
+		}
+		activationsStr := value(fieldActivations)
 		if activationsStr == "" {
 			activationsStr = "relu,relu"
 		}
 		hiddenActivations := strings.Split(activationsStr, ",")
-		outputActivation := m.trainingForm.inputs[3].Value()
+		outputActivation := value(fieldOutputActivation)
 		if outputActivation == "" {
 			outputActivation = "linear"
 		}
-		epochsStr := m.trainingForm.inputs[4].Value()
+		epochsStr := value(fieldEpochs)
 		if epochsStr == "" {
 			epochsStr = "1000"
 		}
@@ -71,7 +138,7 @@ func (m *Model) runTraining() tea.Cmd {
 		if err != nil {
 			return errorMsg{fmt.Errorf("invalid epochs value: %w", err)}
 		}
-		lrStr := m.trainingForm.inputs[5].Value()
+		lrStr := value(fieldLearningRate)
 		if lrStr == "" {
 			lrStr = "0.001"
 		}
@@ -79,7 +146,7 @@ func (m *Model) runTraining() tea.Cmd {
 		if err != nil {
 			return errorMsg{fmt.Errorf("invalid learning rate: %w", err)}
 		}
-		egStr := m.trainingForm.inputs[6].Value()
+		egStr := m.trainingForm.inputs[fieldErrorGoal].Value()
 		if egStr == "" {
 			egStr = "0.001"
 		}
@@ -87,15 +154,169 @@ func (m *Model) runTraining() tea.Cmd {
 		if err != nil {
 			return errorMsg{fmt.Errorf("invalid error goal: %w", err)}
 		}
+		channelsStr := m.trainingForm.inputs[fieldChannels].Value()
+		if channelsStr == "" {
+			channelsStr = "1"
+		}
+		channels, err := strconv.Atoi(channelsStr)
+		if err != nil {
+			return errorMsg{fmt.Errorf("invalid input channels: %w", err)}
+		}
+
+		sequenceID := strings.TrimSpace(m.trainingForm.inputs[fieldSequenceID].Value())
+		var timeSeries *data.TimeSeriesOptions
+		if tsStr := strings.TrimSpace(m.trainingForm.inputs[fieldTimeSeries].Value()); tsStr != "" {
+			timeSeries, err = parseTimeSeriesOptions(tsStr)
+			if err != nil {
+				return errorMsg{fmt.Errorf("invalid time series settings: %w", err)}
+			}
+		}
+
+		var categorical map[string]int
+		if catStr := strings.TrimSpace(m.trainingForm.inputs[fieldCategorical].Value()); catStr != "" {
+			categorical, err = parseCategoricalColumns(catStr)
+			if err != nil {
+				return errorMsg{fmt.Errorf("invalid categorical columns: %w", err)}
+			}
+		}
+
+		var encoders map[string]data.Encoder
+		if encStr := strings.TrimSpace(m.trainingForm.inputs[fieldEncoders].Value()); encStr != "" {
+			encoders, err = parseEncoders(encStr)
+			if err != nil {
+				return errorMsg{fmt.Errorf("invalid encoded columns: %w", err)}
+			}
+		}
+
+		targetColumns := parseColumnList(m.trainingForm.inputs[fieldTargets].Value())
+		includeColumns := parseColumnList(m.trainingForm.inputs[fieldIncludeColumns].Value())
+		excludeColumns := parseColumnList(m.trainingForm.inputs[fieldExcludeColumns].Value())
+		taskType := data.TaskType(strings.TrimSpace(m.trainingForm.inputs[fieldTaskType].Value()))
+		if taskType == "auto" {
+			taskType = data.TaskAuto
+		}
+
+		var imputation map[string]data.Imputer
+		if impStr := strings.TrimSpace(m.trainingForm.inputs[fieldImputation].Value()); impStr != "" {
+			imputation, err = parseImputation(impStr)
+			if err != nil {
+				return errorMsg{fmt.Errorf("invalid missing value settings: %w", err)}
+			}
+		}
+		var missingTokens []string
+		if extra := parseColumnList(m.trainingForm.inputs[fieldMissingTokens].Value()); extra != nil {
+			missingTokens = append(append(missingTokens, data.DefaultMissingTokens...), extra...)
+		}
+
+		var scalers map[string]string
+		if scalerStr := strings.TrimSpace(m.trainingForm.inputs[fieldScalers].Value()); scalerStr != "" {
+			scalers, err = parseScalers(scalerStr)
+			if err != nil {
+				return errorMsg{fmt.Errorf("invalid scalers: %w", err)}
+			}
+		}
+
+		var features []data.Feature
+		if featStr := strings.TrimSpace(m.trainingForm.inputs[fieldFeatures].Value()); featStr != "" {
+			features, err = parseFeatures(featStr)
+			if err != nil {
+				return errorMsg{fmt.Errorf("invalid features: %w", err)}
+			}
+		}
+
+		var headSettings map[string]neuralnetwork.HeadSpec
+		if headsStr := strings.TrimSpace(m.trainingForm.inputs[fieldHeads].Value()); headsStr != "" {
+			headSettings, err = parseHeadSettings(headsStr)
+			if err != nil {
+				return errorMsg{fmt.Errorf("invalid head settings: %w", err)}
+			}
+		}
+
+		trainRatio, validationRatio, err := parseSplitRatios(m.trainingForm.inputs[fieldSplit].Value())
+		if err != nil {
+			return errorMsg{fmt.Errorf("invalid split ratios: %w", err)}
+		}
+		strategy, groupColumn, _ := strings.Cut(strings.TrimSpace(m.trainingForm.inputs[fieldSplitStrategy].Value()), ":")
+		splitStrategy := data.SplitStrategy(strategy)
+		if splitStrategy == "random" {
+			splitStrategy = data.SplitRandom
+		}
+		tabular := !data.IsIDXImages(csvPath) && timeSeries == nil && sequenceID == ""
+		if !tabular && (validationRatio > 0 || splitStrategy != data.SplitRandom && !(timeSeries != nil && splitStrategy == data.SplitTime)) {
+			return errorMsg{fmt.Errorf("validation sets and split strategies need a plain CSV table")}
+		}
+
+		cfg := training.Config{
+			Layers:            layersStr,
+			HiddenActivations: hiddenActivations,
+			OutputActivation:  outputActivation,
+			Heads:             headSettings,
+			Epochs:            epochs,
+			LearningRate:      learningRate,
+			ErrorGoal:         errorGoal,
+		}
+		loadOptions := data.LoadOptions{
+			SplitRatio:         trainRatio,
+			ValidationRatio:    validationRatio,
+			Split:              splitStrategy,
+			GroupColumn:        strings.TrimSpace(groupColumn),
+			TargetColumns:      targetColumns,
+			TaskType:           taskType,
+			IncludeColumns:     includeColumns,
+			ExcludeColumns:     excludeColumns,
+			CategoricalColumns: categorical,
+			Encoders:           encoders,
+			MultiTask:          headSettings != nil,
+			MissingTokens:      missingTokens,
+			Imputation:         imputation,
+			Scalers:            scalers,
+			Features:           features,
+		}
+
+		if cvStr := strings.TrimSpace(m.trainingForm.inputs[fieldCrossValidation].Value()); cvStr != "" && cvStr != "none" {
+			cvOptions, err := parseCrossValidation(cvStr)
+			if err != nil {
+				return errorMsg{fmt.Errorf("invalid cross-validation settings: %w", err)}
+			}
+			if !tabular || validationRatio > 0 || space != nil {
+				return errorMsg{fmt.Errorf("cross-validation needs a plain CSV table, no validation set and no tuning")}
+			}
+			return m.runCrossValidation(csvPath, loadOptions, channels, cfg, cvOptions)
+		}
 
 		// Load data
-		dataset, err := data.LoadCSV(csvPath, 0.8)
+		var dataset *data.Dataset
+		if data.IsIDXImages(csvPath) {
+			dataset, err = data.LoadIDX(csvPath, trainRatio)
+		} else if timeSeries != nil {
+			dataset, err = data.LoadTimeSeries(csvPath, *timeSeries, trainRatio)
+		} else if sequenceID != "" {
+			dataset, err = data.LoadCSVSequences(csvPath, sequenceID, 0, trainRatio)
+		} else if categorical != nil || targetColumns != nil || headSettings != nil || taskType != data.TaskAuto || includeColumns != nil || excludeColumns != nil || imputation != nil || missingTokens != nil || encoders != nil || scalers != nil || features != nil || validationRatio > 0 || splitStrategy != data.SplitRandom {
+			dataset, err = data.LoadCSVWithOptions(csvPath, loadOptions)
+		} else {
+			dataset, err = data.LoadCSV(csvPath, trainRatio)
+		}
 		if err != nil {
-			return errorMsg{fmt.Errorf("failed to load CSV data: %w", err)}
+			return errorMsg{fmt.Errorf("failed to load data: %w", err)}
+		}
+
+		if channels > 1 {
+			// Multi-channel CSV rows list every channel for one position before the next.
+			if err := dataset.ReshapeChannels(channels, true); err != nil {
+				return errorMsg{err}
+			}
+		}
+
+		if space != nil {
+			return m.runTuning(dataset, cfg, *space, tuneOptions, evolveOptions)
 		}
 
 		// Initialize network
-		nn := neuralnetwork.InitNetwork(dataset.InputSize, hiddenLayers, dataset.OutputSize, hiddenActivations, outputActivation)
+		nn, err := training.BuildModel(dataset, cfg)
+		if err != nil {
+			return errorMsg{fmt.Errorf("failed to build network: %w", err)}
+		}
 
 		// This channel will receive training progress
 		progressChan := make(chan any)
@@ -103,15 +324,9 @@ func (m *Model) runTraining() tea.Cmd {
 		// Goroutine to run training and send messages
 		go func() {
 			nn.Train(dataset.TrainInputs, dataset.TrainTargets, epochs, learningRate, errorGoal, progressChan)
-			modelData := &data.ModelData{
-				NN:         nn,
-				InputMins:  dataset.InputMins,
-				InputMaxs:  dataset.InputMaxs,
-				TargetMins: dataset.TargetMins,
-				TargetMaxs: dataset.TargetMaxs,
-				ClassMap:   dataset.ClassMap,
-			}
-			m.program.Send(trainingFinishedMsg{modelData: modelData, testData: dataset})
+			modelData := training.NewModelData(nn, dataset)
+			validationLoss := training.MeanLoss(nn, dataset.ValidationInputs, dataset.ValidationTargets)
+			m.program.Send(trainingFinishedMsg{modelData: modelData, testData: dataset, validationLoss: validationLoss})
 		}()
 
 		// Goroutine to listen for progress and update the TUI
@@ -127,11 +342,102 @@ func (m *Model) runTraining() tea.Cmd {
 	}
 }
 
+// runCrossValidation trains a fresh network with the same settings on every fold of the
+// CSV table and reports the mean and standard deviation of the test scores.
+func (m *Model) runCrossValidation(csvPath string, opts data.LoadOptions, channels int, cfg training.Config, cvOptions training.CVOptions) tea.Msg {
+	load := func(fold data.Fold) (*data.Dataset, error) {
+		opts := opts
+		opts.Fold = &fold
+		dataset, err := data.LoadCSVWithOptions(csvPath, opts)
+		if err != nil {
+			return nil, err
+		}
+		if channels > 1 {
+			if err := dataset.ReshapeChannels(channels, true); err != nil {
+				return nil, err
+			}
+		}
+		return dataset, nil
+	}
+	// Fail before starting the folds when the data or the network settings are invalid.
+	dataset, err := load(data.Fold{K: cvOptions.Folds, Index: 0})
+	if err != nil {
+		return errorMsg{fmt.Errorf("failed to load data: %w", err)}
+	}
+	if _, err := training.BuildModel(dataset, cfg); err != nil {
+		return errorMsg{fmt.Errorf("failed to build network: %w", err)}
+	}
+
+	progressChan := make(chan training.FoldResult)
+	go func() {
+		result, err := training.CrossValidate(load, cfg, cvOptions, progressChan)
+		if err != nil {
+			m.program.Send(errorMsg{fmt.Errorf("cross-validation failed: %w", err)})
+			return
+		}
+		m.program.Send(crossValidationFinishedMsg{result: result})
+	}()
+	go func() {
+		for result := range progressChan {
+			m.program.Send(foldCompletedMsg{result: result})
+		}
+	}()
+
+	return crossValidationStartedMsg{folds: cvOptions.Folds * max(cvOptions.Repeats, 1)}
+}
+
+// runTuning searches the space for the settings whose model scores best on the
+// validation rows, or evolves architectures when evolveOptions is set, training the
+// trials in parallel.
+func (m *Model) runTuning(dataset *data.Dataset, cfg training.Config, space tuning.Space, opts tuning.Options, evolveOptions *tuning.EvolveOptions) tea.Msg {
+	if len(dataset.ValidationInputs) == 0 {
+		return errorMsg{fmt.Errorf("tuning scores trials on a validation set; give the split ratios a validation ratio")}
+	}
+	progressChan := make(chan tuning.Trial)
+	go func() {
+		if evolveOptions != nil {
+			evolution, err := tuning.Evolve(dataset, cfg, *evolveOptions, progressChan)
+			if err != nil {
+				m.program.Send(errorMsg{fmt.Errorf("architecture search failed: %w", err)})
+				return
+			}
+			m.program.Send(tuningFinishedMsg{evolution: evolution, test: training.Evaluate(evolution.Best().Model, dataset)})
+			return
+		}
+		result, err := tuning.Search(dataset, cfg, space, opts, progressChan)
+		if err != nil {
+			m.program.Send(errorMsg{fmt.Errorf("tuning failed: %w", err)})
+			return
+		}
+		m.program.Send(tuningFinishedMsg{result: result, test: training.Evaluate(result.Best().Model, dataset)})
+	}()
+	go func() {
+		for trial := range progressChan {
+			m.program.Send(trialCompletedMsg{trial: trial})
+		}
+	}()
+	metric := opts.MetricName()
+	if evolveOptions != nil {
+		metric = evolveOptions.MetricName(dataset)
+	}
+	return tuningStartedMsg{metric: metric}
+}
+
 func findCsvFiles() tea.Msg {
 	files, err := filepath.Glob("*.csv")
 	if err != nil {
 		return errorMsg{err}
 	}
+	// MNIST-style image datasets are listed by their training images file.
+	idxFiles, err := filepath.Glob("*-images-idx3-ubyte*")
+	if err != nil {
+		return errorMsg{err}
+	}
+	for _, file := range idxFiles {
+		if !strings.HasPrefix(file, "t10k-") {
+			files = append(files, file)
+		}
+	}
 	return csvFilesLoadedMsg{files}
 }
 
@@ -155,6 +461,10 @@ const (
 	predictionResult
 	saveModelForm
 	errorView
+	crossValidationInProgress
+	crossValidationResult
+	tuningInProgress
+	tuningResult
 )
 
 // Styles
@@ -190,11 +500,69 @@ type Model struct {
 	lastLoss        float64
 	currentEpoch    int
 	totalEpochs     int
-	predictionValue float64
 	predictionClass string
-	accuracy        float64
+
+	// predictionForecast holds the values of a multi-step time-series forecast.
+	predictionForecast []float64
+	// predictionValues and predictionNames hold the outputs of a regression model.
+	predictionValues []float64
+	predictionNames  []string
+	// evaluation holds the test results of the last trained model.
+	evaluation *training.Evaluation
+	// predictionHeads holds the predictions of a multi-task model, one per head.
+	predictionHeads []string
+	// validationRows and validationLoss describe the validation set of the last training run.
+	validationRows int
+	validationLoss float64
+	// foldsDone, totalFolds and crossValidation track a cross-validation run.
+	foldsDone       int
+	totalFolds      int
+	crossValidation *training.CVResult
+	// trialsDone, bestTrial and tuning track a hyperparameter search that optimises
+	// tuningMetric; tuningTest holds the test scores of its best model.
+	trialsDone   int
+	bestTrial    *tuning.Trial
+	tuningMetric string
+	tuning       *tuning.Result
+	tuningTest   []training.Metric
+	// evolution holds the result of an architecture search.
+	evolution *tuning.Evolution
 }
 
+// Fields of the training form, in the order they are displayed.
+const (
+	fieldCSV = iota
+	fieldLayers
+	fieldActivations
+	fieldOutputActivation
+	fieldEpochs
+	fieldLearningRate
+	fieldErrorGoal
+	fieldChannels
+	fieldSequenceID
+	fieldTimeSeries
+	fieldCategorical
+	fieldEncoders
+	fieldTargets
+	fieldTaskType
+	fieldIncludeColumns
+	fieldExcludeColumns
+	fieldImputation
+	fieldMissingTokens
+	fieldScalers
+	fieldFeatures
+	fieldHeads
+	fieldSplit
+	fieldSplitStrategy
+	fieldCrossValidation
+	fieldTuning
+	numTrainingFields
+)
+
+// specCharLimit is the input limit of training fields that hold a
+// comma-separated list or spec instead of a single value.
+const specCharLimit = 512
+
 // trainingFormModel holds the state for the training configuration form.
 type trainingFormModel struct {
 	focusIndex int
@@ -211,7 +579,7 @@ type predictionFormModel struct {
 
 func newTrainingForm() trainingFormModel {
 	m := trainingFormModel{
-		inputs: make([]textinput.Model, 7),
+		inputs: make([]textinput.Model, numTrainingFields),
 	}
 
 	var t textinput.Model
@@ -221,21 +589,71 @@ func newTrainingForm() trainingFormModel {
 		t.CharLimit = 32
 
 		switch i {
-		case 0:
+		case fieldCSV:
 			t.Placeholder = "1"
 			t.Focus()
-		case 1:
+		case fieldLayers:
+			t.CharLimit = specCharLimit
 			t.Placeholder = "20,20"
-		case 2:
+		case fieldActivations:
+			t.CharLimit = specCharLimit
 			t.Placeholder = "relu,relu"
-		case 3:
+		case fieldOutputActivation:
 			t.Placeholder = "linear"
-		case 4:
+		case fieldEpochs:
 			t.Placeholder = "1000"
-		case 5:
+		case fieldLearningRate:
 			t.Placeholder = "0.001"
-		case 6:
+		case fieldErrorGoal:
 			t.Placeholder = "0.001"
+		case fieldChannels:
+			t.Placeholder = "1"
+		case fieldSequenceID:
+			t.Placeholder = "none"
+		case fieldTimeSeries:
+			t.CharLimit = specCharLimit
+			t.Placeholder = "none"
+		case fieldCategorical:
+			t.CharLimit = specCharLimit
+			t.Placeholder = "none"
+		case fieldEncoders:
+			t.CharLimit = specCharLimit
+			t.Placeholder = "none"
+		case fieldTargets:
+			t.CharLimit = specCharLimit
+			t.Placeholder = "last column"
+		case fieldTaskType:
+			t.Placeholder = "auto"
+		case fieldIncludeColumns:
+			t.CharLimit = specCharLimit
+			t.Placeholder = "all"
+		case fieldExcludeColumns:
+			t.CharLimit = specCharLimit
+			t.Placeholder = "none"
+		case fieldImputation:
+			t.CharLimit = specCharLimit
+			t.Placeholder = "none"
+		case fieldMissingTokens:
+			t.CharLimit = specCharLimit
+			t.Placeholder = "none"
+		case fieldScalers:
+			t.CharLimit = specCharLimit
+			t.Placeholder = "minmax"
+		case fieldFeatures:
+			t.CharLimit = specCharLimit
+			t.Placeholder = "none"
+		case fieldHeads:
+			t.CharLimit = specCharLimit
+			t.Placeholder = "none"
+		case fieldSplit:
+			t.Placeholder = "0.8"
+		case fieldSplitStrategy:
+			t.Placeholder = "random"
+		case fieldCrossValidation:
+			t.Placeholder = "none"
+		case fieldTuning:
+			t.CharLimit = specCharLimit
+			t.Placeholder = "none"
 		}
 		m.inputs[i] = t
 	}
@@ -245,14 +663,14 @@ func newTrainingForm() trainingFormModel {
 
 func newPredictionForm() predictionFormModel {
 	m := predictionFormModel{
-		inputs: make([]textinput.Model, 2),
+		inputs: make([]textinput.Model, 3),
 	}
 
 	var t textinput.Model
 	for i := range m.inputs {
 		t = textinput.New()
 		t.Cursor.Style = focusedStyle
-		t.CharLimit = 128
+		t.CharLimit = 1024
 
 		switch i {
 		case 0:
@@ -260,6 +678,8 @@ func newPredictionForm() predictionFormModel {
 			t.Focus()
 		case 1:
 			t.Placeholder = "7.4,0.7,0,1.9,0.076,11,34,0.9978,3.51,0.56,9.4"
+		case 2:
+			t.Placeholder = "horizon"
 		}
 		m.inputs[i] = t
 	}
@@ -311,13 +731,53 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case trainingStartedMsg:
 		m.state = trainingInProgress
-		epochs, _ := strconv.Atoi(m.trainingForm.inputs[4].Value())
+		epochs, _ := strconv.Atoi(m.trainingForm.inputs[fieldEpochs].Value())
 		if epochs == 0 {
 			epochs = 1000
 		}
 		m.totalEpochs = epochs
 		return m, nil
 
+	case crossValidationStartedMsg:
+		m.state = crossValidationInProgress
+		m.foldsDone = 0
+		m.totalFolds = msg.folds
+		return m, nil
+
+	case foldCompletedMsg:
+		m.foldsDone++
+		return m, nil
+
+	case crossValidationFinishedMsg:
+		m.crossValidation = msg.result
+		m.state = crossValidationResult
+		return m, nil
+
+	case tuningStartedMsg:
+		m.state = tuningInProgress
+		m.trialsDone = 0
+		m.bestTrial = nil
+		m.tuningMetric = msg.metric
+		return m, nil
+
+	case trialCompletedMsg:
+		m.trialsDone++
+		if msg.trial.Err != nil {
+			return m, nil
+		}
+		lower := tuning.LowerIsBetter(m.tuningMetric)
+		if m.bestTrial == nil || (lower && msg.trial.Score < m.bestTrial.Score) || (!lower && msg.trial.Score > m.bestTrial.Score) {
+			m.bestTrial = &msg.trial
+		}
+		return m, nil
+
+	case tuningFinishedMsg:
+		m.tuning = msg.result
+		m.evolution = msg.evolution
+		m.tuningTest = msg.test
+		m.state = tuningResult
+		return m, nil
+
 	case epochCompletedMsg:
 		m.currentEpoch = msg.epochNum
 		m.lastLoss = msg.loss
@@ -325,54 +785,45 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case trainingFinishedMsg:
 		m.modelData = msg.modelData
+		m.validationRows = len(msg.testData.ValidationInputs)
+		m.validationLoss = msg.validationLoss
 		m.state = evaluation
 		return m, func() tea.Msg {
-			correct := 0
-			for i, input := range msg.testData.TestInputs {
-				_, prediction := m.modelData.NN.FeedForward(input)
-				if msg.testData.ClassMap != nil {
-					max := -1.0
-					maxIndex := -1
-					for i, val := range prediction {
-						if val > max {
-							max = val
-							maxIndex = i
-						}
-					}
-
-					actualIndex := -1
-					for i, val := range msg.testData.TestTargets[i] {
-						if val == 1.0 {
-							actualIndex = i
-							break
-						}
-					}
-
-					if maxIndex == actualIndex {
-						correct++
-					}
-				} else {
-					// This is a regression problem, so we can't calculate accuracy.
-					// We could calculate loss here instead.
-				}
-			}
-			accuracy := float64(correct) / float64(len(msg.testData.TestInputs))
-			return evaluationFinishedMsg{accuracy: accuracy}
+			return evaluationFinishedMsg{training.Assess(m.modelData, msg.testData.TestInputs, msg.testData.TestTargets)}
 		}
 
 	case evaluationFinishedMsg:
-		m.accuracy = msg.accuracy
-		m.state = saveModelForm
+		m.evaluation = msg.evaluation
 		return m, nil
 
 	case predictionResultMsg:
 		m.state = predictionResult
-		m.predictionValue = msg.result
+		m.predictionClass = ""
+		m.predictionValues = msg.result
+		m.predictionNames = msg.names
+		m.predictionForecast = nil
+		m.predictionHeads = nil
 		return m, nil
 
 	case predictionResultClassificationMsg:
 		m.state = predictionResult
 		m.predictionClass = msg.result
+		m.predictionForecast = nil
+		m.predictionHeads = nil
+		return m, nil
+
+	case predictionResultForecastMsg:
+		m.state = predictionResult
+		m.predictionClass = ""
+		m.predictionForecast = msg.result
+		m.predictionHeads = nil
+		return m, nil
+
+	case predictionResultHeadsMsg:
+		m.state = predictionResult
+		m.predictionClass = ""
+		m.predictionForecast = nil
+		m.predictionHeads = msg.result
 		return m, nil
 
 	case tea.KeyMsg:
@@ -393,7 +844,23 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		case predictionForm:
 			return m.updatePredictionForm(msg)
-		case predictionResult:
+		case tuningResult:
+			if msg.String() == "enter" || msg.String() == "q" {
+				// Offer to save the best model.
+				if m.evolution != nil {
+					m.modelData = m.evolution.Best().Model
+				} else {
+					m.modelData = m.tuning.Best().Model
+				}
+				m.state = saveModelForm
+			}
+			return m, nil
+		case crossValidationInProgress, tuningInProgress:
+			if msg.String() == "q" {
+				m.state = mainMenu
+			}
+			return m, nil
+		case predictionResult, crossValidationResult:
 			if msg.String() == "enter" || msg.String() == "q" {
 				m.state = mainMenu
 			}
@@ -496,6 +963,14 @@ func (m *Model) View() string {
 		s = m.viewSaveModelForm()
 	case errorView:
 		s = m.viewError()
+	case crossValidationInProgress:
+		s = m.viewCrossValidationInProgress()
+	case crossValidationResult:
+		s = m.viewCrossValidationResult()
+	case tuningInProgress:
+		s = m.viewTuningInProgress()
+	case tuningResult:
+		s = m.viewTuningResult()
 	default:
 		s = "Unknown state."
 	}
@@ -504,7 +979,127 @@ func (m *Model) View() string {
 }
 
 func (m *Model) viewEvaluation() string {
-	return fmt.Sprintf("Evaluation complete!\n\nAccuracy: %.2f%%\n\n(Press enter to continue)", m.accuracy*100)
+	e := m.evaluation
+	if e == nil {
+		return m.evaluationHeader() + "(Press enter to continue)"
+	}
+	if r := e.MultiLabel; r != nil {
+		return fmt.Sprintf("%sSubset Accuracy: %.2f%%\nHamming Loss: %.4f\nMicro F1: %.4f\nMacro F1: %.4f\n\n(Press enter to continue)",
+			m.evaluationHeader(), r.SubsetAccuracy*100, r.HammingLoss, r.MicroF1, r.MacroF1)
+	}
+	if len(e.Heads) > 0 {
+		var b strings.Builder
+		b.WriteString(m.evaluationHeader())
+		for _, h := range e.Heads {
+			if r := h.Regression; r != nil {
+				fmt.Fprintf(&b, "%s: MAE %.4f  RMSE %.4f  R² %.4f\n", h.Name, r.MAE, r.RMSE, r.R2)
+			} else if r := h.Classification; r != nil {
+				fmt.Fprintf(&b, "%s: Accuracy %.2f%%  Macro F1 %.4f  Log Loss %.4f\n", h.Name, r.Accuracy*100, r.Macro.F1, r.LogLoss)
+			}
+		}
+		b.WriteString("\n(Press enter to continue)")
+		return b.String()
+	}
+	if len(e.Regression) > 0 {
+		var b strings.Builder
+		b.WriteString(m.evaluationHeader())
+		for i, r := range e.Regression {
+			fmt.Fprintf(&b, "%s\n", targetName(e.TargetNames, i))
+			fmt.Fprintf(&b, "  MAE %.4f  RMSE %.4f  MAPE %.2f%%  Median AE %.4f\n", r.MAE, r.RMSE, r.MAPE*100, r.MedianAE)
+			fmt.Fprintf(&b, "  R² %.4f  Adjusted R² %.4f  Explained Variance %.4f\n\n", r.R2, r.AdjustedR2(e.Inputs), r.ExplainedVariance)
+			writeResiduals(&b, e.Residuals[i])
+		}
+		b.WriteString("(Press enter to continue)")
+		return b.String()
+	}
+	if r := e.Classification; r != nil {
+		var b strings.Builder
+		b.WriteString(m.evaluationHeader())
+		writeClassification(&b, r, data.ClassNames(m.modelData.ClassMap))
+		b.WriteString("\n(Press enter to continue)")
+		return b.String()
+	}
+	return m.evaluationHeader() + "(Press enter to continue)"
+}
+
+// writeResiduals writes the residual summary of one regression target: the quartiles and
+// a histogram of the residuals, their mean and mean absolute value by predicted value,
+// and the test rows with the largest residuals.
+func writeResiduals(b *strings.Builder, r metrics.Residuals) {
+	fmt.Fprintf(b, "  Residuals (predicted - actual): mean %.4f  std %.4f\n", r.Mean, r.Std)
+	fmt.Fprintf(b, "  min %.4f  Q1 %.4f  median %.4f  Q3 %.4f  max %.4f\n", r.Min, r.Q1, r.Median, r.Q3, r.Max)
+	most := 0
+	for _, bin := range r.Histogram {
+		most = max(most, bin.Count)
+	}
+	for _, bin := range r.Histogram {
+		bar := 0
+		if most > 0 {
+			bar = bin.Count * 30 / most
+		}
+		fmt.Fprintf(b, "  %10.4f to %10.4f %s %d\n", bin.Low, bin.High, strings.Repeat("█", bar), bin.Count)
+	}
+
+	b.WriteString("\n  By prediction:\n")
+	for _, bin := range r.ByPrediction {
+		if bin.Count == 0 {
+			fmt.Fprintf(b, "  %10.4f to %10.4f  n %4d\n", bin.Low, bin.High, bin.Count)
+			continue
+		}
+		fmt.Fprintf(b, "  %10.4f to %10.4f  n %4d  mean residual %+.4f  MAE %.4f\n", bin.Low, bin.High, bin.Count, bin.MeanResidual, bin.MAE)
+	}
+
+	b.WriteString("\n  Largest errors:\n")
+	for _, s := range r.Largest {
+		fmt.Fprintf(b, "  test row %d: predicted %.4f, actual %.4f, residual %+.4f\n", s.Index+1, s.Predicted, s.Actual, s.Residual)
+	}
+	b.WriteString("\n")
+}
+
+// writeClassification writes the test scores of a classification model: the overall
+// scores, a table of the scores of every class and their averages, and the confusion
+// matrix, labelled with the class names.
+func writeClassification(b *strings.Builder, r *metrics.Classification, names []string) {
+	fmt.Fprintf(b, "Accuracy: %.2f%%  Log Loss: %.4f  Kappa: %.4f  MCC: %.4f\n", r.Accuracy*100, r.LogLoss, r.Kappa, r.MCC)
+	fmt.Fprintf(b, "ROC-AUC: %.4f  PR-AUC: %.4f (one-vs-rest, macro)\n\n", r.ROCAUC, r.PRAUC)
+
+	width := len("Weighted avg")
+	for _, name := range names {
+		width = max(width, len(name))
+	}
+	fmt.Fprintf(b, "%-*s %9s %9s %9s %9s %9s %9s\n", width, "Class", "Precision", "Recall", "F1", "Support", "ROC-AUC", "PR-AUC")
+	for i, c := range r.Classes {
+		fmt.Fprintf(b, "%-*s %9.4f %9.4f %9.4f %9d %9.4f %9.4f\n", width, names[i], c.Precision, c.Recall, c.F1, c.Support, c.ROCAUC, c.PRAUC)
+	}
+	for _, avg := range []struct {
+		name string
+		metrics.Averages
+	}{{"Macro avg", r.Macro}, {"Weighted avg", r.Weighted}, {"Micro avg", r.Micro}} {
+		fmt.Fprintf(b, "%-*s %9.4f %9.4f %9.4f\n", width, avg.name, avg.Precision, avg.Recall, avg.F1)
+	}
+
+	b.WriteString("\nConfusion matrix (rows: actual, columns: predicted)\n")
+	fmt.Fprintf(b, "%-*s", width, "")
+	for _, name := range names {
+		fmt.Fprintf(b, " %*s", max(len(name), 5), name)
+	}
+	b.WriteString("\n")
+	for i, row := range r.Confusion {
+		fmt.Fprintf(b, "%-*s", width, names[i])
+		for j, count := range row {
+			fmt.Fprintf(b, " %*d", max(len(names[j]), 5), count)
+		}
+		b.WriteString("\n")
+	}
+}
+
+// evaluationHeader starts the evaluation view, with the validation loss when the model
+// was trained with a validation set. The scores that follow are on the test set.
+func (m *Model) evaluationHeader() string {
+	if m.validationRows == 0 {
+		return "Evaluation complete!\n\n"
+	}
+	return fmt.Sprintf("Evaluation complete!\n\nValidation Loss: %.4f (%d rows)\n\n", m.validationLoss, m.validationRows)
 }
 
 func (m *Model) updateTrainingForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
@@ -577,9 +1172,9 @@ func (m *Model) viewTrainingForm() string {
 	b.WriteString("Neural Network Training Configuration\n\n")
 
 	// Render CSV file list
-	b.WriteString("Available CSV Files:\n")
+	b.WriteString("Available Datasets:\n")
 	if len(m.trainingForm.csvFiles) == 0 {
-		b.WriteString("  (No CSV files found in current directory)\n")
+		b.WriteString("  (No CSV or IDX files found in current directory)\n")
 	} else {
 		for i, file := range m.trainingForm.csvFiles {
 			b.WriteString(fmt.Sprintf("  %d: %s\n", i+1, file))
@@ -588,21 +1183,40 @@ func (m *Model) viewTrainingForm() string {
 	b.WriteString("\n")
 
 	// Render form
-	fmt.Fprintf(&b, "Select CSV File (number): %s\n", m.trainingForm.inputs[0].View())
-	fmt.Fprintf(&b, "Hidden Layers (e.g., 20,20): %s\n", m.trainingForm.inputs[1].View())
+	fmt.Fprintf(&b, "Select Dataset (number): %s\n", m.trainingForm.inputs[fieldCSV].View())
+	fmt.Fprintf(&b, "Hidden Layers (e.g., 20,20): %s\n", m.trainingForm.inputs[fieldLayers].View())
+	b.WriteString(fmt.Sprintf("%s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render("Hint: also conv1d(filters,kernel), maxpool1d(size), avgpool1d(size), conv2d(filters,kernel), maxpool2d(size), flatten, gap, lstm(units[,seq][,bptt]), gru(...), rnn(...), timedense(units), posenc, attention(heads), transformer(heads,ffdim), res(...), cat(...), dropout(rate), layernorm.")))
 
 	// Activation function hints
 	availableActivations := neuralnetwork.GetAvailableActivations()
 	b.WriteString(fmt.Sprintf("\nAvailable activation functions: %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(strings.Join(availableActivations, ", "))))
 	b.WriteString(fmt.Sprintf("%s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render("Hint: 'relu' or 'tanh' are common choices for hidden layers.")))
-	fmt.Fprintf(&b, "Hidden Activations (e.g., relu,relu): %s\n", m.trainingForm.inputs[2].View())
+	fmt.Fprintf(&b, "Hidden Activations (e.g., relu,relu): %s\n", m.trainingForm.inputs[fieldActivations].View())
 
 	b.WriteString(fmt.Sprintf("\n%s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render("Hint: 'linear' for regression, 'sigmoid' for classification.")))
-	fmt.Fprintf(&b, "Output Activation: %s\n\n", m.trainingForm.inputs[3].View())
-
-	fmt.Fprintf(&b, "Epochs: %s\n", m.trainingForm.inputs[4].View())
-	fmt.Fprintf(&b, "Learning Rate: %s\n", m.trainingForm.inputs[5].View())
-	fmt.Fprintf(&b, "Error Goal: %s\n", m.trainingForm.inputs[6].View())
+	fmt.Fprintf(&b, "Output Activation: %s\n\n", m.trainingForm.inputs[fieldOutputActivation].View())
+
+	fmt.Fprintf(&b, "Epochs: %s\n", m.trainingForm.inputs[fieldEpochs].View())
+	fmt.Fprintf(&b, "Learning Rate: %s\n", m.trainingForm.inputs[fieldLearningRate].View())
+	fmt.Fprintf(&b, "Error Goal: %s\n", m.trainingForm.inputs[fieldErrorGoal].View())
+	fmt.Fprintf(&b, "Input Channels (interleaved columns): %s\n", m.trainingForm.inputs[fieldChannels].View())
+	fmt.Fprintf(&b, "Sequence ID Column (groups rows into sequences): %s\n", m.trainingForm.inputs[fieldSequenceID].View())
+	fmt.Fprintf(&b, "Time Series (lookback,horizon[,stride[,target column]]): %s\n", m.trainingForm.inputs[fieldTimeSeries].View())
+	fmt.Fprintf(&b, "Categorical Columns (column:embedding dim,...): %s\n", m.trainingForm.inputs[fieldCategorical].View())
+	fmt.Fprintf(&b, "Encoded Columns (column:onehot[:max], column:ordinal:low<mid<high or column:frequency,...): %s\n", m.trainingForm.inputs[fieldEncoders].View())
+	fmt.Fprintf(&b, "Target Columns (names or 0-based indices, comma-separated): %s\n", m.trainingForm.inputs[fieldTargets].View())
+	fmt.Fprintf(&b, "Task Type (auto, regression or classification): %s\n", m.trainingForm.inputs[fieldTaskType].View())
+	fmt.Fprintf(&b, "Include Columns (inputs to use): %s\n", m.trainingForm.inputs[fieldIncludeColumns].View())
+	fmt.Fprintf(&b, "Exclude Columns (inputs to ignore): %s\n", m.trainingForm.inputs[fieldExcludeColumns].View())
+	fmt.Fprintf(&b, "Missing Values (column:drop|mean|median|mode|constant[:value][:indicator],...): %s\n", m.trainingForm.inputs[fieldImputation].View())
+	fmt.Fprintf(&b, "Missing Tokens (besides empty cells and NA): %s\n", m.trainingForm.inputs[fieldMissingTokens].View())
+	fmt.Fprintf(&b, "Scalers (column:minmax|zscore|robust|maxabs|log1p,...): %s\n", m.trainingForm.inputs[fieldScalers].View())
+	fmt.Fprintf(&b, "Features (name=sum|difference|product|ratio|square:column[:column],...): %s\n", m.trainingForm.inputs[fieldFeatures].View())
+	fmt.Fprintf(&b, "Head Settings (column:activation:loss[:weight],...): %s\n", m.trainingForm.inputs[fieldHeads].View())
+	fmt.Fprintf(&b, "Split Ratios (train[,validation]; the rest is the test set): %s\n", m.trainingForm.inputs[fieldSplit].View())
+	fmt.Fprintf(&b, "Split Strategy (random, stratified, group:column or time): %s\n", m.trainingForm.inputs[fieldSplitStrategy].View())
+	fmt.Fprintf(&b, "Cross-Validation (folds[,repeats]; replaces the split ratios): %s\n", m.trainingForm.inputs[fieldCrossValidation].View())
+	fmt.Fprintf(&b, "Tuning (grid, random, halving, hyperband or bayesian[:trials][:metric], or evolve[:generations[:max params]][:metric]; separate alternatives with |): %s\n", m.trainingForm.inputs[fieldTuning].View())
 	b.WriteString("\n")
 
 	// Render button
@@ -622,6 +1236,65 @@ func (m *Model) viewTrainingInProgress() string {
 	return fmt.Sprintf("Training in progress...\n\nEpoch: %d/%d\nLoss: %f\n\n(Press 'q' to stop)", m.currentEpoch, m.totalEpochs, m.lastLoss)
 }
 
+func (m *Model) viewCrossValidationInProgress() string {
+	return fmt.Sprintf("Cross-validation in progress...\n\nFolds: %d/%d\n\n(Press 'q' to stop)", m.foldsDone, m.totalFolds)
+}
+
+func (m *Model) viewCrossValidationResult() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Cross-validation complete! (%d folds)\n\n", len(m.crossValidation.Folds))
+	for _, summary := range m.crossValidation.Summaries {
+		fmt.Fprintf(&b, "%s: %.4f ± %.4f\n", summary.Name, summary.Mean, summary.Std)
+	}
+	b.WriteString("\n(Press enter to continue)")
+	return b.String()
+}
+
+func (m *Model) viewTuningInProgress() string {
+	best := "-"
+	if m.bestTrial != nil {
+		best = fmt.Sprintf("%.4f (trial %d)", m.bestTrial.Score, m.bestTrial.ID)
+	}
+	return fmt.Sprintf("Tuning in progress...\n\nTrials scored: %d\nBest validation %s: %s\n\n(Press 'q' to stop)", m.trialsDone, m.tuningMetric, best)
+}
+
+// maxLeaderboardRows is the number of trials the tuning result lists.
+const maxLeaderboardRows = 10
+
+func (m *Model) viewTuningResult() string {
+	var b strings.Builder
+	if m.evolution != nil {
+		fmt.Fprintf(&b, "Architecture search complete! (%d architectures)\n\nPareto front of validation %s against model size:\n\n", len(m.evolution.Trials), m.evolution.Metric)
+		fmt.Fprintf(&b, "%-8s %-12s %-20s %s\n", "Params", m.evolution.Metric, "Layers", "Activations")
+		for _, trial := range m.evolution.Front {
+			fmt.Fprintf(&b, "%-8d %-12.4f %-20s %s\n", trial.Params, trial.Score, trial.Config.Layers, strings.Join(trial.Config.HiddenActivations, ","))
+		}
+		m.writeTuningTest(&b)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "Tuning complete! (%d trials, ranked by validation %s)\n\n", len(m.tuning.Leaderboard), m.tuning.Metric)
+	fmt.Fprintf(&b, "%-4s %-6s %-12s %-20s %-20s %-10s %-10s %-7s %s\n", "Rank", "Trial", m.tuning.Metric, "Layers", "Activations", "Output", "LR", "Epochs", "Params")
+	for i, trial := range m.tuning.Leaderboard[:min(len(m.tuning.Leaderboard), maxLeaderboardRows)] {
+		if trial.Err != nil {
+			fmt.Fprintf(&b, "%-4d %-6d failed: %v\n", i+1, trial.ID, trial.Err)
+			continue
+		}
+		fmt.Fprintf(&b, "%-4d %-6d %-12.4f %-20s %-20s %-10s %-10.5g %-7d %d\n", i+1, trial.ID, trial.Score, trial.Config.Layers,
+			strings.Join(trial.Config.HiddenActivations, ","), trial.Config.OutputActivation, trial.Config.LearningRate, trial.Config.Epochs, trial.Params)
+	}
+	m.writeTuningTest(&b)
+	return b.String()
+}
+
+// writeTuningTest ends the tuning result with the test scores of the best model.
+func (m *Model) writeTuningTest(b *strings.Builder) {
+	b.WriteString("\nBest model on the test set:\n")
+	for _, metric := range m.tuningTest {
+		fmt.Fprintf(b, "  %s: %.4f\n", metric.Name, metric.Value)
+	}
+	b.WriteString("\n(Press enter to save the best model)")
+}
+
 func (m *Model) updatePredictionForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "ctrl+c", "q":
@@ -695,7 +1368,8 @@ func (m *Model) viewPredictionForm() string {
 	b.WriteString("\n")
 
 	fmt.Fprintf(&b, "Select Model (number): %s\n", m.predictionForm.inputs[0].View())
-	fmt.Fprintf(&b, "Input Data (comma-separated): %s\n", m.predictionForm.inputs[1].View())
+	fmt.Fprintf(&b, "Input Data (comma-separated, time steps of sequence models separated by ';'): %s\n", m.predictionForm.inputs[1].View())
+	fmt.Fprintf(&b, "Forecast Steps (time-series models): %s\n", m.predictionForm.inputs[2].View())
 	b.WriteString("\n")
 
 	button := "[ Predict ]"
@@ -722,55 +1396,129 @@ func (m *Model) runPrediction() tea.Cmd {
 		if err != nil {
 			return errorMsg{fmt.Errorf("failed to load model: %w", err)}
 		}
-		if err := modelData.NN.SetActivationFunctions(); err != nil {
-			return errorMsg{fmt.Errorf("failed to set activation functions: %w", err)}
+		if modelData.Model == nil {
+			return errorMsg{fmt.Errorf("model file does not contain a network")}
 		}
 
-		inputStrs := strings.Split(strings.TrimSpace(m.predictionForm.inputs[1].Value()), ",")
-		if len(inputStrs) != modelData.NN.NumInputs {
-			return errorMsg{fmt.Errorf("expected %d input values, but got %d", modelData.NN.NumInputs, len(inputStrs))}
-		}
-
-		predictionInput := make([]float64, modelData.NN.NumInputs)
-		for i, s := range inputStrs {
-			val, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if modelData.SequenceLength > 0 {
+			// Sequence models read one row of features per time step, separated by ';'.
+			var rows [][]float64
+			for _, rowStr := range strings.Split(strings.TrimSpace(m.predictionForm.inputs[1].Value()), ";") {
+				var row []float64
+				for _, s := range strings.Split(rowStr, ",") {
+					val, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+					if err != nil {
+						return errorMsg{fmt.Errorf("invalid input value: %v", err)}
+					}
+					row = append(row, val)
+				}
+				rows = append(rows, row)
+			}
+			if modelData.TimeSeries != nil {
+				steps := modelData.TimeSeries.Horizon
+				if stepsStr := strings.TrimSpace(m.predictionForm.inputs[2].Value()); stepsStr != "" {
+					steps, err = strconv.Atoi(stepsStr)
+					if err != nil || steps < 1 {
+						return errorMsg{fmt.Errorf("invalid forecast steps %q", stepsStr)}
+					}
+				}
+				forecast, err := modelData.Forecast(rows, steps)
+				if err != nil {
+					return errorMsg{err}
+				}
+				return predictionResultForecastMsg{result: forecast}
+			}
+			predictionInput, err := modelData.SequenceInput(rows)
 			if err != nil {
-				return errorMsg{fmt.Errorf("invalid input value: %v", err)}
+				return errorMsg{err}
 			}
-			predictionInput[i] = (val - modelData.InputMins[i]) / (modelData.InputMaxs[i] - modelData.InputMins[i])
+			return predictionMsg(modelData, modelData.Model.Predict(predictionInput))
 		}
 
-		_, predictionOutput := modelData.NN.FeedForward(predictionInput)
+		inputStrs := strings.Split(strings.TrimSpace(m.predictionForm.inputs[1].Value()), ",")
+		// Raw values go through the model's pipeline: categorical columns are given as
+		// category names and missing values as one of the missing-value tokens.
+		for i := range inputStrs {
+			inputStrs[i] = strings.TrimSpace(inputStrs[i])
+		}
+		predictionInput, err := modelData.EncodeInput(inputStrs)
+		if err != nil {
+			return errorMsg{err}
+		}
+		return predictionMsg(modelData, modelData.Model.Predict(predictionInput))
+	}
+}
 
-		if modelData.ClassMap != nil {
-			// Classification
-			max := -1.0
-			maxIndex := -1
-			for i, val := range predictionOutput {
-				if val > max {
-					max = val
-					maxIndex = i
-				}
+// predictionMsg turns the output of a model into the message that displays it.
+func predictionMsg(modelData *data.ModelData, predictionOutput []float64) tea.Msg {
+	if len(modelData.Heads) > 0 {
+		// Multi-task: decode every head on its own.
+		var results []string
+		for _, head := range modelData.Heads {
+			if head.ClassMap != nil {
+				class, score := head.Class(predictionOutput)
+				results = append(results, fmt.Sprintf("%s: %s (%.2f)", head.Name, class, score))
+			} else {
+				results = append(results, fmt.Sprintf("%s: %f", head.Name, head.Denormalize(predictionOutput)))
 			}
-			for class, index := range modelData.ClassMap {
-				if index == maxIndex {
-					return predictionResultClassificationMsg{result: class}
-				}
+		}
+		return predictionResultHeadsMsg{result: results}
+	}
+	if modelData.MultiLabel {
+		labels := modelData.Labels(predictionOutput)
+		if len(labels) == 0 {
+			return predictionResultClassificationMsg{result: "(no labels)"}
+		}
+		return predictionResultClassificationMsg{result: strings.Join(labels, ", ")}
+	}
+	if modelData.ClassMap != nil {
+		// Classification
+		max := -1.0
+		maxIndex := -1
+		for i, val := range predictionOutput {
+			if val > max {
+				max = val
+				maxIndex = i
+			}
+		}
+		for class, index := range modelData.ClassMap {
+			if index == maxIndex {
+				return predictionResultClassificationMsg{result: class}
 			}
-			return errorMsg{fmt.Errorf("could not determine class from prediction")}
-		} else {
-			// Regression
-			finalPrediction := predictionOutput[0]*(modelData.TargetMaxs[0]-modelData.TargetMins[0]) + modelData.TargetMins[0]
-			return predictionResultMsg{result: finalPrediction}
 		}
+		return errorMsg{fmt.Errorf("could not determine class from prediction")}
+	} else {
+		// Regression
+		return predictionResultMsg{result: modelData.DenormalizeTargets(predictionOutput), names: modelData.TargetNames}
 	}
 }
 
 func (m *Model) viewPredictionResult() string {
+	if len(m.predictionHeads) > 0 {
+		return fmt.Sprintf("Prediction Result:\n  %s\n\n(Press enter to return to main menu)", strings.Join(m.predictionHeads, "\n  "))
+	}
 	if m.predictionClass != "" {
 		return fmt.Sprintf("Prediction Result: %s\n\n(Press enter to return to main menu)", m.predictionClass)
 	}
-	return fmt.Sprintf("Prediction Result: %f\n\n(Press enter to return to main menu)", m.predictionValue)
+	if len(m.predictionForecast) > 0 {
+		var b strings.Builder
+		b.WriteString("Forecast:\n")
+		for i, val := range m.predictionForecast {
+			fmt.Fprintf(&b, "  t+%d: %f\n", i+1, val)
+		}
+		b.WriteString("\n(Press enter to return to main menu)")
+		return b.String()
+	}
+	if len(m.predictionValues) == 1 && len(m.predictionNames) == 0 {
+		return fmt.Sprintf("Prediction Result: %f\n\n(Press enter to return to main menu)", m.predictionValues[0])
+	}
+	var b strings.Builder
+	b.WriteString("Prediction Result:\n")
+	for i, val := range m.predictionValues {
+		fmt.Fprintf(&b, "  %s: %f\n", targetName(m.predictionNames, i), val)
+	}
+	b.WriteString("\n(Press enter to return to main menu)")
+	return b.String()
 }
 
 func (m *Model) updateSaveModelForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
@@ -823,3 +1571,354 @@ func Start() {
 		os.Exit(1)
 	}
 }
+
+// parseTimeSeriesOptions parses "lookback,horizon[,stride[,target column]]".
+func parseTimeSeriesOptions(s string) (*data.TimeSeriesOptions, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) < 2 || len(parts) > 4 {
+		return nil, fmt.Errorf("expected lookback,horizon[,stride[,target column]]")
+	}
+	opts := &data.TimeSeriesOptions{Stride: 1}
+	var err error
+	if opts.Lookback, err = strconv.Atoi(strings.TrimSpace(parts[0])); err != nil {
+		return nil, fmt.Errorf("invalid lookback: %w", err)
+	}
+	if opts.Horizon, err = strconv.Atoi(strings.TrimSpace(parts[1])); err != nil {
+		return nil, fmt.Errorf("invalid horizon: %w", err)
+	}
+	if len(parts) > 2 {
+		if opts.Stride, err = strconv.Atoi(strings.TrimSpace(parts[2])); err != nil {
+			return nil, fmt.Errorf("invalid stride: %w", err)
+		}
+	}
+	if len(parts) > 3 {
+		opts.TargetColumn = strings.TrimSpace(parts[3])
+	}
+	return opts, nil
+}
+
+// parseColumnList splits a comma-separated list of column names or indices, returning
+// nil for an empty list.
+func parseColumnList(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	var columns []string
+	for _, name := range strings.Split(s, ",") {
+		columns = append(columns, strings.TrimSpace(name))
+	}
+	return columns
+}
+
+// parseCategoricalColumns parses "column:dim,..." into embedding dimensions per column.
+func parseCategoricalColumns(s string) (map[string]int, error) {
+	columns := make(map[string]int)
+	for _, part := range strings.Split(s, ",") {
+		name, dimStr, found := strings.Cut(part, ":")
+		if !found {
+			return nil, fmt.Errorf("expected column:dim, got %q", part)
+		}
+		dim, err := strconv.Atoi(strings.TrimSpace(dimStr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid embedding dimension %q", dimStr)
+		}
+		columns[strings.TrimSpace(name)] = dim
+	}
+	return columns, nil
+}
+
+// targetName returns the name of output i, falling back to its number for models saved
+// without target names.
+func targetName(names []string, i int) string {
+	if i < len(names) {
+		return names[i]
+	}
+	return fmt.Sprintf("Output %d", i+1)
+}
+
+// parseEncoders parses "column:onehot[:max categories]", "column:ordinal:a<b<c" and
+// "column:frequency" entries, separated by commas, into the encoders of the named columns.
+func parseEncoders(s string) (map[string]data.Encoder, error) {
+	encoders := make(map[string]data.Encoder)
+	for _, part := range strings.Split(s, ",") {
+		fields := strings.Split(part, ":")
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("expected column:encoding[:option], got %q", part)
+		}
+		encoder := data.Encoder{Kind: data.EncoderKind(strings.TrimSpace(fields[1]))}
+		if len(fields) == 3 {
+			option := strings.TrimSpace(fields[2])
+			switch encoder.Kind {
+			case data.EncodeOneHot:
+				maxCategories, err := strconv.Atoi(option)
+				if err != nil || maxCategories < 1 {
+					return nil, fmt.Errorf("invalid maximum number of categories %q", option)
+				}
+				encoder.MaxCategories = maxCategories
+			case data.EncodeOrdinal:
+				for _, category := range strings.Split(option, "<") {
+					encoder.Categories = append(encoder.Categories, strings.TrimSpace(category))
+				}
+			default:
+				return nil, fmt.Errorf("unexpected option %q in %q", option, part)
+			}
+		}
+		encoders[strings.TrimSpace(fields[0])] = encoder
+	}
+	return encoders, nil
+}
+
+// parseImputation parses "column:strategy[:value][:indicator],..." into the imputers of
+// the named columns, where "*" stands for every other numeric input column. The value is
+// required by the constant strategy and "indicator" adds a missing-indicator feature.
+func parseImputation(s string) (map[string]data.Imputer, error) {
+	imputation := make(map[string]data.Imputer)
+	for _, part := range strings.Split(s, ",") {
+		fields := strings.Split(part, ":")
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("expected column:strategy, got %q", part)
+		}
+		imputer := data.Imputer{Strategy: data.ImputeStrategy(strings.TrimSpace(fields[1]))}
+		for _, option := range fields[2:] {
+			option = strings.TrimSpace(option)
+			if option == "indicator" {
+				imputer.Indicator = true
+				continue
+			}
+			value, err := strconv.ParseFloat(option, 64)
+			if err != nil || imputer.Strategy != data.ImputeConstant {
+				return nil, fmt.Errorf("unexpected %q in %q", option, part)
+			}
+			imputer.Value = value
+		}
+		imputation[strings.TrimSpace(fields[0])] = imputer
+	}
+	return imputation, nil
+}
+
+// parseScalers parses "column:scaler,..." into the scaler of each named column, where "*"
+// stands for every other numeric column.
+func parseScalers(s string) (map[string]string, error) {
+	scalers := make(map[string]string)
+	for _, part := range strings.Split(s, ",") {
+		name, scaler, found := strings.Cut(part, ":")
+		if !found {
+			return nil, fmt.Errorf("expected column:scaler, got %q", part)
+		}
+		scalers[strings.TrimSpace(name)] = strings.TrimSpace(scaler)
+	}
+	return scalers, nil
+}
+
+// parseFeatures parses "name=op:column[:column],..." into engineered features, e.g.
+// "bmi=ratio:weight:height".
+func parseFeatures(s string) ([]data.Feature, error) {
+	var features []data.Feature
+	for _, part := range strings.Split(s, ",") {
+		name, def, found := strings.Cut(part, "=")
+		fields := strings.Split(def, ":")
+		if !found || len(fields) < 2 {
+			return nil, fmt.Errorf("expected name=op:column[:column], got %q", part)
+		}
+		feature := data.Feature{Name: strings.TrimSpace(name), Op: data.FeatureOp(strings.TrimSpace(fields[0]))}
+		for _, column := range fields[1:] {
+			feature.Columns = append(feature.Columns, strings.TrimSpace(column))
+		}
+		features = append(features, feature)
+	}
+	return features, nil
+}
+
+// parseSplitRatios parses "train[,validation]" into the fractions of rows used for
+// training and validation. Empty means 0.8 for training and no validation set.
+func parseSplitRatios(s string) (train, validation float64, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0.8, 0, nil
+	}
+	trainStr, validationStr, found := strings.Cut(s, ",")
+	if train, err = strconv.ParseFloat(strings.TrimSpace(trainStr), 64); err != nil {
+		return 0, 0, err
+	}
+	if found {
+		if validation, err = strconv.ParseFloat(strings.TrimSpace(validationStr), 64); err != nil {
+			return 0, 0, err
+		}
+	}
+	if train <= 0 || validation < 0 || train+validation > 1 {
+		return 0, 0, fmt.Errorf("ratios must be positive and add up to at most 1, got %s", s)
+	}
+	return train, validation, nil
+}
+
+// parseEvolution parses "evolve[:generations[:max params]][:metric]", for example
+// "evolve:10:5000".
+func parseEvolution(s string) (tuning.EvolveOptions, error) {
+	var opts tuning.EvolveOptions
+	rest := strings.Split(s, ":")[1:]
+	for _, field := range []*int{&opts.Generations, &opts.MaxParams} {
+		if len(rest) == 0 {
+			break
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(rest[0]))
+		if err != nil {
+			break
+		}
+		if n < 1 {
+			return tuning.EvolveOptions{}, fmt.Errorf("generations and the parameter budget must be positive, got %d", n)
+		}
+		*field = n
+		rest = rest[1:]
+	}
+	if len(rest) > 0 {
+		opts.Metric = strings.TrimSpace(strings.Join(rest, ":"))
+	}
+	return opts, nil
+}
+
+// parseTuning parses "strategy[:trials][:metric]", for example "random:20" or
+// "halving:27:accuracy".
+func parseTuning(s string) (tuning.Options, error) {
+	parts := strings.Split(s, ":")
+	opts := tuning.Options{Strategy: tuning.Strategy(strings.TrimSpace(parts[0]))}
+	switch opts.Strategy {
+	case tuning.Grid, tuning.Random, tuning.Halving, tuning.Hyperband, tuning.Bayesian:
+	default:
+		return tuning.Options{}, fmt.Errorf("unknown strategy %q", parts[0])
+	}
+	rest := parts[1:]
+	if len(rest) > 0 {
+		if trials, err := strconv.Atoi(strings.TrimSpace(rest[0])); err == nil {
+			if trials < 1 {
+				return tuning.Options{}, fmt.Errorf("trials must be positive, got %d", trials)
+			}
+			opts.Trials = trials
+			rest = rest[1:]
+		}
+	}
+	if len(rest) > 0 {
+		opts.Metric = strings.TrimSpace(strings.Join(rest, ":"))
+	}
+	return opts, nil
+}
+
+// tuningHistoryDir holds the trials of Bayesian searches, one file per dataset and metric.
+const tuningHistoryDir = "tuning_history"
+
+// tuningHistoryPath returns the history file of a Bayesian search of the dataset by metric.
+func tuningHistoryPath(csvPath, metric string) string {
+	name := strings.TrimSuffix(filepath.Base(csvPath), filepath.Ext(csvPath)) + "-" + metric
+	name = strings.Map(func(r rune) rune {
+		if r < 128 && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_') {
+			return r
+		}
+		return '_'
+	}, name)
+	return filepath.Join(tuningHistoryDir, name+".json")
+}
+
+// parseSearchSpace reads the "|"-separated alternatives of the hidden layers, hidden
+// activations, output activation, learning rate and epochs fields. Hidden layer
+// alternatives of the form "min..max[*depth]" search the width of depth dense layers
+// between min and max instead of listing layer specifications.
+func parseSearchSpace(layers, activations, outputActivations, learningRates, epochs string) (tuning.Space, error) {
+	var space tuning.Space
+	for _, spec := range parseAlternatives(layers) {
+		widths, depthStr, hasDepth := strings.Cut(spec, "*")
+		minStr, maxStr, isRange := strings.Cut(widths, "..")
+		if !isRange {
+			space.Layers = append(space.Layers, spec)
+			continue
+		}
+		low, errLow := strconv.Atoi(strings.TrimSpace(minStr))
+		high, errHigh := strconv.Atoi(strings.TrimSpace(maxStr))
+		depth := 1
+		var errDepth error
+		if hasDepth {
+			depth, errDepth = strconv.Atoi(strings.TrimSpace(depthStr))
+		}
+		if errLow != nil || errHigh != nil || errDepth != nil || low < 1 || high < low || depth < 1 {
+			return tuning.Space{}, fmt.Errorf("invalid layer width range %q", spec)
+		}
+		if space.Width.Max > 0 && space.Width != (tuning.IntRange{Min: low, Max: high}) {
+			return tuning.Space{}, fmt.Errorf("layer width ranges must be the same, got %q", spec)
+		}
+		space.Width = tuning.IntRange{Min: low, Max: high}
+		space.Depths = append(space.Depths, depth)
+	}
+	if space.Width.Max > 0 && len(space.Layers) > 0 {
+		return tuning.Space{}, fmt.Errorf("hidden layers cannot mix width ranges and layer specifications")
+	}
+	space.Activations = parseAlternatives(activations)
+	space.OutputActivations = parseAlternatives(outputActivations)
+	for _, lr := range parseAlternatives(learningRates) {
+		val, err := strconv.ParseFloat(lr, 64)
+		if err != nil {
+			return tuning.Space{}, fmt.Errorf("invalid learning rate %q", lr)
+		}
+		space.LearningRates = append(space.LearningRates, val)
+	}
+	for _, e := range parseAlternatives(epochs) {
+		val, err := strconv.Atoi(e)
+		if err != nil || val < 1 {
+			return tuning.Space{}, fmt.Errorf("invalid epochs %q", e)
+		}
+		space.Epochs = append(space.Epochs, val)
+	}
+	return space, nil
+}
+
+// parseAlternatives splits "a|b|c" into its trimmed, non-empty parts.
+func parseAlternatives(s string) []string {
+	var alternatives []string
+	for _, part := range strings.Split(s, "|") {
+		if part = strings.TrimSpace(part); part != "" {
+			alternatives = append(alternatives, part)
+		}
+	}
+	return alternatives
+}
+
+// parseCrossValidation parses "folds[,repeats]".
+func parseCrossValidation(s string) (training.CVOptions, error) {
+	foldsStr, repeatsStr, hasRepeats := strings.Cut(s, ",")
+	folds, err := strconv.Atoi(strings.TrimSpace(foldsStr))
+	if err != nil || folds < 2 {
+		return training.CVOptions{}, fmt.Errorf("folds %q must be a whole number of at least 2", foldsStr)
+	}
+	repeats := 1
+	if hasRepeats {
+		repeats, err = strconv.Atoi(strings.TrimSpace(repeatsStr))
+		if err != nil || repeats < 1 {
+			return training.CVOptions{}, fmt.Errorf("repeats %q must be a positive whole number", repeatsStr)
+		}
+	}
+	return training.CVOptions{Folds: folds, Repeats: repeats}, nil
+}
+
+// parseHeadSettings parses "column:activation:loss[:weight],..." into the output layer
+// of each named head of a multi-task model. The weight defaults to 1.
+func parseHeadSettings(s string) (map[string]neuralnetwork.HeadSpec, error) {
+	heads := make(map[string]neuralnetwork.HeadSpec)
+	for _, part := range strings.Split(s, ",") {
+		fields := strings.Split(part, ":")
+		if len(fields) < 3 || len(fields) > 4 {
+			return nil, fmt.Errorf("expected column:activation:loss[:weight], got %q", part)
+		}
+		head := neuralnetwork.HeadSpec{
+			Name:       strings.TrimSpace(fields[0]),
+			Activation: strings.TrimSpace(fields[1]),
+			Loss:       strings.TrimSpace(fields[2]),
+			Weight:     1,
+		}
+		if len(fields) == 4 {
+			weight, err := strconv.ParseFloat(strings.TrimSpace(fields[3]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid loss weight %q", fields[3])
+			}
+			head.Weight = weight
+		}
+		heads[head.Name] = head
+	}
+	return heads, nil
+}
@@ -0,0 +1,318 @@
+package tuning
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"slices"
+	"sort"
+)
+
+const (
+	// tpeGamma is the fraction of the scored trials whose values make up the density of
+	// good settings.
+	tpeGamma = 0.25
+	// tpeCandidates is the number of values drawn from the density of good settings, of
+	// which the one most likely to improve on the best trial is kept.
+	tpeCandidates = 24
+	// maxStartupTrials caps the trials sampled at random before the estimator takes over.
+	maxStartupTrials = 10
+)
+
+// param is one setting of the space as the estimator sees it: a categorical choice
+// between values, or a number on a log scale.
+type param struct {
+	// choices is the number of values of a categorical parameter, or 0 for a numeric one.
+	choices int
+	// low and high bound the logarithm of a numeric parameter.
+	low, high float64
+	// get returns the choice index or the log value of the candidate's setting, or NaN
+	// when the setting is not in the space.
+	get func(c candidate) float64
+	set func(c *candidate, v float64)
+}
+
+// params returns the settings of the space: the layer specification, or the width and
+// the depth of the layers, the hidden and output activations, the learning rate and the
+// epochs. The learning rate is numeric when the space spans positive learning rates.
+func (space Space) params() []param {
+	var params []param
+	if space.Width.Max > 0 {
+		params = append(params,
+			param{
+				low:  math.Log(float64(max(space.Width.Min, 1))),
+				high: math.Log(float64(space.Width.Max)),
+				get:  func(c candidate) float64 { return logOrNaN(float64(c.width)) },
+				set: func(c *candidate, v float64) {
+					c.width = min(max(int(math.Round(math.Exp(v))), space.Width.Min, 1), space.Width.Max)
+				},
+			},
+			choice(space.Depths, func(c *candidate) *int { return &c.depth }))
+	} else {
+		params = append(params, choice(space.Layers, func(c *candidate) *string { return &c.layers }))
+	}
+	params = append(params,
+		choice(space.Activations, func(c *candidate) *string { return &c.activations }),
+		choice(space.OutputActivations, func(c *candidate) *string { return &c.outputActivation }),
+		choice(space.Epochs, func(c *candidate) *int { return &c.epochs }))
+
+	lowest, highest := slices.Min(space.LearningRates), slices.Max(space.LearningRates)
+	if lowest > 0 && highest > lowest {
+		params = append(params, param{
+			low:  math.Log(lowest),
+			high: math.Log(highest),
+			get:  func(c candidate) float64 { return logOrNaN(c.learningRate) },
+			set:  func(c *candidate, v float64) { c.learningRate = math.Exp(v) },
+		})
+	} else {
+		params = append(params, choice(space.LearningRates, func(c *candidate) *float64 { return &c.learningRate }))
+	}
+	return params
+}
+
+// choice returns a categorical parameter over values, stored in the field of the
+// candidate that field points to.
+func choice[T comparable](values []T, field func(c *candidate) *T) param {
+	return param{
+		choices: len(values),
+		get: func(c candidate) float64 {
+			if i := slices.Index(values, *field(&c)); i >= 0 {
+				return float64(i)
+			}
+			return math.NaN()
+		},
+		set: func(c *candidate, v float64) { *field(c) = values[int(v)] },
+	}
+}
+
+func logOrNaN(v float64) float64 {
+	if v <= 0 {
+		return math.NaN()
+	}
+	return math.Log(v)
+}
+
+// uniform draws a value of the parameter from its whole range.
+func (p param) uniform(r *rand.Rand) float64 {
+	if p.choices > 0 {
+		return float64(r.Intn(p.choices))
+	}
+	return p.low + r.Float64()*(p.high-p.low)
+}
+
+// suggest returns the value the Tree-structured Parzen Estimator picks given the values
+// of the good and of the other trials: of tpeCandidates values drawn from the density l
+// of the good values, the one with the highest ratio l/g to the density g of the other
+// values, which is the one with the highest expected improvement.
+func (p param) suggest(r *rand.Rand, good, bad []float64) float64 {
+	best, bestRatio := 0.0, math.Inf(-1)
+	for i := 0; i < tpeCandidates; i++ {
+		v := p.draw(r, good)
+		if ratio := p.logDensity(v, good) - p.logDensity(v, bad); ratio > bestRatio {
+			best, bestRatio = v, ratio
+		}
+	}
+	return best
+}
+
+// draw samples the Parzen density of the observed values: a mixture of the uniform
+// prior and, for numeric parameters, a Gaussian around every observation, or, for
+// categorical ones, the observed choices themselves.
+func (p param) draw(r *rand.Rand, observed []float64) float64 {
+	j := r.Intn(len(observed) + 1)
+	if j == len(observed) {
+		return p.uniform(r)
+	}
+	if p.choices > 0 {
+		return observed[j]
+	}
+	return min(max(observed[j]+p.bandwidth(observed)*r.NormFloat64(), p.low), p.high)
+}
+
+// logDensity returns the logarithm of the Parzen density of the observed values at v.
+func (p param) logDensity(v float64, observed []float64) float64 {
+	n := float64(len(observed))
+	if p.choices > 0 {
+		count := 0.0
+		for _, o := range observed {
+			if o == v {
+				count++
+			}
+		}
+		// One pseudo-observation of every choice is the uniform prior.
+		return math.Log((count + 1) / (n + float64(p.choices)))
+	}
+	density := 1 / (p.high - p.low)
+	sigma := p.bandwidth(observed)
+	for _, o := range observed {
+		z := (v - o) / sigma
+		density += math.Exp(-z*z/2) / (sigma * math.Sqrt(2*math.Pi))
+	}
+	return math.Log(density / (n + 1))
+}
+
+// bandwidth returns the width of the Gaussians around the observed values by Scott's
+// rule, kept between a twentieth and the whole of the range.
+func (p param) bandwidth(observed []float64) float64 {
+	mean, variance := 0.0, 0.0
+	for _, o := range observed {
+		mean += o / float64(len(observed))
+	}
+	for _, o := range observed {
+		variance += (o - mean) * (o - mean) / float64(len(observed))
+	}
+	width := p.high - p.low
+	return min(max(1.06*math.Sqrt(variance)*math.Pow(float64(len(observed)), -0.2), width/20), width)
+}
+
+// record is one trial in the history file of a Bayesian search.
+type record struct {
+	Layers           string  `json:"layers,omitempty"`
+	Width            int     `json:"width,omitempty"`
+	Depth            int     `json:"depth,omitempty"`
+	Activations      string  `json:"activations"`
+	OutputActivation string  `json:"outputActivation"`
+	LearningRate     float64 `json:"learningRate"`
+	Epochs           int     `json:"epochs"`
+	Metric           string  `json:"metric"`
+	Score            float64 `json:"score"`
+	Error            string  `json:"error,omitempty"`
+}
+
+// optimize runs Bayesian search: the first trials are sampled at random and the rest
+// suggested by the estimator, a batch of Parallel trials at a time. Trials are loaded
+// from and saved to the history file when one is named. When the best trial was loaded
+// from the history, it is trained again at the end so that its model can be saved.
+func (s *searcher) optimize(history string) error {
+	if history != "" {
+		if err := s.loadHistory(history); err != nil {
+			return err
+		}
+	}
+	params := s.space.params()
+	startup := min(max(s.trials/3, 2), maxStartupTrials)
+	for len(s.all) < s.trials {
+		n := min(s.parallel, s.trials-len(s.all))
+		var candidates []candidate
+		if scored := s.scored(); len(scored) < startup {
+			candidates = s.sample(n)
+		} else {
+			for i := 0; i < n; i++ {
+				candidates = append(candidates, s.suggest(params, scored))
+			}
+		}
+		s.runAll(s.add(candidates...), 0)
+		if history != "" {
+			if err := s.saveHistory(history); err != nil {
+				return err
+			}
+		}
+	}
+	if scored := s.scored(); len(scored) > 0 && scored[0].Model == nil {
+		s.runAll(s.add(scored[0].candidate), 0)
+		if history != "" {
+			return s.saveHistory(history)
+		}
+	}
+	return nil
+}
+
+// scored returns the trials that did not fail, best first.
+func (s *searcher) scored() []*Trial {
+	var scored []*Trial
+	for _, t := range s.all {
+		if t.Err == nil {
+			scored = append(scored, t)
+		}
+	}
+	lower := LowerIsBetter(s.metric)
+	sort.SliceStable(scored, func(i, j int) bool { return better(scored[i].Score, scored[j].Score, lower) })
+	return scored
+}
+
+// suggest picks every setting of the next candidate with the estimator, splitting the
+// scored trials, best first, into the good and the other ones.
+func (s *searcher) suggest(params []param, scored []*Trial) candidate {
+	nGood := max(int(math.Ceil(tpeGamma*float64(len(scored)))), 1)
+	var c candidate
+	for _, p := range params {
+		var good, bad []float64
+		for i, t := range scored {
+			v := p.get(t.candidate)
+			if math.IsNaN(v) {
+				continue
+			}
+			if i < nGood {
+				good = append(good, v)
+			} else {
+				bad = append(bad, v)
+			}
+		}
+		p.set(&c, p.suggest(s.rand, good, bad))
+	}
+	return c
+}
+
+// loadHistory adds the trials of an earlier search from a history file, if it exists.
+func (s *searcher) loadHistory(path string) error {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var records []record
+	if err := json.Unmarshal(content, &records); err != nil {
+		return fmt.Errorf("failed to read tuning history %s: %w", path, err)
+	}
+	for _, r := range records {
+		if r.Metric != s.metric {
+			return fmt.Errorf("tuning history %s is scored by %q, not %q", path, r.Metric, s.metric)
+		}
+		t := s.add(candidate{
+			layers:           r.Layers,
+			width:            r.Width,
+			depth:            r.Depth,
+			activations:      r.Activations,
+			outputActivation: r.OutputActivation,
+			learningRate:     r.LearningRate,
+			epochs:           r.Epochs,
+		})[0]
+		t.Score = r.Score
+		if r.Error != "" {
+			t.Err = errors.New(r.Error)
+		}
+	}
+	return nil
+}
+
+// saveHistory writes every trial so far to a history file.
+func (s *searcher) saveHistory(path string) error {
+	records := make([]record, len(s.all))
+	for i, t := range s.all {
+		c := t.candidate
+		records[i] = record{
+			Layers:           c.layers,
+			Width:            c.width,
+			Depth:            c.depth,
+			Activations:      c.activations,
+			OutputActivation: c.outputActivation,
+			LearningRate:     c.learningRate,
+			Epochs:           c.epochs,
+			Metric:           s.metric,
+			Score:            t.Score,
+		}
+		if t.Err != nil {
+			records[i].Error = t.Err.Error()
+		}
+	}
+	content, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o644)
+}
@@ -0,0 +1,372 @@
+package tuning
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-neuralnetwork/internal/data"
+	"go-neuralnetwork/internal/training"
+)
+
+// maxMutationAttempts is the number of mutations tried for a child before giving up on
+// finding one that is new and within the parameter budget.
+const maxMutationAttempts = 20
+
+// Architecture is a stack of dense hidden layers, each with its own activation.
+type Architecture struct {
+	Widths      []int
+	Activations []string
+}
+
+// Layers returns the hidden layer specification of the architecture.
+func (a Architecture) Layers() string {
+	widths := make([]string, len(a.Widths))
+	for i, width := range a.Widths {
+		widths[i] = strconv.Itoa(width)
+	}
+	return strings.Join(widths, ",")
+}
+
+// key identifies the architecture, to train every architecture once.
+func (a Architecture) key() string {
+	return a.Layers() + "/" + strings.Join(a.Activations, ",")
+}
+
+// EvolveOptions configures Evolve.
+type EvolveOptions struct {
+	// Population is the number of architectures kept from one generation to the next,
+	// and the number of children bred from them in every generation; 0 means 8.
+	Population int
+	// Generations is the number of generations of children; 0 means 5.
+	Generations int
+	// MaxParams is the parameter budget: architectures whose model has more trainable
+	// parameters are never trained. 0 means no budget.
+	MaxParams int
+	// Width bounds the width of every layer; a zero Max means 2 to 128.
+	Width IntRange
+	// MaxDepth is the most hidden layers an architecture has; 0 means 4.
+	MaxDepth int
+	// Activations lists the activations layers choose from; nil means relu, tanh and sigmoid.
+	Activations []string
+	// Metric names the validation metric, as for Search; "" means the accuracy of
+	// classification models and the loss of other models.
+	Metric string
+	// Parallel is the number of architectures trained at the same time; 0 means one per CPU.
+	Parallel int
+	// Seed drives the sampling and the mutations; 0 picks a seed from the clock.
+	Seed int64
+}
+
+// MetricName returns the name of the validation metric the architectures of dataset
+// are scored by.
+func (o EvolveOptions) MetricName(dataset *data.Dataset) string {
+	if o.Metric != "" {
+		return o.Metric
+	}
+	if dataset.ClassMap != nil && len(dataset.Heads) == 0 && !dataset.MultiLabel {
+		return "accuracy"
+	}
+	return "loss"
+}
+
+// Evolution holds the architectures tried by Evolve.
+type Evolution struct {
+	// Metric is the name of the validation metric the architectures are scored by.
+	Metric string
+	// Trials holds every architecture trained, in order.
+	Trials []Trial
+	// Front is the Pareto front of validation score against model size: the trials no
+	// other trial matches with fewer parameters, from the smallest model to the largest,
+	// so the last one scores best.
+	Front []Trial
+}
+
+// Best returns the trial with the best validation score.
+func (e *Evolution) Best() Trial {
+	return e.Front[len(e.Front)-1]
+}
+
+// Evolve searches for dense architectures by evolution. It trains a population of
+// random architectures, then in every generation breeds as many children, each a
+// mutation of the better of two random members: a layer added or removed, a layer made
+// twice as wide or half as wide, or the activation of a layer swapped. Members and
+// children are ranked by Pareto dominance on validation score and parameter count,
+// then by score, and the best make up the next population. Settings other than the
+// hidden layers come from base.
+//
+// Trials are trained in parallel and sent to progress when scored; progress is closed
+// at the end and may be nil. The dataset needs validation rows.
+func Evolve(dataset *data.Dataset, base training.Config, opts EvolveOptions, progress chan<- Trial) (*Evolution, error) {
+	if progress != nil {
+		defer close(progress)
+	}
+	if len(dataset.ValidationInputs) == 0 {
+		return nil, fmt.Errorf("architecture search needs validation rows to score the architectures on")
+	}
+	if opts.Population < 1 {
+		opts.Population = 8
+	}
+	if opts.Generations < 1 {
+		opts.Generations = 5
+	}
+	if opts.Width.Max == 0 {
+		opts.Width = IntRange{Min: 2, Max: 128}
+	}
+	opts.Width.Min = max(opts.Width.Min, 1)
+	if opts.Width.Min > opts.Width.Max {
+		return nil, fmt.Errorf("invalid layer width range %d to %d", opts.Width.Min, opts.Width.Max)
+	}
+	if opts.MaxDepth < 1 {
+		opts.MaxDepth = 4
+	}
+	if len(opts.Activations) == 0 {
+		opts.Activations = []string{"relu", "tanh", "sigmoid"}
+	}
+	opts.Metric = opts.MetricName(dataset)
+	if opts.Parallel < 1 {
+		opts.Parallel = runtime.NumCPU()
+	}
+	if opts.Seed == 0 {
+		opts.Seed = time.Now().UnixNano()
+	}
+	e := &evolver{
+		searcher: searcher{
+			dataset:  dataset,
+			base:     base,
+			metric:   opts.Metric,
+			parallel: opts.Parallel,
+			rand:     rand.New(rand.NewSource(opts.Seed)),
+			progress: progress,
+		},
+		opts: opts,
+		seen: make(map[string]bool),
+	}
+
+	var population []member
+	var initial []Architecture
+	for attempt := 0; len(initial) < opts.Population && attempt < opts.Population*maxMutationAttempts; attempt++ {
+		if a := e.random(); e.admit(a) {
+			initial = append(initial, a)
+		}
+	}
+	if len(initial) == 0 {
+		return nil, fmt.Errorf("no architecture fits the budget of %d parameters", opts.MaxParams)
+	}
+	population = e.train(initial)
+	for generation := 0; generation < opts.Generations && len(population) > 0; generation++ {
+		var children []Architecture
+		for attempt := 0; len(children) < opts.Population && attempt < opts.Population*maxMutationAttempts; attempt++ {
+			if child := e.mutate(e.tournament(population).arch); e.admit(child) {
+				children = append(children, child)
+			}
+		}
+		if len(children) == 0 {
+			break
+		}
+		population = e.survivors(append(population, e.train(children)...))
+	}
+
+	evolution := &Evolution{Metric: opts.Metric}
+	var scored []Trial
+	for _, t := range e.all {
+		evolution.Trials = append(evolution.Trials, *t)
+		if t.Err == nil {
+			scored = append(scored, *t)
+		}
+	}
+	if len(scored) == 0 {
+		return nil, fmt.Errorf("every architecture failed, for example: %w", e.all[0].Err)
+	}
+	// Walking from the smallest model up, a trial is on the front when it scores better
+	// than every smaller one.
+	lower := LowerIsBetter(opts.Metric)
+	sort.SliceStable(scored, func(i, j int) bool {
+		if scored[i].Params != scored[j].Params {
+			return scored[i].Params < scored[j].Params
+		}
+		return better(scored[i].Score, scored[j].Score, lower)
+	})
+	for _, t := range scored {
+		if len(evolution.Front) == 0 || better(t.Score, evolution.Front[len(evolution.Front)-1].Score, lower) {
+			evolution.Front = append(evolution.Front, t)
+		}
+	}
+	return evolution, nil
+}
+
+// evolver holds the state of one Evolve.
+type evolver struct {
+	searcher
+	opts EvolveOptions
+	// seen holds the architectures already admitted.
+	seen map[string]bool
+}
+
+// member is a trained architecture of the population with its Pareto rank.
+type member struct {
+	arch  Architecture
+	trial *Trial
+	rank  int
+}
+
+// random returns an architecture of one or two layers of random widths and activations.
+func (e *evolver) random() Architecture {
+	var a Architecture
+	depth := 1 + e.rand.Intn(min(2, e.opts.MaxDepth))
+	for i := 0; i < depth; i++ {
+		a.Widths = append(a.Widths, e.randomWidth())
+		a.Activations = append(a.Activations, e.randomActivation())
+	}
+	return a
+}
+
+// randomWidth returns a width drawn log-uniformly from the width range.
+func (e *evolver) randomWidth() int {
+	low, high := math.Log(float64(e.opts.Width.Min)), math.Log(float64(e.opts.Width.Max))
+	return min(max(int(math.Round(math.Exp(low+e.rand.Float64()*(high-low)))), e.opts.Width.Min), e.opts.Width.Max)
+}
+
+func (e *evolver) randomActivation() string {
+	return e.opts.Activations[e.rand.Intn(len(e.opts.Activations))]
+}
+
+// mutate returns a copy of the architecture with one random change. A change that does
+// not apply, such as removing the only layer, leaves the architecture as it is.
+func (e *evolver) mutate(parent Architecture) Architecture {
+	a := Architecture{Widths: slices.Clone(parent.Widths), Activations: slices.Clone(parent.Activations)}
+	i := e.rand.Intn(len(a.Widths))
+	switch e.rand.Intn(5) {
+	case 0: // add a layer
+		if len(a.Widths) < e.opts.MaxDepth {
+			at := e.rand.Intn(len(a.Widths) + 1)
+			a.Widths = slices.Insert(a.Widths, at, e.randomWidth())
+			a.Activations = slices.Insert(a.Activations, at, e.randomActivation())
+		}
+	case 1: // remove a layer
+		if len(a.Widths) > 1 {
+			a.Widths = slices.Delete(a.Widths, i, i+1)
+			a.Activations = slices.Delete(a.Activations, i, i+1)
+		}
+	case 2: // widen
+		a.Widths[i] = min(a.Widths[i]*2, e.opts.Width.Max)
+	case 3: // narrow
+		a.Widths[i] = max(a.Widths[i]/2, e.opts.Width.Min)
+	case 4: // swap the activation
+		a.Activations[i] = e.randomActivation()
+	}
+	return a
+}
+
+// admit reports whether the architecture is new and builds a model within the parameter
+// budget, and marks it as seen.
+func (e *evolver) admit(a Architecture) bool {
+	if e.seen[a.key()] {
+		return false
+	}
+	if e.opts.MaxParams > 0 {
+		cfg := e.base
+		cfg.Layers, cfg.HiddenActivations = a.Layers(), a.Activations
+		nn, err := training.BuildModel(e.dataset, cfg)
+		if err != nil || nn.NumParams() > e.opts.MaxParams {
+			return false
+		}
+	}
+	e.seen[a.key()] = true
+	return true
+}
+
+// train trains and scores the architectures in parallel and returns those that did not fail.
+func (e *evolver) train(archs []Architecture) []member {
+	candidates := make([]candidate, len(archs))
+	for i, a := range archs {
+		candidates[i] = candidate{
+			layers:           a.Layers(),
+			activations:      strings.Join(a.Activations, ","),
+			outputActivation: e.base.OutputActivation,
+			learningRate:     e.base.LearningRate,
+			epochs:           e.base.Epochs,
+		}
+	}
+	trials := e.add(candidates...)
+	e.runAll(trials, 0)
+	var members []member
+	for i, t := range trials {
+		if t.Err == nil {
+			members = append(members, member{arch: archs[i], trial: t})
+		}
+	}
+	return members
+}
+
+// tournament returns the better of two random members of the population.
+func (e *evolver) tournament(population []member) member {
+	a, b := population[e.rand.Intn(len(population))], population[e.rand.Intn(len(population))]
+	if e.ahead(b, a) {
+		return b
+	}
+	return a
+}
+
+// ahead reports whether member a ranks before member b: on a better Pareto front, or on
+// the same front with a better score.
+func (e *evolver) ahead(a, b member) bool {
+	if a.rank != b.rank {
+		return a.rank < b.rank
+	}
+	return better(a.trial.Score, b.trial.Score, LowerIsBetter(e.metric))
+}
+
+// survivors ranks the members into Pareto fronts and keeps the Population best.
+func (e *evolver) survivors(members []member) []member {
+	lower := LowerIsBetter(e.metric)
+	for i := range members {
+		members[i].rank = -1
+	}
+	// Repeatedly peel off the members no remaining member dominates.
+	for rank, left := 0, len(members); left > 0; rank++ {
+		var front []int
+		for i := range members {
+			if members[i].rank != -1 {
+				continue
+			}
+			dominated := false
+			for j := range members {
+				if j != i && members[j].rank == -1 && dominates(members[j].trial, members[i].trial, lower) {
+					dominated = true
+					break
+				}
+			}
+			if !dominated {
+				front = append(front, i)
+			}
+		}
+		for _, i := range front {
+			members[i].rank = rank
+		}
+		left -= len(front)
+	}
+	sort.SliceStable(members, func(i, j int) bool { return e.ahead(members[i], members[j]) })
+	return members[:min(len(members), e.opts.Population)]
+}
+
+// dominates reports whether trial a scores at least as well as b with at most as many
+// parameters, and is strictly better in one of the two.
+func dominates(a, b *Trial, lower bool) bool {
+	noWorse := !better(b.Score, a.Score, lower) && a.Params <= b.Params
+	return noWorse && (better(a.Score, b.Score, lower) || a.Params < b.Params)
+}
+
+// better reports whether score a beats score b.
+func better(a, b float64, lower bool) bool {
+	if lower {
+		return a < b
+	}
+	return a > b
+}
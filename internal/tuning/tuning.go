@@ -0,0 +1,464 @@
+// Package tuning searches for the architecture and training settings of a network that
+// score best on the validation rows of a dataset, by grid search, random search,
+// successive halving or Hyperband.
+package tuning
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-neuralnetwork/internal/data"
+	"go-neuralnetwork/internal/neuralnetwork"
+	"go-neuralnetwork/internal/training"
+)
+
+// Space lists the values to try for every setting. An empty list keeps the value of the
+// base Config passed to Search.
+type Space struct {
+	// Layers lists hidden layer specifications in the syntax of BuildSequential.
+	Layers []string
+	// Width, when its Max is set, replaces Layers: every trial has one of Depths dense
+	// hidden layers of a width between Min and Max, sampled log-uniformly.
+	Width  IntRange
+	Depths []int
+	// Activations lists hidden activations: a single name is used for every hidden layer
+	// and a comma-separated list names them in order.
+	Activations       []string
+	OutputActivations []string
+	// LearningRates lists learning rates. Random search, successive halving and Hyperband
+	// sample them log-uniformly between the smallest and the largest.
+	LearningRates []float64
+	// Epochs lists training lengths. Successive halving and Hyperband give the largest to
+	// the configurations that reach their last rung and fewer to the others.
+	Epochs []int
+}
+
+// IntRange is an inclusive range of whole numbers.
+type IntRange struct {
+	Min, Max int
+}
+
+// Strategy selects how Search picks the configurations it trains.
+type Strategy string
+
+const (
+	// Grid trains every combination of the values of the space.
+	Grid Strategy = "grid"
+	// Random trains Trials configurations sampled from the space.
+	Random Strategy = "random"
+	// Halving trains Trials sampled configurations for a few epochs, keeps the best
+	// 1/Eta of them, trains those Eta times longer, and so on up to the largest epochs.
+	Halving Strategy = "halving"
+	// Hyperband runs successive halving several times, from many configurations trained
+	// briefly to a few trained for the largest epochs from the start.
+	Hyperband Strategy = "hyperband"
+	// Bayesian samples its first trials at random and then lets a Tree-structured Parzen
+	// Estimator fitted to the scores so far pick the settings most likely to improve on
+	// the best, for Trials trials in total.
+	Bayesian Strategy = "bayesian"
+)
+
+// Options configures Search.
+type Options struct {
+	Strategy Strategy
+	// Trials is the number of configurations random search, successive halving and
+	// Bayesian search train, and the most Hyperband starts a round of successive halving
+	// with; 0 means 10.
+	Trials int
+	// Metric names the validation metric to optimise, as reported by training.Evaluate;
+	// "" means the loss. Losses and errors are minimised and other metrics maximised.
+	Metric string
+	// Eta is the factor by which successive halving cuts the configurations and grows
+	// their epochs from one rung to the next; 0 means 3.
+	Eta int
+	// Parallel is the number of trials trained at the same time; 0 means one per CPU.
+	Parallel int
+	// Seed drives the sampling of configurations; 0 picks a seed from the clock.
+	Seed int64
+	// History names a JSON file that Bayesian search saves its trials to after every
+	// batch. An existing history is loaded first, so an interrupted search resumes
+	// where it stopped and its trials count towards Trials.
+	History string
+}
+
+// MetricName returns the name of the validation metric the search optimises.
+func (o Options) MetricName() string {
+	if o.Metric == "" {
+		return "loss"
+	}
+	return o.Metric
+}
+
+// Trial is one configuration tried by Search with its validation scores.
+type Trial struct {
+	ID int
+	// Config holds the settings of the trial, with the epochs it has been trained for.
+	Config training.Config
+	// Rung is the last round of successive halving the trial took part in.
+	Rung    int
+	Score   float64
+	Metrics []training.Metric
+	Model   *data.ModelData
+	// Params is the number of trainable parameters of the model.
+	Params int
+	Err    error
+
+	// candidate holds the values of the space the trial was created from.
+	candidate candidate
+}
+
+// Result holds the trials of a search.
+type Result struct {
+	// Metric is the name of the validation metric the trials are ranked by.
+	Metric string
+	// Leaderboard holds every trial, best first; failed trials come last.
+	Leaderboard []Trial
+}
+
+// Best returns the best trial with a trained model. Trials loaded from the history of
+// a Bayesian search have none.
+func (r *Result) Best() Trial {
+	for _, t := range r.Leaderboard {
+		if t.Model != nil {
+			return t
+		}
+	}
+	return r.Leaderboard[0]
+}
+
+// Search trains networks for the dataset with settings from the space and ranks them by
+// their validation metric; the dataset needs validation rows. Settings the space leaves
+// out come from base. Trials are trained in parallel; every trial is sent to progress
+// each time it is scored, and progress is closed at the end. progress may be nil.
+func Search(dataset *data.Dataset, base training.Config, space Space, opts Options, progress chan<- Trial) (*Result, error) {
+	if progress != nil {
+		defer close(progress)
+	}
+	if len(dataset.ValidationInputs) == 0 {
+		return nil, fmt.Errorf("tuning needs validation rows to score the trials on")
+	}
+	s := &searcher{
+		dataset:  dataset,
+		base:     base,
+		space:    space.withDefaults(base),
+		metric:   opts.MetricName(),
+		trials:   opts.Trials,
+		eta:      opts.Eta,
+		parallel: opts.Parallel,
+		progress: progress,
+	}
+	if s.trials < 1 {
+		s.trials = 10
+	}
+	if s.eta < 2 {
+		s.eta = 3
+	}
+	if s.parallel < 1 {
+		s.parallel = runtime.NumCPU()
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	s.rand = rand.New(rand.NewSource(seed))
+
+	maxEpochs := 0
+	for _, epochs := range s.space.Epochs {
+		maxEpochs = max(maxEpochs, epochs)
+	}
+	switch opts.Strategy {
+	case Grid:
+		s.runAll(s.add(s.space.grid()...), 0)
+	case Random:
+		s.runAll(s.add(s.sample(s.trials)...), 0)
+	case Halving:
+		s.halve(s.add(s.sample(s.trials)...), maxEpochs, rungs(s.trials, s.eta))
+	case Hyperband:
+		top := rungs(s.trials, s.eta) - 1
+		for bracket := top; bracket >= 0; bracket-- {
+			n := int(math.Ceil(float64(top+1) / float64(bracket+1) * math.Pow(float64(s.eta), float64(bracket))))
+			s.halve(s.add(s.sample(min(n, s.trials))...), maxEpochs, bracket+1)
+		}
+	case Bayesian:
+		if err := s.optimize(opts.History); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown tuning strategy %q", opts.Strategy)
+	}
+
+	// Successive halving compares trials within a rung, so trials trained for more epochs
+	// rank first there.
+	budgeted := opts.Strategy == Halving || opts.Strategy == Hyperband
+	lower := LowerIsBetter(s.metric)
+	leaderboard := make([]Trial, len(s.all))
+	for i, t := range s.all {
+		leaderboard[i] = *t
+	}
+	sort.SliceStable(leaderboard, func(i, j int) bool {
+		a, b := leaderboard[i], leaderboard[j]
+		if (a.Err == nil) != (b.Err == nil) {
+			return a.Err == nil
+		}
+		if budgeted && a.Config.Epochs != b.Config.Epochs {
+			return a.Config.Epochs > b.Config.Epochs
+		}
+		return better(a.Score, b.Score, lower)
+	})
+	if len(leaderboard) == 0 {
+		return nil, fmt.Errorf("the search space is empty")
+	}
+	if leaderboard[0].Err != nil {
+		return nil, fmt.Errorf("every trial failed, for example: %w", leaderboard[0].Err)
+	}
+	result := &Result{Metric: s.metric, Leaderboard: leaderboard}
+	if result.Best().Model == nil {
+		return nil, fmt.Errorf("none of the %d trials has a trained model", len(leaderboard))
+	}
+	return result, nil
+}
+
+// withDefaults fills the empty lists of the space with the settings of base.
+func (space Space) withDefaults(base training.Config) Space {
+	if len(space.Layers) == 0 {
+		space.Layers = []string{base.Layers}
+	}
+	if len(space.Depths) == 0 {
+		space.Depths = []int{1}
+	}
+	if len(space.Activations) == 0 {
+		space.Activations = []string{strings.Join(base.HiddenActivations, ",")}
+		if len(base.HiddenActivations) > 0 && slices.Equal(base.HiddenActivations[1:], base.HiddenActivations[:len(base.HiddenActivations)-1]) {
+			// The same activation throughout fits layers of any depth.
+			space.Activations = []string{base.HiddenActivations[0]}
+		}
+	}
+	if len(space.OutputActivations) == 0 {
+		space.OutputActivations = []string{base.OutputActivation}
+	}
+	if len(space.LearningRates) == 0 {
+		space.LearningRates = []float64{base.LearningRate}
+	}
+	if len(space.Epochs) == 0 {
+		space.Epochs = []int{base.Epochs}
+	}
+	return space
+}
+
+// candidate is one combination of values from the space.
+type candidate struct {
+	layers, activations, outputActivation string
+	learningRate                          float64
+	epochs                                int
+	// width and depth, when width is set, give the layers as depth dense layers of width.
+	width, depth int
+}
+
+// layerSpec returns the hidden layer specification of the candidate.
+func (c candidate) layerSpec() string {
+	if c.width == 0 {
+		return c.layers
+	}
+	widths := make([]string, c.depth)
+	for i := range widths {
+		widths[i] = strconv.Itoa(c.width)
+	}
+	return strings.Join(widths, ",")
+}
+
+// grid returns every combination of the values of the space.
+func (space Space) grid() []candidate {
+	architectures := make([]candidate, len(space.Layers))
+	for i, layers := range space.Layers {
+		architectures[i] = candidate{layers: layers}
+	}
+	if space.Width.Max > 0 {
+		architectures = nil
+		for _, width := range space.Width.values() {
+			for _, depth := range space.Depths {
+				architectures = append(architectures, candidate{width: width, depth: depth})
+			}
+		}
+	}
+	var candidates []candidate
+	for _, c := range architectures {
+		for _, c.activations = range space.Activations {
+			for _, c.outputActivation = range space.OutputActivations {
+				for _, c.learningRate = range space.LearningRates {
+					for _, c.epochs = range space.Epochs {
+						candidates = append(candidates, c)
+					}
+				}
+			}
+		}
+	}
+	return candidates
+}
+
+// values returns the widths a grid search tries: Min, doubled until it reaches Max, and Max.
+func (r IntRange) values() []int {
+	var values []int
+	for width := max(r.Min, 1); width < r.Max; width *= 2 {
+		values = append(values, width)
+	}
+	return append(values, r.Max)
+}
+
+// rungs returns the number of rounds successive halving needs to cut n configurations
+// down to one by a factor eta.
+func rungs(n, eta int) int {
+	r := 1
+	for size := eta; size <= n; size *= eta {
+		r++
+	}
+	return r
+}
+
+// LowerIsBetter reports whether smaller values of the named metric are better, as they
+// are for losses and errors.
+func LowerIsBetter(metric string) bool {
+	for _, suffix := range []string{"loss", "MAE", "RMSE", "MAPE", "median AE"} {
+		if strings.HasSuffix(metric, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// searcher holds the state of one Search.
+type searcher struct {
+	dataset  *data.Dataset
+	base     training.Config
+	space    Space
+	metric   string
+	trials   int
+	eta      int
+	parallel int
+	rand     *rand.Rand
+	progress chan<- Trial
+	all      []*Trial
+	mu       sync.Mutex
+}
+
+// sample draws n candidates from the space, with log-uniform learning rates and widths.
+func (s *searcher) sample(n int) []candidate {
+	params := s.space.params()
+	candidates := make([]candidate, n)
+	for i := range candidates {
+		for _, p := range params {
+			p.set(&candidates[i], p.uniform(s.rand))
+		}
+	}
+	return candidates
+}
+
+// add creates a trial for every candidate.
+func (s *searcher) add(candidates ...candidate) []*Trial {
+	trials := make([]*Trial, len(candidates))
+	for i, c := range candidates {
+		cfg := s.base
+		cfg.Layers, cfg.OutputActivation, cfg.LearningRate, cfg.Epochs = c.layerSpec(), c.outputActivation, c.learningRate, c.epochs
+		trials[i] = &Trial{ID: len(s.all) + 1, Config: cfg, candidate: c}
+		if strings.Contains(c.activations, ",") {
+			trials[i].Config.HiddenActivations = strings.Split(c.activations, ",")
+		} else {
+			trials[i].Config.HiddenActivations, trials[i].Err = neuralnetwork.RepeatActivation(training.InputShape(s.dataset), cfg.Layers, c.activations)
+		}
+		s.all = append(s.all, trials[i])
+	}
+	return trials
+}
+
+// halve runs successive halving over the trials in the given number of rungs, the last
+// of which trains the survivors for maxEpochs.
+func (s *searcher) halve(trials []*Trial, maxEpochs, rungs int) {
+	lower := LowerIsBetter(s.metric)
+	for rung := 0; rung < rungs && len(trials) > 0; rung++ {
+		epochs := max(maxEpochs/int(math.Pow(float64(s.eta), float64(rungs-1-rung))), 1)
+		for _, t := range trials {
+			t.Rung = rung
+		}
+		s.runAll(trials, epochs)
+		var survivors []*Trial
+		for _, t := range trials {
+			if t.Err == nil {
+				survivors = append(survivors, t)
+			}
+		}
+		sort.SliceStable(survivors, func(i, j int) bool { return better(survivors[i].Score, survivors[j].Score, lower) })
+		trials = survivors[:min(len(survivors), max(len(trials)/s.eta, 1))]
+	}
+}
+
+// runAll trains and scores the trials in parallel, each up to the given epochs, or its
+// own epochs when epochs is 0.
+func (s *searcher) runAll(trials []*Trial, epochs int) {
+	sem := make(chan struct{}, s.parallel)
+	var wg sync.WaitGroup
+	for _, t := range trials {
+		if t.Err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(t *Trial) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			target := epochs
+			if target == 0 {
+				target = t.Config.Epochs
+			}
+			s.run(t, target)
+			if s.progress != nil {
+				s.mu.Lock()
+				s.progress <- *t
+				s.mu.Unlock()
+			}
+		}(t)
+	}
+	wg.Wait()
+}
+
+// run trains the model of a trial, building it first if needed, until it has been
+// trained for epochs epochs, and scores it on the validation rows.
+func (s *searcher) run(t *Trial, epochs int) {
+	trained := 0
+	var nn *neuralnetwork.Sequential
+	if t.Model != nil {
+		nn, trained = t.Model.Model, t.Config.Epochs
+	} else {
+		var err error
+		if nn, err = training.BuildModel(s.dataset, t.Config); err != nil {
+			t.Err = err
+			return
+		}
+		t.Params = nn.NumParams()
+	}
+	cfg := t.Config
+	cfg.Epochs = epochs - trained
+	training.Fit(nn, s.dataset, cfg, nil)
+	t.Config.Epochs = epochs
+	t.Model = training.NewModelData(nn, s.dataset)
+	t.Metrics = training.EvaluateRows(t.Model, s.dataset.ValidationInputs, s.dataset.ValidationTargets)
+	t.Score, t.Err = score(t.Metrics, s.metric)
+}
+
+// score returns the value of the named metric.
+func score(metrics []training.Metric, name string) (float64, error) {
+	names := make([]string, len(metrics))
+	for i, metric := range metrics {
+		if metric.Name == name {
+			return metric.Value, nil
+		}
+		names[i] = metric.Name
+	}
+	return 0, fmt.Errorf("the model does not report metric %q; choose one of %s", name, strings.Join(names, ", "))
+}
@@ -0,0 +1,237 @@
+package tuning_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go-neuralnetwork/internal/data"
+	"go-neuralnetwork/internal/tempfile"
+	"go-neuralnetwork/internal/training"
+	"go-neuralnetwork/internal/tuning"
+)
+
+func loadDataset(t *testing.T) *data.Dataset {
+	t.Helper()
+	var csv strings.Builder
+	csv.WriteString("x,y,z\n")
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&csv, "%d,%d,%d\n", i, i%4, 2*i+i%4)
+	}
+	filePath, err := tempfile.CreateTempFileWithContent("tuning-*.csv", csv.String())
+	if err != nil {
+		t.Fatalf("Failed to create temp CSV: %v", err)
+	}
+	t.Cleanup(func() { os.Remove(filePath) })
+	dataset, err := data.LoadCSVWithOptions(filePath, data.LoadOptions{SplitRatio: 0.6, ValidationRatio: 0.2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return dataset
+}
+
+func TestSearch(t *testing.T) {
+	dataset := loadDataset(t)
+	base := training.Config{Layers: "4", HiddenActivations: []string{"tanh"}, OutputActivation: "linear", Epochs: 3, LearningRate: 0.01}
+	space := tuning.Space{
+		Layers:        []string{"4", "3,3"},
+		Activations:   []string{"tanh", "relu"},
+		LearningRates: []float64{0.001, 0.1},
+		Epochs:        []int{9},
+	}
+
+	result, err := tuning.Search(dataset, base, space, tuning.Options{Strategy: tuning.Grid, Seed: 1}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Leaderboard) != 8 {
+		t.Fatalf("Expected 8 grid trials, got %d", len(result.Leaderboard))
+	}
+	for i, trial := range result.Leaderboard {
+		if trial.Err != nil {
+			t.Fatalf("Unexpected error in trial %d: %v", trial.ID, trial.Err)
+		}
+		if len(trial.Config.HiddenActivations) != strings.Count(trial.Config.Layers, ",")+1 {
+			t.Errorf("Expected one activation per hidden layer of %q, got %v", trial.Config.Layers, trial.Config.HiddenActivations)
+		}
+		if i > 0 && trial.Score < result.Leaderboard[i-1].Score {
+			t.Errorf("Expected the leaderboard sorted by increasing loss, got %f after %f", trial.Score, result.Leaderboard[i-1].Score)
+		}
+	}
+	if best := result.Best(); best.Model == nil || best.Config.Epochs != 9 {
+		t.Errorf("Expected the best trial to hold a model trained for 9 epochs, got %+v", best)
+	}
+
+	progress := make(chan tuning.Trial)
+	sent := make(chan int)
+	go func() {
+		n := 0
+		for range progress {
+			n++
+		}
+		sent <- n
+	}()
+	result, err = tuning.Search(dataset, base, space, tuning.Options{Strategy: tuning.Halving, Trials: 9, Metric: "z RMSE", Parallel: 3, Seed: 2}, progress)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Nine trials for 1 epoch, the best three for 3 and the best one for 9.
+	if n := <-sent; n != 13 || len(result.Leaderboard) != 9 {
+		t.Errorf("Expected 13 scored rounds of 9 trials, got %d of %d", n, len(result.Leaderboard))
+	}
+	epochs := map[int]int{}
+	for _, trial := range result.Leaderboard {
+		epochs[trial.Config.Epochs]++
+		if lr := trial.Config.LearningRate; lr < 0.001 || lr > 0.1 {
+			t.Errorf("Expected a learning rate between 0.001 and 0.1, got %g", lr)
+		}
+	}
+	if epochs[1] != 6 || epochs[3] != 2 || epochs[9] != 1 || result.Best().Config.Epochs != 9 {
+		t.Errorf("Expected 6, 2 and 1 trials trained for 1, 3 and 9 epochs with the last one first, got %v", epochs)
+	}
+
+	result, err = tuning.Search(dataset, base, space, tuning.Options{Strategy: tuning.Hyperband, Trials: 3, Seed: 3}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// A round of successive halving from 3 trials and one of 2 trials at full length.
+	if len(result.Leaderboard) != 5 {
+		t.Errorf("Expected 5 Hyperband trials, got %d", len(result.Leaderboard))
+	}
+
+	for _, opts := range []tuning.Options{{Strategy: "annealing"}, {Strategy: tuning.Random, Metric: "accuracy"}} {
+		if _, err := tuning.Search(dataset, base, space, opts, nil); err == nil {
+			t.Errorf("Expected an error for options %+v, got nil", opts)
+		}
+	}
+	dataset.ValidationInputs, dataset.ValidationTargets = nil, nil
+	if _, err := tuning.Search(dataset, base, space, tuning.Options{Strategy: tuning.Random}, nil); err == nil {
+		t.Errorf("Expected an error without validation rows, got nil")
+	}
+}
+
+func TestMetricDirection(t *testing.T) {
+	if name := (tuning.Options{}).MetricName(); name != "loss" {
+		t.Errorf("Expected the loss by default, got %q", name)
+	}
+	for metric, lower := range map[string]bool{"loss": true, "y RMSE": true, "MAE": true, "MAPE": true, "median AE": true, "accuracy": false, "macro F1": false} {
+		if got := tuning.LowerIsBetter(metric); got != lower {
+			t.Errorf("LowerIsBetter(%q) = %v, expected %v", metric, got, lower)
+		}
+	}
+}
+
+func TestBayesianSearch(t *testing.T) {
+	dataset := loadDataset(t)
+	base := training.Config{Layers: "4", HiddenActivations: []string{"tanh"}, OutputActivation: "linear", Epochs: 3, LearningRate: 0.01}
+	space := tuning.Space{
+		Width:         tuning.IntRange{Min: 2, Max: 16},
+		Depths:        []int{1, 2},
+		Activations:   []string{"tanh", "relu"},
+		LearningRates: []float64{0.001, 0.1},
+	}
+	history := filepath.Join(t.TempDir(), "history.json")
+
+	opts := tuning.Options{Strategy: tuning.Bayesian, Trials: 8, Parallel: 2, Seed: 4, History: history}
+	result, err := tuning.Search(dataset, base, space, opts, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Leaderboard) != 8 {
+		t.Fatalf("Expected 8 trials, got %d", len(result.Leaderboard))
+	}
+	for _, trial := range result.Leaderboard {
+		layers := strings.Split(trial.Config.Layers, ",")
+		width, err := strconv.Atoi(layers[0])
+		if err != nil || width < 2 || width > 16 || len(layers) > 2 {
+			t.Errorf("Expected 1 or 2 layers of 2 to 16 neurons, got %q", trial.Config.Layers)
+		}
+		if lr := trial.Config.LearningRate; lr < 0.001 || lr > 0.1 {
+			t.Errorf("Expected a learning rate between 0.001 and 0.1, got %g", lr)
+		}
+	}
+
+	// Resuming loads the 8 trials and trains 2 more, and the best one again if it was
+	// loaded from the history.
+	opts.Trials = 10
+	result, err = tuning.Search(dataset, base, space, opts, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if n := len(result.Leaderboard); n < 10 || n > 11 || result.Best().Model == nil {
+		t.Errorf("Expected 10 or 11 trials and a trained best model, got %d", n)
+	}
+	content, err := os.ReadFile(history)
+	if err != nil {
+		t.Fatalf("Failed to read the history: %v", err)
+	}
+	var records []map[string]any
+	if err := json.Unmarshal(content, &records); err != nil || len(records) != len(result.Leaderboard) {
+		t.Errorf("Expected a history of %d trials, got %d (%v)", len(result.Leaderboard), len(records), err)
+	}
+
+	opts.Metric = "z MAE"
+	if _, err := tuning.Search(dataset, base, space, opts, nil); err == nil {
+		t.Errorf("Expected an error for a history scored by another metric, got nil")
+	}
+}
+
+func TestEvolve(t *testing.T) {
+	var csv strings.Builder
+	csv.WriteString("x,y,label\n")
+	for i := 0; i < 30; i++ {
+		label := "a"
+		if i%3 == 0 {
+			label = "b"
+		}
+		fmt.Fprintf(&csv, "%d,%d,%s\n", i, i%3, label)
+	}
+	filePath, err := tempfile.CreateTempFileWithContent("evolve-*.csv", csv.String())
+	if err != nil {
+		t.Fatalf("Failed to create temp CSV: %v", err)
+	}
+	defer os.Remove(filePath)
+	dataset, err := data.LoadCSVWithOptions(filePath, data.LoadOptions{SplitRatio: 0.6, ValidationRatio: 0.2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	base := training.Config{OutputActivation: "sigmoid", Epochs: 3, LearningRate: 0.05}
+
+	opts := tuning.EvolveOptions{Population: 4, Generations: 2, MaxParams: 80, Width: tuning.IntRange{Min: 1, Max: 16}, Parallel: 2, Seed: 5}
+	evolution, err := tuning.Evolve(dataset, base, opts, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if evolution.Metric != "accuracy" || len(evolution.Trials) < 4 || len(evolution.Trials) > 12 {
+		t.Errorf("Expected 4 to 12 architectures scored by accuracy, got %d by %s", len(evolution.Trials), evolution.Metric)
+	}
+	seen := map[string]bool{}
+	for _, trial := range evolution.Trials {
+		key := trial.Config.Layers + "/" + strings.Join(trial.Config.HiddenActivations, ",")
+		if seen[key] {
+			t.Errorf("Expected every architecture to be trained once, got %s twice", key)
+		}
+		seen[key] = true
+		if trial.Params == 0 || trial.Params > 80 {
+			t.Errorf("Expected a model of at most 80 parameters, got %d for %s", trial.Params, key)
+		}
+	}
+	for i := 1; i < len(evolution.Front); i++ {
+		prev, next := evolution.Front[i-1], evolution.Front[i]
+		if next.Params <= prev.Params || next.Score <= prev.Score {
+			t.Errorf("Expected larger models on the front to score better, got %d: %f after %d: %f", next.Params, next.Score, prev.Params, prev.Score)
+		}
+	}
+	if best := evolution.Best(); best.Model == nil {
+		t.Errorf("Expected the best architecture to hold a model")
+	}
+
+	opts.MaxParams = 5
+	if _, err := tuning.Evolve(dataset, base, opts, nil); err == nil {
+		t.Errorf("Expected an error for a budget no architecture fits, got nil")
+	}
+}